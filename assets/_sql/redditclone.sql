@@ -8,11 +8,99 @@ CREATE TABLE `users` (
   `username` varchar(100),
   `password` varchar(100),
   `user_id` int(11) AUTO_INCREMENT,
-  PRIMARY KEY (`user_id`)
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `bio` varchar(500) DEFAULT '',
+  `avatar_url` varchar(500) DEFAULT '',
+  `post_karma` int(11) DEFAULT 0,
+  `comment_karma` int(11) DEFAULT 0,
+  `role` varchar(20) DEFAULT 'user',
+  `nsfw_mode` varchar(10) DEFAULT 'hide',
+  `spoiler_mode` varchar(10) DEFAULT 'blur',
+  `email` varchar(255) DEFAULT NULL,
+  `email_verified` tinyint(1) NOT NULL DEFAULT 0,
+  PRIMARY KEY (`user_id`),
+  UNIQUE KEY `email` (`email`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8;
 
 DROP TABLE IF EXISTS `authorization`;
 CREATE TABLE `authorization` (
   `token` varchar(255),
-  `time_to` int(11)
+  `user_id` int(11) NOT NULL DEFAULT 0,
+  `time_to` int(11),
+  KEY `user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+DROP TABLE IF EXISTS `post_marks`;
+CREATE TABLE `post_marks` (
+  `user_id` int(11) NOT NULL,
+  `post_id` bigint(20) unsigned NOT NULL,
+  `kind` varchar(20) NOT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`user_id`, `post_id`, `kind`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+DROP TABLE IF EXISTS `messages`;
+CREATE TABLE `messages` (
+  `message_id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `sender_id` int(11) NOT NULL,
+  `recipient_id` int(11) NOT NULL,
+  `body` text NOT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `read_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`message_id`),
+  KEY `sender_recipient` (`sender_id`, `recipient_id`),
+  KEY `recipient_sender` (`recipient_id`, `sender_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+DROP TABLE IF EXISTS `user_blocks`;
+CREATE TABLE `user_blocks` (
+  `user_id` int(11) NOT NULL,
+  `blocked_id` int(11) NOT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`user_id`, `blocked_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+DROP TABLE IF EXISTS `reports`;
+CREATE TABLE `reports` (
+  `report_id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `reporter_id` int(11) NOT NULL,
+  `kind` varchar(20) NOT NULL,
+  `post_id` bigint(20) unsigned NOT NULL,
+  `comment_id` bigint(20) unsigned NOT NULL DEFAULT 0,
+  `reason` varchar(500) NOT NULL,
+  `resolved` tinyint(1) NOT NULL DEFAULT 0,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`report_id`),
+  UNIQUE KEY `reporter_item` (`reporter_id`, `kind`, `post_id`, `comment_id`),
+  KEY `item` (`kind`, `post_id`, `comment_id`, `resolved`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+DROP TABLE IF EXISTS `category_owners`;
+CREATE TABLE `category_owners` (
+  `category` varchar(100) NOT NULL,
+  `user_id` int(11) NOT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`category`, `user_id`),
+  KEY `user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+DROP TABLE IF EXISTS `category_flairs`;
+CREATE TABLE `category_flairs` (
+  `category` varchar(100) NOT NULL,
+  `text` varchar(64) NOT NULL,
+  `color` varchar(7) NOT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`category`, `text`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+DROP TABLE IF EXISTS `user_tokens`;
+CREATE TABLE `user_tokens` (
+  `token_hash` char(64) NOT NULL,
+  `user_id` int(11) NOT NULL,
+  `kind` varchar(10) NOT NULL,
+  `email` varchar(255) NOT NULL DEFAULT '',
+  `expires_at` int(11) NOT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`token_hash`),
+  KEY `user_kind` (`user_id`, `kind`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8;
@@ -4,9 +4,13 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"redditclone/pkg/blob"
 	"redditclone/pkg/database"
+	"redditclone/pkg/events"
 	"redditclone/pkg/handlers"
+	"redditclone/pkg/mailer"
 	"redditclone/pkg/middleware"
+	"redditclone/pkg/preview"
 	"redditclone/pkg/session"
 	"time"
 
@@ -17,6 +21,30 @@ import (
 const (
 	secretKey       = "kekw"
 	staticDirectory = "./web/"
+	uploadDirectory = "./uploads/"
+	maxImageSize    = 10 << 20
+	notificationTTL = 30 * 24 * time.Hour
+	draftTTL        = 30 * 24 * time.Hour
+	restoreWindow   = 24 * time.Hour
+	deletedTTL      = 30 * 24 * time.Hour
+	duplicateWindow = 7 * 24 * time.Hour
+	previewTimeout  = 5 * time.Second
+	previewMaxBytes = 512 << 10
+	archiveAge      = 180 * 24 * time.Hour
+	// archiveCollection receives archived posts, empty keeps them in place.
+	// Listings only read live posts, so moved posts leave the site.
+	archiveCollection = ""
+	// reportHideThreshold open reports hide a post or comment until a
+	// moderator looks at it, 0 turns auto-hiding off
+	reportHideThreshold = 5
+
+	siteURL = "http://localhost:8080"
+	// smtpAddr is host:port of the mail server, empty writes letters to mailDirectory
+	smtpAddr      = ""
+	smtpUser      = ""
+	smtpPassword  = ""
+	mailFrom      = "redditclone <noreply@localhost>"
+	mailDirectory = "./mail/"
 )
 
 func main() {
@@ -36,12 +64,47 @@ func main() {
 	defer databasePost.Close()
 	postBase, errPost := database.NewPostRepo(databasePost, databaseUser)
 	panicOnErr(errPost)
+	if archiveCollection != "" {
+		databaseArchive, errArchive := database.InitDatabasePost("mongodb://localhost:27017", "redditclone", archiveCollection)
+		panicOnErr(errArchive)
+		defer databaseArchive.Close()
+		panicOnErr(postBase.AttachArchive(databaseArchive))
+	}
+	postMarks := database.NewPostMarkRepo(databaseUser)
+	messages := database.NewMessageRepo(databaseUser)
+	reports := database.NewReportRepo(databaseUser)
+	categories := database.NewCategoryRepo(databaseUser)
+	tokens := database.NewTokenRepo(databaseUser)
+
+	databaseNotification, errNotification := database.InitDatabaseNotification("mongodb://localhost:27017", "redditclone", "notifications", notificationTTL)
+	panicOnErr(errNotification)
+	defer databaseNotification.Close()
+	notifications := database.NewNotificationRepo(databaseNotification)
+
+	databaseDraft, errDraft := database.InitDatabaseDraft("mongodb://localhost:27017", "redditclone", "drafts", draftTTL)
+	panicOnErr(errDraft)
+	defer databaseDraft.Close()
+	drafts := database.NewDraftRepo(databaseDraft)
 
 	databaseSession, err := session.InitDatabaseSession("root:testpass12345@(localhost:3306)", "redditclone")
 	panicOnErr(err)
 	sessionManager := session.InitSessionManager(databaseSession)
 	defer sessionManager.Close()
 
+	images, errImages := blob.NewFileStore(uploadDirectory, "/uploads/")
+	panicOnErr(errImages)
+
+	var mail mailer.Mailer
+	if smtpAddr != "" {
+		smtpMailer, errMailer := mailer.NewSMTPMailer(smtpAddr, smtpUser, smtpPassword, mailFrom)
+		panicOnErr(errMailer)
+		mail = smtpMailer
+	} else {
+		fileMailer, errMailer := mailer.NewFileMailer(mailDirectory, mailFrom)
+		panicOnErr(errMailer)
+		mail = fileMailer
+	}
+
 	zapLogger, _ := zap.NewProduction()
 	defer zapLogger.Sync()
 	logger := zapLogger.Sugar()
@@ -57,16 +120,114 @@ func main() {
 		}
 	}()
 
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		for {
+			<-ticker.C
+			posts, comments, errPurge := postBase.Purge(time.Now().Add(-deletedTTL))
+			if errPurge != nil {
+				logger.Errorf("purge: %s", errPurge)
+				continue
+			}
+			if posts != 0 || comments != 0 {
+				logger.Infof("purge: removed %d posts and %d comments", posts, comments)
+			}
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		for {
+			<-ticker.C
+			archived, errArchive := postBase.Archive(time.Now().Add(-archiveAge))
+			if errArchive != nil {
+				logger.Errorf("archive: %s", errArchive)
+				continue
+			}
+			if archived != 0 {
+				logger.Infof("archive: archived %d posts", archived)
+			}
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		for {
+			<-ticker.C
+			removed, errTokens := tokens.Purge(time.Now())
+			if errTokens != nil {
+				logger.Errorf("tokens: %s", errTokens)
+				continue
+			}
+			if removed != 0 {
+				logger.Infof("tokens: removed %d expired tokens", removed)
+			}
+		}
+	}()
+
 	userHandler := &handlers.UserHandler{
-		UserRepo:  userBase,
-		Logger:    logger,
-		SecretKey: secretKey,
+		UserRepo:      userBase,
+		PostRepo:      postBase,
+		PostMarks:     postMarks,
+		Messages:      messages,
+		Drafts:        drafts,
+		Notifications: notifications,
+		Tokens:        tokens,
+		Mailer:        mail,
+		SiteURL:       siteURL,
+		Logger:        logger,
+		SecretKey:     secretKey,
 	}
 
 	handler := &handlers.PostHandler{
-		PostRepo:  postBase,
-		Logger:    logger,
-		SecretKey: secretKey,
+		PostRepo:        postBase,
+		UserRepo:        userBase,
+		PostMarks:       postMarks,
+		Notifications:   notifications,
+		Drafts:          drafts,
+		CategoryRepo:    categories,
+		Blocks:          messages,
+		Events:          events.NewHub(),
+		RestoreWindow:   restoreWindow,
+		DuplicateWindow: duplicateWindow,
+		Previews:        preview.NewHTTPFetcher(previewTimeout, previewMaxBytes),
+		Images:          images,
+		MaxImageSize:    maxImageSize,
+		Logger:          logger,
+		SecretKey:       secretKey,
+	}
+
+	notificationHandler := &handlers.NotificationHandler{
+		Notifications: notifications,
+		Logger:        logger,
+	}
+
+	categoryHandler := &handlers.CategoryHandler{
+		UserRepo:     userBase,
+		CategoryRepo: categories,
+		Logger:       logger,
+	}
+
+	messageHandler := &handlers.MessageHandler{
+		UserRepo: userBase,
+		Messages: messages,
+		Logger:   logger,
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		for {
+			<-ticker.C
+			handler.PublishScheduled(time.Now())
+		}
+	}()
+
+	moderationHandler := &handlers.ModerationHandler{
+		PostRepo:      postBase,
+		UserRepo:      userBase,
+		Reports:       reports,
+		HideThreshold: reportHideThreshold,
+		Logger:        logger,
 	}
 
 	middleware := &middleware.Middleware{
@@ -80,18 +241,92 @@ func main() {
 
 	r.HandleFunc("/api/register", middleware.AddAuth(userHandler.Register)).Methods("POST")
 	r.HandleFunc("/api/login", middleware.AddAuth(userHandler.Login)).Methods("POST")
-	r.HandleFunc("/api/posts/", handler.Posts).Methods("GET")
+	r.HandleFunc("/api/verify", userHandler.EmailVerify).Methods("POST")
+	r.HandleFunc("/api/password/forgot", userHandler.PasswordForgot).Methods("POST")
+	r.HandleFunc("/api/password/reset", middleware.AddAuth(userHandler.PasswordReset)).Methods("POST")
+	r.HandleFunc("/api/posts/", middleware.OptionalAuth(handler.Posts)).Methods("GET")
 	r.HandleFunc("/api/posts", middleware.CheckAuth(handler.PostAdd)).Methods("POST")
-	r.HandleFunc("/api/posts/{category_name}", handler.Categories).Methods("GET")
-	r.HandleFunc("/api/post/{post_id:[0-9]+}", handler.PostGet).Methods("GET")
+	r.HandleFunc("/api/posts/image", middleware.CheckAuth(handler.ImageAdd)).Methods("POST")
+	r.HandleFunc("/api/posts/{category_name}", middleware.OptionalAuth(handler.Categories)).Methods("GET")
+	r.HandleFunc("/api/category/{category_name}/flairs", categoryHandler.Flairs).Methods("GET")
+	r.HandleFunc("/api/category/{category_name}/flairs", middleware.CheckAuth(categoryHandler.FlairSet)).Methods("POST")
+	r.HandleFunc("/api/category/{category_name}/flairs/{flair_text}", middleware.CheckAuth(categoryHandler.FlairRemove)).Methods("DELETE")
+	r.HandleFunc("/api/category/{category_name}/owners", categoryHandler.Owners).Methods("GET")
+	r.HandleFunc("/api/category/{category_name}/owners/{user_login}", middleware.CheckAuth(categoryHandler.OwnerAdd)).Methods("POST")
+	r.HandleFunc("/api/category/{category_name}/owners/{user_login}", middleware.CheckAuth(categoryHandler.OwnerRemove)).Methods("DELETE")
+	r.HandleFunc("/api/domain/{domain}", middleware.OptionalAuth(handler.DomainPosts)).Methods("GET")
+	r.HandleFunc("/api/events", handler.CategoryEvents).Methods("GET")
+	r.HandleFunc("/api/posts/{category_name}/events", handler.CategoryEvents).Methods("GET")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/events", middleware.OptionalAuth(handler.PostEvents)).Methods("GET")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}", middleware.OptionalAuth(handler.PostGet)).Methods("GET")
 	r.HandleFunc("/api/post/{post_id:[0-9]+}", middleware.CheckAuth(handler.CommentAdd)).Methods("POST")
 	r.HandleFunc("/api/post/{post_id:[0-9]+}/{comment_id:[0-9]+}", middleware.CheckAuth(handler.CommentRemove)).Methods("DELETE")
 	r.HandleFunc("/api/post/{post_id:[0-9]+}/upvote", middleware.CheckAuth(handler.PostRatingUp)).Methods("GET")
 	r.HandleFunc("/api/post/{post_id:[0-9]+}/unvote", middleware.CheckAuth(handler.PostRatingDefault)).Methods("GET")
 	r.HandleFunc("/api/post/{post_id:[0-9]+}/downvote", middleware.CheckAuth(handler.PostRatingDown)).Methods("GET")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/{comment_id:[0-9]+}/upvote", middleware.CheckAuth(handler.CommentRatingUp)).Methods("GET")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/{comment_id:[0-9]+}/unvote", middleware.CheckAuth(handler.CommentRatingDefault)).Methods("GET")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/{comment_id:[0-9]+}/downvote", middleware.CheckAuth(handler.CommentRatingDown)).Methods("GET")
 	r.HandleFunc("/api/post/{post_id:[0-9]+}", middleware.CheckAuth(handler.PostRemove)).Methods("DELETE")
-	r.HandleFunc("/api/user/{user_login}", handler.UserPosts).Methods("GET")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/restore", middleware.CheckAuth(handler.PostRestore)).Methods("POST")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/{comment_id:[0-9]+}/restore", middleware.CheckAuth(handler.CommentRestore)).Methods("POST")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/crosspost", middleware.CheckAuth(handler.Crosspost)).Methods("POST")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/poll", middleware.CheckAuth(handler.PollVote)).Methods("POST")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/schedule", middleware.CheckAuth(handler.ScheduledEdit)).Methods("PUT")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/schedule", middleware.CheckAuth(handler.ScheduledCancel)).Methods("DELETE")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/pin", middleware.CheckAuth(handler.PostPin)).Methods("POST")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/unpin", middleware.CheckAuth(handler.PostUnpin)).Methods("POST")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/lock", middleware.CheckAuth(handler.PostLock)).Methods("POST")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/unlock", middleware.CheckAuth(handler.PostUnlock)).Methods("POST")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/marknsfw", middleware.CheckAuth(handler.PostMarkNSFW)).Methods("POST")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/unmarknsfw", middleware.CheckAuth(handler.PostUnmarkNSFW)).Methods("POST")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/spoiler", middleware.CheckAuth(handler.PostSpoiler)).Methods("POST")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/unspoiler", middleware.CheckAuth(handler.PostUnspoiler)).Methods("POST")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/save", middleware.CheckAuth(handler.PostSave)).Methods("POST")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/unsave", middleware.CheckAuth(handler.PostUnsave)).Methods("POST")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/hide", middleware.CheckAuth(handler.PostHide)).Methods("POST")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/unhide", middleware.CheckAuth(handler.PostUnhide)).Methods("POST")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/report", middleware.CheckAuth(moderationHandler.PostReport)).Methods("POST")
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/{comment_id:[0-9]+}/report", middleware.CheckAuth(moderationHandler.CommentReport)).Methods("POST")
+	r.HandleFunc("/api/moderation/queue", middleware.CheckAuth(moderationHandler.Queue)).Methods("GET")
+	r.HandleFunc("/api/moderation/post/{post_id:[0-9]+}/dismiss", middleware.CheckAuth(moderationHandler.ReportDismiss)).Methods("POST")
+	r.HandleFunc("/api/moderation/post/{post_id:[0-9]+}/remove", middleware.CheckAuth(moderationHandler.ReportRemove)).Methods("POST")
+	r.HandleFunc("/api/moderation/post/{post_id:[0-9]+}/{comment_id:[0-9]+}/dismiss", middleware.CheckAuth(moderationHandler.ReportDismiss)).Methods("POST")
+	r.HandleFunc("/api/moderation/post/{post_id:[0-9]+}/{comment_id:[0-9]+}/remove", middleware.CheckAuth(moderationHandler.ReportRemove)).Methods("POST")
+	r.HandleFunc("/api/drafts", middleware.CheckAuth(handler.DraftList)).Methods("GET")
+	r.HandleFunc("/api/drafts", middleware.CheckAuth(handler.DraftAdd)).Methods("POST")
+	r.HandleFunc("/api/drafts/{draft_id:[0-9a-f]{24}}", middleware.CheckAuth(handler.DraftUpdate)).Methods("PUT")
+	r.HandleFunc("/api/drafts/{draft_id:[0-9a-f]{24}}", middleware.CheckAuth(handler.DraftRemove)).Methods("DELETE")
+	r.HandleFunc("/api/drafts/{draft_id:[0-9a-f]{24}}/publish", middleware.CheckAuth(handler.DraftPublish)).Methods("POST")
+	r.HandleFunc("/api/user/me/scheduled", middleware.CheckAuth(handler.ScheduledPosts)).Methods("GET")
+	r.HandleFunc("/api/user/me/blocked", middleware.CheckAuth(messageHandler.BlockedUsers)).Methods("GET")
+	r.HandleFunc("/api/user/me/saved", middleware.CheckAuth(handler.SavedPosts)).Methods("GET")
+	r.HandleFunc("/api/user/me/profile", middleware.CheckAuth(userHandler.ProfileUpdate)).Methods("PATCH")
+	r.HandleFunc("/api/user/me/preferences", middleware.CheckAuth(userHandler.Preferences)).Methods("GET")
+	r.HandleFunc("/api/user/me/preferences", middleware.CheckAuth(userHandler.PreferencesUpdate)).Methods("PATCH")
+	r.HandleFunc("/api/user/me/password", middleware.AddAuth(middleware.CheckAuth(userHandler.PasswordChange))).Methods("PUT")
+	r.HandleFunc("/api/user/me/username", middleware.AddAuth(middleware.CheckAuth(userHandler.UsernameChange))).Methods("PUT")
+	r.HandleFunc("/api/user/me/export", middleware.CheckAuth(userHandler.Export)).Methods("GET")
+	r.HandleFunc("/api/user/me/email", middleware.CheckAuth(userHandler.Email)).Methods("GET")
+	r.HandleFunc("/api/user/me/email", middleware.CheckAuth(userHandler.EmailChange)).Methods("PUT")
+	r.HandleFunc("/api/user/me/email/verify", middleware.CheckAuth(userHandler.EmailVerifyResend)).Methods("POST")
+	r.HandleFunc("/api/user/me", middleware.AddAuth(middleware.CheckAuth(userHandler.AccountDelete))).Methods("DELETE")
+	r.HandleFunc("/api/notifications", middleware.CheckAuth(notificationHandler.Inbox)).Methods("GET")
+	r.HandleFunc("/api/notifications/read", middleware.CheckAuth(notificationHandler.NotificationsReadAll)).Methods("POST")
+	r.HandleFunc("/api/notifications/{notification_id:[0-9a-f]{24}}/read", middleware.CheckAuth(notificationHandler.NotificationRead)).Methods("POST")
+	r.HandleFunc("/api/messages", middleware.CheckAuth(messageHandler.Conversations)).Methods("GET")
+	r.HandleFunc("/api/messages", middleware.CheckAuth(messageHandler.MessageSend)).Methods("POST")
+	r.HandleFunc("/api/messages/{user_login}", middleware.CheckAuth(messageHandler.Thread)).Methods("GET")
+	r.HandleFunc("/api/messages/{user_login}/read", middleware.CheckAuth(messageHandler.ThreadRead)).Methods("POST")
+	r.HandleFunc("/api/messages/id/{user_id:[0-9]+}", middleware.CheckAuth(messageHandler.Thread)).Methods("GET")
+	r.HandleFunc("/api/messages/id/{user_id:[0-9]+}/read", middleware.CheckAuth(messageHandler.ThreadRead)).Methods("POST")
+	r.HandleFunc("/api/messages/{user_login}/block", middleware.CheckAuth(messageHandler.UserBlock)).Methods("POST")
+	r.HandleFunc("/api/messages/{user_login}/unblock", middleware.CheckAuth(messageHandler.UserUnblock)).Methods("POST")
+	r.HandleFunc("/api/user/{user_login}", middleware.OptionalAuth(handler.UserPosts)).Methods("GET")
+	r.HandleFunc("/api/user/{user_login}/profile", userHandler.Profile).Methods("GET")
+	r.HandleFunc("/api/user/{user_login}/comments", middleware.OptionalAuth(handler.UserComments)).Methods("GET")
 
+	r.PathPrefix("/uploads/").Handler(images.Handler())
 	r.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir(staticDirectory))))
 	r.PathPrefix("/").Handler(func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
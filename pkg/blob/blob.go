@@ -0,0 +1,89 @@
+package blob
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const cacheControl = "public, max-age=31536000, immutable"
+
+// Store keeps uploaded files. Names are flat, without directories, and are
+// expected to be derived from the content, so a stored file never changes.
+type Store interface {
+	Put(name string, data []byte) error
+	Delete(name string) error
+	URL(name string) string
+}
+
+type FileStore struct {
+	dir    string
+	prefix string
+}
+
+// NewFileStore keeps files in dir, they are served under the URL prefix by
+// the Handler.
+func NewFileStore(dir, prefix string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("blob: can`t create directory: %w", err)
+	}
+	return &FileStore{dir: dir, prefix: prefix}, nil
+}
+
+func checkName(name string) error {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("blob: bad name %q", name)
+	}
+	return nil
+}
+
+func (s *FileStore) Put(name string, data []byte) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(s.dir, name))
+}
+
+func (s *FileStore) Delete(name string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	err := os.Remove(filepath.Join(s.dir, name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *FileStore) URL(name string) string {
+	return s.prefix + name
+}
+
+// Handler serves stored files like the /static/ file server does, but
+// without directory listings and with long cache headers.
+func (s *FileStore) Handler() http.Handler {
+	files := http.FileServer(http.Dir(s.dir))
+	return http.StripPrefix(s.prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if checkName(r.URL.Path) != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Cache-Control", cacheControl)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		files.ServeHTTP(w, r)
+	}))
+}
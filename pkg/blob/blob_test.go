@@ -0,0 +1,59 @@
+package blob
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(filepath.Join(dir, "uploads"), "/uploads/")
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put("a.png", []byte("image")))
+	data, err := os.ReadFile(filepath.Join(dir, "uploads", "a.png"))
+	require.NoError(t, err)
+	require.Equal(t, string(data), "image")
+	require.Equal(t, store.URL("a.png"), "/uploads/a.png")
+
+	for _, name := range []string{"", "../a.png", "b/a.png", ".hidden"} {
+		require.Error(t, store.Put(name, []byte("image")), name)
+	}
+
+	type testCase struct {
+		path       string
+		statusCode int
+		body       string
+	}
+	testCases := []testCase{
+		{path: "/uploads/a.png", statusCode: http.StatusOK, body: "image"},
+		{path: "/uploads/b.png", statusCode: http.StatusNotFound},
+		{path: "/uploads/", statusCode: http.StatusNotFound},
+		{path: "/other/a.png", statusCode: http.StatusNotFound},
+	}
+	handler := store.Handler()
+	for _, testCase := range testCases {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", testCase.path, nil))
+		resp := w.Result()
+		require.Equal(t, resp.StatusCode, testCase.statusCode, testCase.path)
+		if testCase.statusCode != http.StatusOK {
+			continue
+		}
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, string(body), testCase.body)
+		require.Equal(t, resp.Header.Get("Cache-Control"), cacheControl)
+	}
+
+	require.NoError(t, store.Delete("a.png"))
+	require.NoError(t, store.Delete("a.png"))
+	_, err = os.Stat(filepath.Join(dir, "uploads", "a.png"))
+	require.True(t, os.IsNotExist(err))
+}
@@ -1,12 +1,74 @@
 package comment
 
 import (
+	"redditclone/pkg/frontendMessages"
+	"redditclone/pkg/markdown"
 	"redditclone/pkg/user"
+	"time"
+)
+
+const (
+	SortNew = "new"
+	SortTop = "top"
 )
 
 type Comment struct {
-	Author user.User `json:"author"`
-	Body   string    `json:"body"`
-	Time   string    `json:"created"`
-	ID     uint64    `json:"id,string"`
+	Author   user.User               `json:"author"`
+	Body     string                  `json:"body"`
+	BodyHTML string                  `json:"bodyHtml,omitempty" bson:"body_html,omitempty"`
+	Time     string                  `json:"created"`
+	ID       uint64                  `json:"id,string"`
+	Parent   *uint64                 `json:"parent,string,omitempty" bson:"parent,omitempty"`
+	Score    int64                   `json:"score"`
+	Votes    []frontendMessages.Vote `json:"votes,omitempty"`
+	MyVote   int                     `json:"myVote" bson:"-"`
+	Hidden   bool                    `json:"hidden,omitempty" bson:"hidden,omitempty"`
+	Blocked  bool                    `json:"blocked,omitempty" bson:"-"`
+	Mentions []user.User             `json:"mentions,omitempty" bson:"mentions,omitempty"`
+
+	DeletedAt    *time.Time `json:"-" bson:"deleted_at,omitempty"`
+	DeletedBy    *user.User `json:"-" bson:"deleted_by,omitempty"`
+	DeleteReason string     `json:"-" bson:"delete_reason,omitempty"`
+}
+
+type UserComment struct {
+	Comment   `bson:",inline"`
+	PostID    uint64 `json:"postId,string"`
+	PostTitle string `json:"postTitle"`
+}
+
+func (c *Comment) GetVotes() {
+	var score int64
+	for _, vote := range c.Votes {
+		score += int64(vote.Vote)
+	}
+	c.Score = score
+}
+
+func (c *Comment) MarkDeleted(by user.User, reason string) {
+	now := time.Now()
+	c.DeletedAt = &now
+	c.DeletedBy = &by
+	c.DeleteReason = reason
+}
+
+func (c *Comment) Restore() {
+	c.DeletedAt = nil
+	c.DeletedBy = nil
+	c.DeleteReason = ""
+}
+
+// Render stores the HTML of the body, call it whenever Body changes.
+func (c *Comment) Render() {
+	c.BodyHTML = markdown.Render(c.Body)
+}
+
+func (c *Comment) ForViewer(viewer *user.User) {
+	c.Votes, c.MyVote = frontendMessages.ViewerVotes(c.Votes, viewer)
+	if c.Hidden {
+		c.Body, c.BodyHTML = "", ""
+	}
+	if c.BodyHTML == "" {
+		c.Render()
+	}
 }
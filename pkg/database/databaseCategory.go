@@ -0,0 +1,100 @@
+package database
+
+import (
+	"redditclone/pkg/flair"
+	"redditclone/pkg/user"
+)
+
+func (d *DatabaseUser) AddCategoryOwner(category string, userID int64) (err error) {
+	_, err = d.database.Exec(
+		"INSERT IGNORE INTO category_owners (`category`, `user_id`) VALUES (?, ?)",
+		category,
+		userID,
+	)
+	return
+}
+
+func (d *DatabaseUser) RemoveCategoryOwner(category string, userID int64) (err error) {
+	_, err = d.database.Exec(
+		"DELETE FROM category_owners WHERE category = ? AND user_id = ?",
+		category,
+		userID,
+	)
+	return
+}
+
+func (d *DatabaseUser) IsCategoryOwner(category string, userID int64) (owner bool, err error) {
+	row := d.database.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM category_owners WHERE category = ? AND user_id = ?)",
+		category,
+		userID,
+	)
+	err = row.Scan(&owner)
+	return
+}
+
+func (d *DatabaseUser) GetCategoryOwners(category string) (res []user.User, err error) {
+	rows, err := d.database.Query(
+		"SELECT u.username, u.user_id FROM category_owners o "+
+			"JOIN users u ON u.user_id = o.user_id "+
+			"WHERE o.category = ? ORDER BY o.created_at",
+		category,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res = []user.User{}
+	for rows.Next() {
+		var usr user.User
+		if err = rows.Scan(&usr.Username, &usr.UserID); err != nil {
+			return nil, err
+		}
+		res = append(res, usr)
+	}
+	return res, rows.Err()
+}
+
+func (d *DatabaseUser) SetFlair(category string, flr flair.Flair) (err error) {
+	_, err = d.database.Exec(
+		"INSERT INTO category_flairs (`category`, `text`, `color`) VALUES (?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE color = VALUES(color)",
+		category,
+		flr.Text,
+		flr.Color,
+	)
+	return
+}
+
+func (d *DatabaseUser) RemoveFlair(category, text string) (removed bool, err error) {
+	result, err := d.database.Exec(
+		"DELETE FROM category_flairs WHERE category = ? AND text = ?",
+		category,
+		text,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected != 0, err
+}
+
+func (d *DatabaseUser) GetFlairs(category string) (res []flair.Flair, err error) {
+	rows, err := d.database.Query(
+		"SELECT text, color FROM category_flairs WHERE category = ? ORDER BY created_at, text",
+		category,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res = []flair.Flair{}
+	for rows.Next() {
+		var flr flair.Flair
+		if err = rows.Scan(&flr.Text, &flr.Color); err != nil {
+			return nil, err
+		}
+		res = append(res, flr)
+	}
+	return res, rows.Err()
+}
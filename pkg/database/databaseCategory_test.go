@@ -0,0 +1,74 @@
+package database
+
+import (
+	"fmt"
+	"redditclone/pkg/flair"
+	"redditclone/pkg/user"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestCategoryRepo(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err, "can`t create mock")
+	defer db.Close()
+
+	repo := NewCategoryRepo(&DatabaseUser{database: db})
+	owner := user.User{Username: "owner", UserID: 2}
+	news := flair.Flair{Text: "News", Color: "#ff4500"}
+
+	mock.
+		ExpectExec("INSERT IGNORE INTO category_owners").
+		WithArgs("music", int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.AddOwner("music", owner))
+
+	mock.
+		ExpectQuery("SELECT EXISTS").
+		WithArgs("music", int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	isOwner, err := repo.IsOwner("music", owner)
+	require.NoError(t, err)
+	require.True(t, isOwner)
+
+	mock.
+		ExpectQuery("SELECT u.username, u.user_id FROM category_owners").
+		WithArgs("music").
+		WillReturnRows(sqlmock.NewRows([]string{"username", "user_id"}).AddRow("owner", 2))
+	owners, err := repo.Owners("music")
+	require.NoError(t, err)
+	require.Equal(t, owners, []user.User{owner})
+
+	mock.
+		ExpectExec("DELETE FROM category_owners").
+		WithArgs("music", int64(2)).
+		WillReturnError(fmt.Errorf("test error"))
+	require.Error(t, repo.RemoveOwner("music", owner))
+
+	mock.
+		ExpectExec("INSERT INTO category_flairs").
+		WithArgs("music", "News", "#ff4500").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.SetFlair("music", news))
+
+	mock.
+		ExpectQuery("SELECT text, color FROM category_flairs").
+		WithArgs("music").
+		WillReturnRows(sqlmock.NewRows([]string{"text", "color"}).AddRow("News", "#ff4500"))
+	flairs, err := repo.Flairs("music")
+	require.NoError(t, err)
+	require.Equal(t, flairs, []flair.Flair{news})
+
+	mock.
+		ExpectExec("DELETE FROM category_flairs").
+		WithArgs("music", "Meta").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	removed, err := repo.RemoveFlair("music", "Meta")
+	require.NoError(t, err)
+	require.False(t, removed)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
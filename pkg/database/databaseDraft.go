@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"redditclone/pkg/draft"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DatabaseDraft interface {
+	Insert(drf *draft.Draft) error
+	Find(filter interface{}, drf *draft.Draft) error
+	GetAll(filter interface{}, opts ...*options.FindOptions) ([]draft.Draft, error)
+	Replace(filter interface{}, drf *draft.Draft) (int64, error)
+	Delete(filter interface{}) (int64, error)
+	Count(filter interface{}) (int64, error)
+	DeleteMany(filter interface{}) (int64, error)
+}
+
+type DatabaseDraftMongo struct {
+	database *mongo.Collection
+}
+
+// InitDatabaseDraft connects to the drafts collection, drafts untouched for
+// ttl are removed by MongoDB.
+func InitDatabaseDraft(path, databaseName, collenctionName string, ttl time.Duration) (*DatabaseDraftMongo, error) {
+	collection, err := connectMongo(path, databaseName, collenctionName)
+	if err != nil {
+		return nil, err
+	}
+	_, errIndex := collection.Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "updated", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "updated", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(ttl.Seconds())),
+		},
+	})
+	if errIndex != nil {
+		return nil, fmt.Errorf("mongodb: can`t create indexes: %w", errIndex)
+	}
+	return &DatabaseDraftMongo{
+		database: collection,
+	}, nil
+}
+
+func (d *DatabaseDraftMongo) Close() error {
+	return d.database.Database().Client().Disconnect(context.TODO())
+}
+
+func (d *DatabaseDraftMongo) Insert(drf *draft.Draft) (err error) {
+	_, err = d.database.InsertOne(context.TODO(), drf)
+	return
+}
+
+func (d *DatabaseDraftMongo) Find(filter interface{}, drf *draft.Draft) error {
+	return d.database.FindOne(context.TODO(), filter).Decode(drf)
+}
+
+func (d *DatabaseDraftMongo) GetAll(filter interface{}, opts ...*options.FindOptions) (drafts []draft.Draft, err error) {
+	cur, err := d.database.Find(context.TODO(), filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	err = cur.All(context.TODO(), &drafts)
+	return
+}
+
+func (d *DatabaseDraftMongo) Replace(filter interface{}, drf *draft.Draft) (int64, error) {
+	res, err := d.database.ReplaceOne(context.TODO(), filter, drf)
+	if err != nil {
+		return 0, err
+	}
+	return res.MatchedCount, nil
+}
+
+func (d *DatabaseDraftMongo) Delete(filter interface{}) (int64, error) {
+	res, err := d.database.DeleteOne(context.TODO(), filter)
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+func (d *DatabaseDraftMongo) Count(filter interface{}) (int64, error) {
+	return d.database.CountDocuments(context.TODO(), filter)
+}
+
+func (d *DatabaseDraftMongo) DeleteMany(filter interface{}) (int64, error) {
+	res, err := d.database.DeleteMany(context.TODO(), filter)
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
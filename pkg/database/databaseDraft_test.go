@@ -0,0 +1,91 @@
+package database
+
+import (
+	"fmt"
+	"redditclone/pkg/database/mocks"
+	"redditclone/pkg/draft"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestDraftRepo(t *testing.T) {
+	databaseDraft := &mocks.DatabaseDraft{}
+	repo := NewDraftRepo(databaseDraft)
+
+	databaseDraft.
+		On("Insert", mock.AnythingOfType("*draft.Draft")).
+		Return(nil).Once()
+	drf := &draft.Draft{UserID: 1, Post: &draft.Post{Title: "title"}}
+	require.NoError(t, repo.Add(drf))
+	require.False(t, drf.ID.IsZero())
+	require.False(t, drf.Updated.IsZero())
+
+	databaseDraft.
+		On("GetAll", bson.M{"user_id": int64(1)}, mock.Anything).
+		Return(nil, nil).Once()
+	drafts, err := repo.List(1)
+	require.NoError(t, err)
+	require.Equal(t, drafts, []draft.Draft{})
+
+	databaseDraft.
+		On("GetAll", bson.M{"user_id": int64(2)}, mock.Anything).
+		Return(nil, fmt.Errorf("test error")).Once()
+	_, err = repo.List(2)
+	require.Error(t, err)
+
+	_, ok, err := repo.Get(1, "wrong id")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	objectID := primitive.NewObjectID()
+	databaseDraft.
+		On("Find", bson.M{"_id": objectID, "user_id": int64(1)}, mock.AnythingOfType("*draft.Draft")).
+		Run(func(args mock.Arguments) {
+			*args.Get(1).(*draft.Draft) = *drf
+		}).
+		Return(nil).Once()
+	got, ok, err := repo.Get(1, objectID.Hex())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, got, *drf)
+
+	databaseDraft.
+		On("Find", bson.M{"_id": objectID, "user_id": int64(2)}, mock.AnythingOfType("*draft.Draft")).
+		Return(mongo.ErrNoDocuments).Once()
+	_, ok, err = repo.Get(2, objectID.Hex())
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	updated := drf.Updated
+	databaseDraft.
+		On("Replace", bson.M{"_id": drf.ID, "user_id": int64(1)}, drf).
+		Return(int64(1), nil).Once()
+	ok, err = repo.Update(drf)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.False(t, drf.Updated.Before(updated))
+
+	databaseDraft.
+		On("Delete", bson.M{"_id": objectID, "user_id": int64(1)}).
+		Return(int64(0), nil).Once()
+	ok, err = repo.Remove(1, objectID.Hex())
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	databaseDraft.
+		On("Count", bson.M{"user_id": int64(1)}).
+		Return(int64(3), nil).Once()
+	count, err := repo.Count(1)
+	require.NoError(t, err)
+	require.Equal(t, count, int64(3))
+
+	databaseDraft.
+		On("DeleteMany", bson.M{"user_id": int64(1)}).
+		Return(int64(3), nil).Once()
+	require.NoError(t, repo.RemoveAll(1))
+}
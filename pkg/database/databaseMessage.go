@@ -0,0 +1,170 @@
+package database
+
+import (
+	"redditclone/pkg/message"
+	"redditclone/pkg/user"
+)
+
+func (d *DatabaseUser) AddMessage(senderID, recipientID int64, body string) (msg message.Message, err error) {
+	result, err := d.database.Exec(
+		"INSERT INTO messages (`sender_id`, `recipient_id`, `body`) VALUES (?, ?, ?)",
+		senderID,
+		recipientID,
+		body,
+	)
+	if err != nil {
+		return
+	}
+	msg.ID, err = result.LastInsertId()
+	if err != nil {
+		return
+	}
+	var created string
+	row := d.database.QueryRow("SELECT created_at FROM messages WHERE message_id = ?", msg.ID)
+	err = row.Scan(&created)
+	msg.Body = body
+	msg.Created = sqlTimeToRFC3339(created)
+	return
+}
+
+// GetConversations returns the last message of every conversation of the
+// user, users who deleted their accounts are named user.DeletedUsername.
+func (d *DatabaseUser) GetConversations(userID int64) (res []message.Conversation, err error) {
+	rows, err := d.database.Query(
+		"SELECT m.message_id, m.sender_id, COALESCE(s.username, ?), m.recipient_id, COALESCE(r.username, ?), "+
+			"m.body, m.created_at, m.read_at IS NOT NULL, "+
+			"(SELECT COUNT(*) FROM messages u WHERE u.recipient_id = ? AND u.sender_id = IF(m.sender_id = ?, m.recipient_id, m.sender_id) AND u.read_at IS NULL) "+
+			"FROM messages m "+
+			"LEFT JOIN users s ON s.user_id = m.sender_id "+
+			"LEFT JOIN users r ON r.user_id = m.recipient_id "+
+			"WHERE m.message_id IN (SELECT MAX(message_id) FROM messages WHERE sender_id = ? OR recipient_id = ? "+
+			"GROUP BY LEAST(sender_id, recipient_id), GREATEST(sender_id, recipient_id)) "+
+			"ORDER BY m.message_id DESC",
+		user.DeletedUsername,
+		user.DeletedUsername,
+		userID,
+		userID,
+		userID,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res = []message.Conversation{}
+	for rows.Next() {
+		var (
+			cnv     message.Conversation
+			created string
+		)
+		msg := &cnv.LastMessage
+		err = rows.Scan(
+			&msg.ID, &msg.From.UserID, &msg.From.Username, &msg.To.UserID, &msg.To.Username,
+			&msg.Body, &created, &msg.Read, &cnv.Unread,
+		)
+		if err != nil {
+			return nil, err
+		}
+		msg.Created = sqlTimeToRFC3339(created)
+		cnv.With = msg.To
+		if msg.To.UserID == userID {
+			cnv.With = msg.From
+		}
+		res = append(res, cnv)
+	}
+	return res, rows.Err()
+}
+
+func (d *DatabaseUser) GetThread(usr, with user.User, page, limit int64) (res []message.Message, err error) {
+	rows, err := d.database.Query(
+		"SELECT message_id, sender_id, body, created_at, read_at IS NOT NULL FROM messages "+
+			"WHERE (sender_id = ? AND recipient_id = ?) OR (sender_id = ? AND recipient_id = ?) "+
+			"ORDER BY message_id DESC LIMIT ? OFFSET ?",
+		usr.UserID,
+		with.UserID,
+		with.UserID,
+		usr.UserID,
+		limit,
+		page*limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res = []message.Message{}
+	for rows.Next() {
+		var (
+			msg      message.Message
+			senderID int64
+			created  string
+		)
+		if err = rows.Scan(&msg.ID, &senderID, &msg.Body, &created, &msg.Read); err != nil {
+			return nil, err
+		}
+		msg.Created = sqlTimeToRFC3339(created)
+		msg.From, msg.To = usr, with
+		if senderID != usr.UserID {
+			msg.From, msg.To = with, usr
+		}
+		res = append(res, msg)
+	}
+	return res, rows.Err()
+}
+
+func (d *DatabaseUser) MarkThreadRead(userID, senderID int64) (err error) {
+	_, err = d.database.Exec(
+		"UPDATE messages SET read_at = NOW() WHERE recipient_id = ? AND sender_id = ? AND read_at IS NULL",
+		userID,
+		senderID,
+	)
+	return
+}
+
+func (d *DatabaseUser) AddBlock(userID, blockedID int64) (err error) {
+	_, err = d.database.Exec(
+		"INSERT IGNORE INTO user_blocks (`user_id`, `blocked_id`) VALUES (?, ?)",
+		userID,
+		blockedID,
+	)
+	return
+}
+
+func (d *DatabaseUser) RemoveBlock(userID, blockedID int64) (err error) {
+	_, err = d.database.Exec(
+		"DELETE FROM user_blocks WHERE user_id = ? AND blocked_id = ?",
+		userID,
+		blockedID,
+	)
+	return
+}
+
+func (d *DatabaseUser) IsBlocked(userID, blockedID int64) (blocked bool, err error) {
+	row := d.database.QueryRow(
+		"SELECT COUNT(*) > 0 FROM user_blocks WHERE user_id = ? AND blocked_id = ?",
+		userID,
+		blockedID,
+	)
+	err = row.Scan(&blocked)
+	return
+}
+
+func (d *DatabaseUser) GetBlocks(userID int64) (res []user.User, err error) {
+	rows, err := d.database.Query(
+		"SELECT u.username, u.user_id FROM user_blocks b JOIN users u ON u.user_id = b.blocked_id "+
+			"WHERE b.user_id = ? ORDER BY b.created_at DESC",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res = []user.User{}
+	for rows.Next() {
+		var usr user.User
+		if err = rows.Scan(&usr.Username, &usr.UserID); err != nil {
+			return nil, err
+		}
+		res = append(res, usr)
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"fmt"
+	"redditclone/pkg/message"
+	"redditclone/pkg/user"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestMessageRepo(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err, "can`t create mock")
+	defer db.Close()
+
+	repo := NewMessageRepo(&DatabaseUser{database: db})
+	usr := user.User{Username: "test", UserID: 1}
+	friend := user.User{Username: "friend", UserID: 2}
+
+	mock.
+		ExpectExec("INSERT INTO messages").
+		WithArgs(int64(1), int64(2), "hello").
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.
+		ExpectQuery("SELECT created_at FROM messages WHERE").
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow("2022-05-02 18:32:00"))
+	msg, err := repo.Send(usr, friend, "hello")
+	require.NoError(t, err)
+	require.Equal(t, message.Message{
+		ID: 7, From: usr, To: friend, Body: "hello", Created: "2022-05-02T18:32:00Z",
+	}, msg)
+
+	rows := sqlmock.NewRows([]string{"message_id", "sender_id", "body", "created_at", "read"})
+	rows.AddRow(8, 2, "hi", "2022-05-02 18:33:00", false)
+	rows.AddRow(7, 1, "hello", "2022-05-02 18:32:00", true)
+	mock.
+		ExpectQuery("SELECT message_id, sender_id, body, created_at, read_at IS NOT NULL FROM messages").
+		WithArgs(int64(1), int64(2), int64(2), int64(1), int64(10), int64(10)).
+		WillReturnRows(rows)
+	thread, err := repo.Thread(usr, friend, 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, []message.Message{
+		{ID: 8, From: friend, To: usr, Body: "hi", Created: "2022-05-02T18:33:00Z"},
+		{ID: 7, From: usr, To: friend, Body: "hello", Created: "2022-05-02T18:32:00Z", Read: true},
+	}, thread)
+
+	rows = sqlmock.NewRows([]string{"message_id", "sender_id", "sender", "recipient_id", "recipient", "body", "created_at", "read", "unread"})
+	rows.AddRow(8, 2, "friend", 1, "test", "hi", "2022-05-02 18:33:00", false, 1)
+	rows.AddRow(5, 1, "test", 3, user.DeletedUsername, "bye", "2022-05-01 10:00:00", true, 0)
+	mock.
+		ExpectQuery("SELECT m.message_id").
+		WithArgs(user.DeletedUsername, user.DeletedUsername, int64(1), int64(1), int64(1), int64(1)).
+		WillReturnRows(rows)
+	conversations, err := repo.Conversations(usr)
+	require.NoError(t, err)
+	deleted := user.User{Username: user.DeletedUsername, UserID: 3}
+	require.Equal(t, []message.Conversation{{
+		With:        friend,
+		LastMessage: message.Message{ID: 8, From: friend, To: usr, Body: "hi", Created: "2022-05-02T18:33:00Z"},
+		Unread:      1,
+	}, {
+		With:        deleted,
+		LastMessage: message.Message{ID: 5, From: usr, To: deleted, Body: "bye", Created: "2022-05-01T10:00:00Z", Read: true},
+	}}, conversations)
+
+	mock.
+		ExpectExec("UPDATE messages SET read_at").
+		WithArgs(int64(1), int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.MarkRead(usr, friend))
+
+	mock.
+		ExpectExec("INSERT IGNORE INTO user_blocks").
+		WithArgs(int64(1), int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.Block(usr, friend))
+
+	mock.
+		ExpectQuery("SELECT COUNT").
+		WithArgs(int64(2), int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"blocked"}).AddRow(true))
+	blocked, err := repo.IsBlocked(friend, usr)
+	require.NoError(t, err)
+	require.True(t, blocked)
+
+	mock.
+		ExpectQuery("SELECT u.username, u.user_id FROM user_blocks").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"username", "user_id"}).AddRow("friend", 2))
+	blocks, err := repo.Blocks(usr)
+	require.NoError(t, err)
+	require.Equal(t, []user.User{friend}, blocks)
+
+	mock.
+		ExpectExec("DELETE FROM user_blocks WHERE").
+		WithArgs(int64(1), int64(2)).
+		WillReturnError(fmt.Errorf("test error"))
+	require.Error(t, repo.Unblock(usr, friend))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
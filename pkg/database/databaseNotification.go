@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"redditclone/pkg/notification"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DatabaseNotification interface {
+	Insert(ntf *notification.Notification) error
+	GetAll(filter interface{}, opts ...*options.FindOptions) ([]notification.Notification, error)
+	Count(filter interface{}) (int64, error)
+	UpdateMany(filter interface{}, update interface{}) (int64, error)
+	DeleteMany(filter interface{}) (int64, error)
+}
+
+type DatabaseNotificationMongo struct {
+	database *mongo.Collection
+}
+
+func InitDatabaseNotification(path, databaseName, collenctionName string, ttl time.Duration) (*DatabaseNotificationMongo, error) {
+	collection, err := connectMongo(path, databaseName, collenctionName)
+	if err != nil {
+		return nil, err
+	}
+	_, errIndex := collection.Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "created", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(ttl.Seconds())),
+		},
+	})
+	if errIndex != nil {
+		return nil, fmt.Errorf("mongodb: can`t create indexes: %w", errIndex)
+	}
+	return &DatabaseNotificationMongo{
+		database: collection,
+	}, nil
+}
+
+func (d *DatabaseNotificationMongo) Close() error {
+	return d.database.Database().Client().Disconnect(context.TODO())
+}
+
+func (d *DatabaseNotificationMongo) Insert(ntf *notification.Notification) (err error) {
+	_, err = d.database.InsertOne(context.TODO(), ntf)
+	return
+}
+
+func (d *DatabaseNotificationMongo) GetAll(filter interface{}, opts ...*options.FindOptions) (ntfs []notification.Notification, err error) {
+	cur, err := d.database.Find(context.TODO(), filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	err = cur.All(context.TODO(), &ntfs)
+	return
+}
+
+func (d *DatabaseNotificationMongo) Count(filter interface{}) (int64, error) {
+	return d.database.CountDocuments(context.TODO(), filter)
+}
+
+func (d *DatabaseNotificationMongo) UpdateMany(filter interface{}, update interface{}) (int64, error) {
+	res, err := d.database.UpdateMany(context.TODO(), filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return res.MatchedCount, nil
+}
+
+func (d *DatabaseNotificationMongo) DeleteMany(filter interface{}) (int64, error) {
+	res, err := d.database.DeleteMany(context.TODO(), filter)
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"fmt"
+	"redditclone/pkg/database/mocks"
+	"redditclone/pkg/notification"
+	"redditclone/pkg/user"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNotificationRepo(t *testing.T) {
+	databaseNotification := &mocks.DatabaseNotification{}
+	repo := NewNotificationRepo(databaseNotification)
+
+	databaseNotification.
+		On("Insert", mock.AnythingOfType("*notification.Notification")).
+		Return(nil).Once()
+	ntf := &notification.Notification{UserID: 1, Kind: notification.KindPostReply, Read: true}
+	require.NoError(t, repo.Add(ntf))
+	require.False(t, ntf.ID.IsZero())
+	require.False(t, ntf.Created.IsZero())
+	require.False(t, ntf.Read)
+
+	databaseNotification.
+		On("GetAll", bson.M{"user_id": int64(1), "read": false}, mock.Anything).
+		Return(nil, nil).Once()
+	databaseNotification.
+		On("Count", bson.M{"user_id": int64(1), "read": false}).
+		Return(int64(3), nil).Once()
+	inbox, err := repo.List(1, true, 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, inbox, notification.Inbox{Unread: 3, Notifications: []notification.Notification{}})
+
+	databaseNotification.
+		On("GetAll", bson.M{"user_id": int64(1)}, mock.Anything).
+		Return(nil, fmt.Errorf("test error")).Once()
+	_, err = repo.List(1, false, 0, 10)
+	require.Error(t, err)
+
+	ok, err := repo.MarkRead(1, "wrong id")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	objectID := primitive.NewObjectID()
+	databaseNotification.
+		On("UpdateMany", bson.M{"_id": objectID, "user_id": int64(1)}, bson.M{"$set": bson.M{"read": true}}).
+		Return(int64(1), nil).Once()
+	ok, err = repo.MarkRead(1, objectID.Hex())
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	databaseNotification.
+		On("UpdateMany", bson.M{"user_id": int64(1), "read": false}, bson.M{"$set": bson.M{"read": true}}).
+		Return(int64(0), fmt.Errorf("test error")).Once()
+	require.Error(t, repo.MarkAllRead(1))
+
+	databaseNotification.
+		On("DeleteMany", bson.M{"user_id": int64(1)}).
+		Return(int64(2), nil).Once()
+	require.NoError(t, repo.RemoveAll(1))
+
+	from := user.User{Username: "test", UserID: 1}
+	to := user.User{Username: user.DeletedUsername}
+	databaseNotification.
+		On("UpdateMany", bson.M{"from": from}, bson.M{"$set": bson.M{"from": to}}).
+		Return(int64(4), nil).Once()
+	require.NoError(t, repo.RenameSender(from, to))
+}
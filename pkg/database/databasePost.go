@@ -16,13 +16,16 @@ type DatabasePost interface {
 	GetAll(filter interface{}, opts ...*options.FindOptions) ([]*post.Post, error)
 	Replace(pst post.Post) error
 	Delete(id uint64) error
+	Count(filter interface{}) (int64, error)
+	Aggregate(pipeline interface{}, results interface{}) error
+	UpdateMany(filter interface{}, update interface{}) (int64, error)
 }
 
 type DatabasePostMongo struct {
 	database *mongo.Collection
 }
 
-func InitDatabasePost(path, databaseName, collenctionName string) (*DatabasePostMongo, error) {
+func connectMongo(path, databaseName, collenctionName string) (*mongo.Collection, error) {
 	clientOptions := options.Client().ApplyURI(path)
 	client, errConnect := mongo.Connect(context.TODO(), clientOptions)
 	if errConnect != nil {
@@ -36,6 +39,26 @@ func InitDatabasePost(path, databaseName, collenctionName string) (*DatabasePost
 	if collection == nil {
 		return nil, fmt.Errorf(`mongodb: no such collection (has "nil" collection)`)
 	}
+	return collection, nil
+}
+
+func InitDatabasePost(path, databaseName, collenctionName string) (*DatabasePostMongo, error) {
+	collection, err := connectMongo(path, databaseName, collenctionName)
+	if err != nil {
+		return nil, err
+	}
+	_, errIndex := collection.Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "author", Value: 1}}},
+		{Keys: bson.D{{Key: "comments.author", Value: 1}}},
+		{Keys: bson.D{{Key: "domain", Value: 1}}},
+		{Keys: bson.D{{Key: "category", Value: 1}, {Key: "url", Value: 1}}},
+		{Keys: bson.D{{Key: "category", Value: 1}, {Key: "flair.text", Value: 1}}},
+		{Keys: bson.D{{Key: "publish_at", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "crosspost.post_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+	})
+	if errIndex != nil {
+		return nil, fmt.Errorf("mongodb: can`t create indexes: %w", errIndex)
+	}
 	return &DatabasePostMongo{
 		database: collection,
 	}, nil
@@ -72,3 +95,23 @@ func (d *DatabasePostMongo) Delete(id uint64) (err error) {
 	_, err = d.database.DeleteOne(context.TODO(), bson.M{"id": id})
 	return
 }
+
+func (d *DatabasePostMongo) Count(filter interface{}) (int64, error) {
+	return d.database.CountDocuments(context.TODO(), filter)
+}
+
+func (d *DatabasePostMongo) Aggregate(pipeline interface{}, results interface{}) (err error) {
+	cur, err := d.database.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return err
+	}
+	return cur.All(context.TODO(), results)
+}
+
+func (d *DatabasePostMongo) UpdateMany(filter interface{}, update interface{}) (int64, error) {
+	res, err := d.database.UpdateMany(context.TODO(), filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
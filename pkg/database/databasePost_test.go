@@ -3,15 +3,18 @@ package database
 import (
 	"encoding/json"
 	"fmt"
+	"redditclone/pkg/comment"
 	"redditclone/pkg/database/mocks"
 	"redditclone/pkg/post"
 	"redditclone/pkg/user"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
@@ -181,10 +184,247 @@ func TestPostFind(t *testing.T) {
 		On("Find", uint64(1), mock.AnythingOfType("*post.Post")).
 		Return(fmt.Errorf("not found error"))
 
+	postRepo.data.(*mocks.DatabasePost).
+		On("Find", uint64(2), mock.AnythingOfType("*post.Post")).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*post.Post).MarkDeleted(*users[0], "test")
+		}).
+		Return(nil)
+
 	res := postRepo.Find(0)
 	require.True(t, res)
 	res = postRepo.Find(1)
 	require.False(t, res)
+	res = postRepo.Find(2)
+	require.False(t, res, "deleted post")
+}
+
+func TestPostLockDeleted(t *testing.T) {
+	postRepo := setupMongo()
+	postRepo.postsMuxes[0] = &sync.Mutex{}
+	postRepo.postsMuxes[1] = &sync.Mutex{}
+
+	postRepo.data.(*mocks.DatabasePost).
+		On("Find", uint64(0), mock.AnythingOfType("*post.Post")).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*post.Post).MarkDeleted(*users[0], "test")
+		}).
+		Return(nil)
+	postRepo.data.(*mocks.DatabasePost).
+		On("Find", uint64(1), mock.AnythingOfType("*post.Post")).
+		Return(nil)
+
+	require.True(t, postRepo.LockDeleted(0))
+	require.False(t, postRepo.LockDeleted(1), "post isn`t deleted")
+	require.False(t, postRepo.LockDeleted(2))
+	require.True(t, postRepo.Lock(1), "failed LockDeleted must unlock post")
+}
+
+func TestPostPurge(t *testing.T) {
+	postRepo := setupMongo()
+	postRepo.postsMuxes[3] = &sync.Mutex{}
+	before := time.Now()
+
+	deleted := post.Post{ID: 3}
+	deleted.MarkDeleted(*users[0], "test")
+	postRepo.data.(*mocks.DatabasePost).
+		On("GetAll", bson.M{"deleted_at": bson.M{"$lt": before}}).
+		Return([]*post.Post{&deleted}, nil)
+	postRepo.data.(*mocks.DatabasePost).
+		On("Find", uint64(3), mock.AnythingOfType("*post.Post")).
+		Run(func(args mock.Arguments) {
+			*args.Get(1).(*post.Post) = deleted
+		}).
+		Return(nil)
+	postRepo.data.(*mocks.DatabasePost).
+		On("Delete", uint64(3)).
+		Return(nil)
+	postRepo.data.(*mocks.DatabasePost).
+		On("UpdateMany",
+			bson.M{"comments.deleted_at": bson.M{"$lt": before}},
+			bson.M{"$pull": bson.M{"comments": bson.M{"deleted_at": bson.M{"$lt": before}}}},
+		).
+		Return(int64(2), nil)
+
+	posts, comments, err := postRepo.Purge(before)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), posts)
+	require.Equal(t, int64(2), comments)
+	_, ok := postRepo.postsMuxes[3]
+	require.False(t, ok)
+}
+
+func TestPostDuplicate(t *testing.T) {
+	postRepo := setupMongo()
+	since := time.Now().Add(-time.Hour)
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.M{"id": -1})
+	findOptions.SetLimit(1)
+	filter := func(url string) bson.M {
+		return bson.M{
+			"category":   "music",
+			"url":        url,
+			"deleted_at": bson.M{"$exists": false},
+			"$expr": bson.M{"$gte": bson.A{
+				bson.M{"$dateFromString": bson.M{"dateString": "$time"}},
+				since,
+			}},
+		}
+	}
+	postRepo.data.(*mocks.DatabasePost).
+		On("GetAll", filter("https://example.com/"), findOptions).
+		Return([]*post.Post{{ID: 5, Time: time.Now().Format(time.RFC3339)}}, nil)
+	postRepo.data.(*mocks.DatabasePost).
+		On("GetAll", filter("https://example.com/old"), findOptions).
+		Return([]*post.Post{}, nil)
+	postRepo.data.(*mocks.DatabasePost).
+		On("GetAll", filter("https://example.com/error"), findOptions).
+		Return(nil, fmt.Errorf("test error"))
+
+	id, err := postRepo.Duplicate("music", "https://example.com/", since)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), id)
+
+	id, err = postRepo.Duplicate("music", "https://example.com/old", since)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), id)
+
+	_, err = postRepo.Duplicate("music", "https://example.com/error", since)
+	require.Error(t, err)
+}
+
+func TestPostArchive(t *testing.T) {
+	before := time.Now().Add(-time.Hour)
+	filter := bson.M{
+		"archived":   bson.M{"$ne": true},
+		"deleted_at": bson.M{"$exists": false},
+		"publish_at": bson.M{"$exists": false},
+		"$expr": bson.M{"$lt": bson.A{
+			bson.M{"$dateFromString": bson.M{"dateString": "$time"}},
+			before,
+		}},
+	}
+	old := post.Post{ID: 1, Time: before.Add(-time.Hour).Format(time.RFC3339)}
+	archived := old
+	archived.Archived = true
+
+	postRepo := setupMongo()
+	postRepo.postsMuxes[1] = &sync.Mutex{}
+	postRepo.data.(*mocks.DatabasePost).
+		On("GetAll", filter).
+		Return([]*post.Post{&old}, nil)
+	postRepo.data.(*mocks.DatabasePost).
+		On("Find", uint64(1), mock.AnythingOfType("*post.Post")).
+		Run(func(args mock.Arguments) {
+			*args.Get(1).(*post.Post) = old
+		}).
+		Return(nil)
+	postRepo.data.(*mocks.DatabasePost).
+		On("Replace", archived).
+		Return(nil)
+
+	count, err := postRepo.Archive(before)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+	postRepo.data.(*mocks.DatabasePost).AssertCalled(t, "Replace", archived)
+
+	postRepo = setupMongo()
+	postRepo.postsMuxes[1] = &sync.Mutex{}
+	archive := &mocks.DatabasePost{}
+	archive.On("GetAll", bson.M{}, options.Find()).Return([]*post.Post{{ID: 7}}, nil)
+	require.NoError(t, postRepo.AttachArchive(archive))
+	require.Equal(t, uint64(7), postRepo.idGetter)
+	_, ok := postRepo.postsMuxes[7]
+	require.True(t, ok)
+
+	postRepo.data.(*mocks.DatabasePost).
+		On("GetAll", filter).
+		Return([]*post.Post{&old}, nil)
+	postRepo.data.(*mocks.DatabasePost).
+		On("Find", uint64(1), mock.AnythingOfType("*post.Post")).
+		Run(func(args mock.Arguments) {
+			*args.Get(1).(*post.Post) = old
+		}).
+		Return(nil)
+	postRepo.data.(*mocks.DatabasePost).
+		On("Delete", uint64(1)).
+		Return(nil)
+	archive.On("Insert", archived).Return(nil)
+
+	count, err = postRepo.Archive(before)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+	archive.AssertCalled(t, "Insert", archived)
+	postRepo.data.(*mocks.DatabasePost).AssertCalled(t, "Delete", uint64(1))
+
+	postRepo.data.(*mocks.DatabasePost).
+		On("Find", uint64(7), mock.AnythingOfType("*post.Post")).
+		Return(mongo.ErrNoDocuments)
+	archive.On("Find", uint64(7), mock.AnythingOfType("*post.Post")).
+		Run(func(args mock.Arguments) {
+			*args.Get(1).(*post.Post) = post.Post{ID: 7, Archived: true}
+		}).
+		Return(nil)
+	pst, err := postRepo.Get(7)
+	require.NoError(t, err)
+	require.Equal(t, post.Post{ID: 7, Archived: true}, pst)
+	require.True(t, postRepo.Find(7), "read-through from the archive")
+}
+
+func TestPostPublish(t *testing.T) {
+	now := time.Now()
+	due := now.Add(-time.Minute)
+	moved := now.Add(time.Hour)
+
+	postRepo := setupMongo()
+	postRepo.postsMuxes[1] = &sync.Mutex{}
+	postRepo.postsMuxes[2] = &sync.Mutex{}
+	postRepo.data.(*mocks.DatabasePost).
+		On("GetAll", bson.M{
+			"publish_at": bson.M{"$lte": now},
+			"deleted_at": bson.M{"$exists": false},
+		}).
+		Return([]*post.Post{{ID: 1, PublishAt: &due}, {ID: 2, PublishAt: &due}}, nil)
+	postRepo.data.(*mocks.DatabasePost).
+		On("Find", uint64(1), mock.AnythingOfType("*post.Post")).
+		Run(func(args mock.Arguments) {
+			*args.Get(1).(*post.Post) = post.Post{ID: 1, PublishAt: &due}
+		}).
+		Return(nil)
+	postRepo.data.(*mocks.DatabasePost).
+		On("Find", uint64(2), mock.AnythingOfType("*post.Post")).
+		Run(func(args mock.Arguments) {
+			*args.Get(1).(*post.Post) = post.Post{ID: 2, PublishAt: &moved}
+		}).
+		Return(nil)
+	published := post.Post{ID: 1, Time: now.Format(time.RFC3339)}
+	postRepo.data.(*mocks.DatabasePost).
+		On("Replace", published).
+		Return(nil)
+
+	res, err := postRepo.Publish(now)
+	require.NoError(t, err)
+	require.Equal(t, []post.Post{published}, res)
+	postRepo.data.(*mocks.DatabasePost).AssertNumberOfCalls(t, "Replace", 1)
+}
+
+func TestPostMarkCrossposts(t *testing.T) {
+	postRepo := setupMongo()
+	archive := &mocks.DatabasePost{}
+	postRepo.archive = archive
+
+	filter := bson.M{"crosspost.post_id": uint64(3)}
+	removed := bson.M{"$set": bson.M{"crosspost.removed": true}}
+	restored := bson.M{"$unset": bson.M{"crosspost.removed": ""}}
+	postRepo.data.(*mocks.DatabasePost).On("UpdateMany", filter, removed).Return(int64(2), nil)
+	archive.On("UpdateMany", filter, removed).Return(int64(0), nil)
+	postRepo.data.(*mocks.DatabasePost).On("UpdateMany", filter, restored).Return(int64(0), fmt.Errorf("test error"))
+
+	require.NoError(t, postRepo.MarkCrossposts(3, true))
+	archive.AssertCalled(t, "UpdateMany", filter, removed)
+	require.Error(t, postRepo.MarkCrossposts(3, false))
+	archive.AssertNotCalled(t, "UpdateMany", filter, restored)
 }
 
 func TestPostGet(t *testing.T) {
@@ -265,24 +505,26 @@ func TestPostToJson(t *testing.T) {
 	postRepo.users = dbUser
 
 	for _, usr := range users {
-		rows := sqlmock.NewRows([]string{"username", "password", "user_id"})
-		rows.AddRow(usr.Username, usr.PasswordHash, usr.UserID)
+		rows := sqlmock.NewRows([]string{"username", "password", "user_id", "email", "email_verified"})
+		rows.AddRow(usr.Username, usr.PasswordHash, usr.UserID, usr.Email, usr.EmailVerified)
 		mock.
-			ExpectQuery("SELECT username, password, user_id FROM users WHERE").
+			ExpectQuery("SELECT username, password, user_id, (.+) FROM users WHERE username").
 			WithArgs(usr.Username).
 			WillReturnRows(rows)
 	}
-	rows := sqlmock.NewRows([]string{"username", "password", "user_id"})
-	rows.AddRow(1, 1, 1)
+	rows := sqlmock.NewRows([]string{"username", "password", "user_id", "email", "email_verified"})
+	rows.AddRow(1, 1, 1, "", false)
 	mock.
-		ExpectQuery("SELECT username, password, user_id FROM users WHERE").
+		ExpectQuery("SELECT username, password, user_id, (.+) FROM users WHERE username").
 		WithArgs("test username").
 		WillReturnRows(rows)
 
 	findOptions := options.Find()
-	findOptions.SetSort(bson.M{"score": -1})
+	findOptions.SetSort(bson.D{{Key: "pinned", Value: -1}, {Key: "score", Value: -1}})
 
-	case1json, errMarshal := json.Marshal(posts[0])
+	case1post := *posts[0]
+	case1post.ForViewer(nil)
+	case1json, errMarshal := json.Marshal(case1post)
 	require.NoError(t, errMarshal)
 	case1res := []byte("[" + string(case1json) + "]")
 
@@ -324,19 +566,142 @@ func TestPostToJson(t *testing.T) {
 
 	for _, testCase := range testCases {
 		filter := bson.M{
-			"category": testCase.category,
-			"author":   testCase.author,
+			"hidden":     bson.M{"$ne": true},
+			"deleted_at": bson.M{"$exists": false},
+			"publish_at": bson.M{"$exists": false},
+			"category":   testCase.category,
+			"author":     testCase.author,
 		}
 		postRepo.data.(*mocks.DatabasePost).
 			On("GetAll", filter, findOptions).
 			Return(testCase.resultDb, testCase.errDb)
-		res, err := postRepo.ToJson("music", testCase.author.Username)
+		res, err := postRepo.ToJson(post.Filter{Category: "music", Username: testCase.author.Username})
 
 		require.Equal(t, err, testCase.errJson)
 		require.Equal(t, res, testCase.resultJson)
 	}
 }
 
+func TestPostToJsonFlair(t *testing.T) {
+	postRepo := setupMongo()
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "pinned", Value: -1}, {Key: "score", Value: -1}})
+	filter := bson.M{
+		"hidden":     bson.M{"$ne": true},
+		"deleted_at": bson.M{"$exists": false},
+		"publish_at": bson.M{"$exists": false},
+		"category":   "music",
+		"flair.text": "News",
+	}
+	postRepo.data.(*mocks.DatabasePost).
+		On("GetAll", filter, findOptions).
+		Return([]*post.Post{}, nil)
+
+	res, err := postRepo.ToJson(post.Filter{Category: "music", Flair: "News"})
+	require.NoError(t, err)
+	require.Equal(t, res, []byte("[]"))
+}
+
+func TestPostToJsonPreferences(t *testing.T) {
+	postRepo := setupMongo()
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "score", Value: -1}})
+	filter := bson.M{
+		"hidden":     bson.M{"$ne": true},
+		"deleted_at": bson.M{"$exists": false},
+		"publish_at": bson.M{"$exists": false},
+		"nsfw":       bson.M{"$ne": true},
+	}
+	postRepo.data.(*mocks.DatabasePost).
+		On("GetAll", filter, findOptions).
+		Return([]*post.Post{{ID: 1, Spoiler: true}, {ID: 2}}, nil)
+
+	res, err := postRepo.ToJson(post.Filter{Preferences: user.DefaultPreferences()})
+	require.NoError(t, err)
+	listed := []post.Post{}
+	require.NoError(t, json.Unmarshal(res, &listed))
+	require.Len(t, listed, 2)
+	require.True(t, listed[0].Blurred)
+	require.False(t, listed[1].Blurred)
+}
+
+func TestPostToJsonBlocked(t *testing.T) {
+	postRepo := setupMongo()
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "score", Value: -1}})
+	filter := bson.M{
+		"hidden":        bson.M{"$ne": true},
+		"deleted_at":    bson.M{"$exists": false},
+		"publish_at":    bson.M{"$exists": false},
+		"author.userid": bson.M{"$nin": []int64{3}},
+	}
+	postRepo.data.(*mocks.DatabasePost).
+		On("GetAll", filter, findOptions).
+		Return([]*post.Post{{ID: 1, Comments: []comment.Comment{{Author: user.User{UserID: 3}, Body: "noise"}}}}, nil)
+
+	res, err := postRepo.ToJson(post.Filter{Blocked: []int64{3}})
+	require.NoError(t, err)
+	listed := []post.Post{}
+	require.NoError(t, json.Unmarshal(res, &listed))
+	require.Len(t, listed, 1)
+	require.Empty(t, listed[0].Comments)
+}
+
+func TestPostToJsonIDs(t *testing.T) {
+	postRepo := setupMongo()
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "score", Value: -1}})
+	filter := bson.M{
+		"hidden":     bson.M{"$ne": true},
+		"deleted_at": bson.M{"$exists": false},
+		"publish_at": bson.M{"$exists": false},
+		"id":         bson.M{"$in": []uint64{2, 3, 1}},
+	}
+	postRepo.data.(*mocks.DatabasePost).
+		On("GetAll", filter, findOptions).
+		Return([]*post.Post{{ID: 1, Score: 5}, {ID: 3, Score: 2}, {ID: 2, Score: 1}}, nil)
+
+	res, err := postRepo.ToJson(post.Filter{IDs: []uint64{2, 3, 1}})
+	require.NoError(t, err)
+	listed := []post.Post{}
+	require.NoError(t, json.Unmarshal(res, &listed))
+	require.Len(t, listed, 3)
+	require.Equal(t, []uint64{2, 3, 1}, []uint64{listed[0].ID, listed[1].ID, listed[2].ID})
+}
+
+func TestPostToJsonArchive(t *testing.T) {
+	postRepo := setupMongo()
+
+	res, err := postRepo.ToJson(post.Filter{Archive: true})
+	require.NoError(t, err)
+	require.Equal(t, res, []byte("[]"))
+
+	archive := &mocks.DatabasePost{}
+	archive.On("GetAll", bson.M{}, options.Find()).Return([]*post.Post{}, nil)
+	require.NoError(t, postRepo.AttachArchive(archive))
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "score", Value: -1}})
+	filter := bson.M{
+		"hidden":     bson.M{"$ne": true},
+		"deleted_at": bson.M{"$exists": false},
+		"publish_at": bson.M{"$exists": false},
+	}
+	archive.On("GetAll", filter, findOptions).Return([]*post.Post{{ID: 2, Archived: true}}, nil)
+
+	res, err = postRepo.ToJson(post.Filter{Archive: true})
+	require.NoError(t, err)
+	listed := []post.Post{}
+	require.NoError(t, json.Unmarshal(res, &listed))
+	require.Len(t, listed, 1)
+	require.Equal(t, listed[0].ID, uint64(2))
+	postRepo.data.(*mocks.DatabasePost).AssertNotCalled(t, "GetAll", mock.Anything, mock.Anything)
+}
+
 func TestPostRepoInit(t *testing.T) {
 	type testCase struct {
 		getAll []*post.Post
@@ -388,3 +753,144 @@ func TestPostRepoInit(t *testing.T) {
 		}
 	}
 }
+
+func TestPostUserComments(t *testing.T) {
+	dbUserSql, sqlMock, err := sqlmock.New()
+	require.NoError(t, err, "can`t connect to mysql")
+
+	postRepo := setupMongo()
+	postRepo.users = &DatabaseUser{
+		database: dbUserSql,
+	}
+
+	userComments := []comment.UserComment{{
+		Comment:   comment.Comment{Author: user.User{Username: "test1", UserID: 1}, Body: "test"},
+		PostID:    2,
+		PostTitle: "test2",
+	}}
+
+	for i := 0; i < 2; i++ {
+		rows := sqlmock.NewRows([]string{"username", "password", "user_id", "email", "email_verified"})
+		rows.AddRow("test1", "test1", 1, "", false)
+		sqlMock.
+			ExpectQuery("SELECT username, password, user_id, (.+) FROM users WHERE username").
+			WithArgs("test1").
+			WillReturnRows(rows)
+	}
+
+	postRepo.data.(*mocks.DatabasePost).
+		On("Aggregate", mock.AnythingOfType("primitive.A"), mock.AnythingOfType("*[]comment.UserComment")).
+		Run(func(args mock.Arguments) {
+			res := args.Get(1).(*[]comment.UserComment)
+			*res = userComments
+		}).
+		Return(nil).
+		Once()
+	postRepo.data.(*mocks.DatabasePost).
+		On("Aggregate", mock.AnythingOfType("primitive.A"), mock.AnythingOfType("*[]comment.UserComment")).
+		Return(fmt.Errorf("test error"))
+
+	res, err := postRepo.UserComments("test1", comment.SortNew, 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, userComments, res)
+
+	res, err = postRepo.UserComments("test1", comment.SortTop, 1, 10)
+	require.Error(t, err)
+	require.Nil(t, res)
+}
+
+func TestPostAuthored(t *testing.T) {
+	author := user.User{Username: "test1", UserID: 1}
+	filter := bson.M{"author": author}
+	sort := options.Find().SetSort(bson.D{{Key: "time", Value: -1}})
+	deletedAt := time.Now()
+
+	postRepo := setupMongo()
+	archive := &mocks.DatabasePost{}
+	archive.On("GetAll", bson.M{}, options.Find()).Return([]*post.Post{}, nil)
+	require.NoError(t, postRepo.AttachArchive(archive))
+	postRepo.data.(*mocks.DatabasePost).
+		On("GetAll", filter, sort).
+		Return([]*post.Post{{ID: 2, Author: author, Hidden: true}, {ID: 1, Author: author, DeletedAt: &deletedAt}}, nil)
+	archive.
+		On("GetAll", filter, sort).
+		Return([]*post.Post{{ID: 3, Author: author, Archived: true}}, nil)
+	postRepo.data.(*mocks.DatabasePost).
+		On("Aggregate", mock.AnythingOfType("primitive.A"), mock.AnythingOfType("*[]comment.UserComment")).
+		Run(func(args mock.Arguments) {
+			res := args.Get(1).(*[]comment.UserComment)
+			*res = []comment.UserComment{{Comment: comment.Comment{ID: 1, Author: author, DeletedAt: &deletedAt}, PostID: 4}}
+		}).
+		Return(nil)
+	archive.
+		On("Aggregate", mock.AnythingOfType("primitive.A"), mock.AnythingOfType("*[]comment.UserComment")).
+		Return(nil)
+
+	posts, comments, err := postRepo.Authored(user.User{Username: "test1", UserID: 1, Email: "test@example.com"})
+	require.NoError(t, err)
+	require.Equal(t, []uint64{2, 1, 3}, []uint64{posts[0].ID, posts[1].ID, posts[2].ID})
+	require.Equal(t, []comment.UserComment{{Comment: comment.Comment{ID: 1, Author: author, DeletedAt: &deletedAt}, PostID: 4}}, comments)
+
+	postRepo = setupMongo()
+	postRepo.data.(*mocks.DatabasePost).
+		On("GetAll", filter, sort).
+		Return(nil, fmt.Errorf("test error"))
+	_, _, err = postRepo.Authored(author)
+	require.Error(t, err)
+}
+
+func TestPostRenameAuthor(t *testing.T) {
+	from := user.User{Username: "test1", UserID: 1}
+	to := user.User{Username: user.DeletedUsername}
+	other := user.User{Username: "test2", UserID: 2}
+	filter := bson.M{"$or": bson.A{
+		bson.M{"author": from},
+		bson.M{"comments.author": from},
+		bson.M{"crosspost.author": from},
+		bson.M{"mentions": from},
+		bson.M{"comments.mentions": from},
+		bson.M{"deleted_by": from},
+		bson.M{"comments.deleted_by": from},
+	}}
+	found := post.Post{ID: 1, Author: other, Mentions: []user.User{from}, Comments: []comment.Comment{
+		{ID: 0, Author: from, Body: "first"},
+		{ID: 1, Author: other, Body: "second", Mentions: []user.User{other, from}},
+		{ID: 2, Author: other, DeletedBy: &from},
+	}}
+	renamed := post.Post{ID: 1, Author: other, Mentions: []user.User{to}, Comments: []comment.Comment{
+		{ID: 0, Author: to, Body: "first"},
+		{ID: 1, Author: other, Body: "second", Mentions: []user.User{other, to}},
+		{ID: 2, Author: other, DeletedBy: &to},
+	}}
+
+	postRepo := setupMongo()
+	postRepo.postsMuxes[1] = &sync.Mutex{}
+	postRepo.data.(*mocks.DatabasePost).
+		On("GetAll", filter).
+		Return([]*post.Post{&found}, nil)
+	postRepo.data.(*mocks.DatabasePost).
+		On("Find", uint64(1), mock.AnythingOfType("*post.Post")).
+		Run(func(args mock.Arguments) {
+			pst := found
+			pst.Mentions = append([]user.User{}, found.Mentions...)
+			pst.Comments = append([]comment.Comment{}, found.Comments...)
+			pst.Comments[1].Mentions = append([]user.User{}, found.Comments[1].Mentions...)
+			*args.Get(1).(*post.Post) = pst
+		}).
+		Return(nil)
+	postRepo.data.(*mocks.DatabasePost).
+		On("Replace", renamed).
+		Return(nil)
+
+	changed, err := postRepo.RenameAuthor(from, to)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), changed)
+	postRepo.data.(*mocks.DatabasePost).AssertCalled(t, "Replace", renamed)
+
+	postRepo = setupMongo()
+	postRepo.data.(*mocks.DatabasePost).
+		On("GetAll", filter).
+		Return(nil, fmt.Errorf("test error"))
+	_, err = postRepo.RenameAuthor(from, to)
+	require.Error(t, err)
+}
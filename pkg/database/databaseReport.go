@@ -0,0 +1,90 @@
+package database
+
+import (
+	"redditclone/pkg/report"
+	"strings"
+)
+
+const reasonsSeparator = "\x1f"
+
+// AddReport stores the report, or reopens the reporter's earlier report of
+// the same item if it was resolved. An open report of the item by the same
+// user leaves the row unchanged and ErrAlreadyReported is returned.
+func (d *DatabaseUser) AddReport(rpt report.Report) (count int64, err error) {
+	result, err := d.database.Exec(
+		"INSERT INTO reports (`reporter_id`, `kind`, `post_id`, `comment_id`, `reason`) VALUES (?, ?, ?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE reason = IF(resolved = 1, VALUES(reason), reason), "+
+			"created_at = IF(resolved = 1, NOW(), created_at), resolved = 0",
+		rpt.ReporterID,
+		rpt.Kind,
+		rpt.PostID,
+		rpt.CommentID,
+		rpt.Reason,
+	)
+	if err != nil {
+		return
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+	if affected == 0 {
+		return 0, ErrAlreadyReported
+	}
+	row := d.database.QueryRow(
+		"SELECT COUNT(*) FROM reports WHERE kind = ? AND post_id = ? AND comment_id = ? AND resolved = 0",
+		rpt.Kind,
+		rpt.PostID,
+		rpt.CommentID,
+	)
+	err = row.Scan(&count)
+	return
+}
+
+func (d *DatabaseUser) GetReportQueue(page, limit int64) (res []report.Item, err error) {
+	rows, err := d.database.Query(
+		"SELECT kind, post_id, comment_id, COUNT(*) AS reports, "+
+			"GROUP_CONCAT(DISTINCT reason SEPARATOR '"+reasonsSeparator+"'), MAX(created_at) AS last_reported "+
+			"FROM reports WHERE resolved = 0 GROUP BY kind, post_id, comment_id "+
+			"ORDER BY reports DESC, last_reported DESC LIMIT ? OFFSET ?",
+		limit,
+		page*limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res = []report.Item{}
+	for rows.Next() {
+		var (
+			item    report.Item
+			reasons string
+		)
+		err = rows.Scan(&item.Kind, &item.PostID, &item.CommentID, &item.Reports, &reasons, &item.LastReported)
+		if err != nil {
+			return nil, err
+		}
+		item.Reasons = strings.Split(reasons, reasonsSeparator)
+		item.LastReported = sqlTimeToRFC3339(item.LastReported)
+		res = append(res, item)
+	}
+	return res, rows.Err()
+}
+
+func (d *DatabaseUser) ResolveReports(target report.Target) (err error) {
+	_, err = d.database.Exec(
+		"UPDATE reports SET resolved = 1 WHERE kind = ? AND post_id = ? AND comment_id = ? AND resolved = 0",
+		target.Kind,
+		target.PostID,
+		target.CommentID,
+	)
+	return
+}
+
+func (d *DatabaseUser) ResolvePostReports(postID uint64) (err error) {
+	_, err = d.database.Exec(
+		"UPDATE reports SET resolved = 1 WHERE post_id = ? AND resolved = 0",
+		postID,
+	)
+	return
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"fmt"
+	"redditclone/pkg/report"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestReportRepo(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err, "can`t create mock")
+	defer db.Close()
+
+	repo := NewReportRepo(&DatabaseUser{database: db})
+	target := report.Target{Kind: report.KindComment, PostID: 1, CommentID: 3}
+
+	mock.
+		ExpectExec("INSERT INTO reports .* ON DUPLICATE KEY UPDATE").
+		WithArgs(int64(2), report.KindComment, uint64(1), uint64(3), "spam").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.
+		ExpectQuery("SELECT COUNT").
+		WithArgs(report.KindComment, uint64(1), uint64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+	count, err := repo.Add(report.Report{Target: target, ReporterID: 2, Reason: "spam"})
+	require.NoError(t, err)
+	require.Equal(t, int64(4), count)
+
+	// a resolved report of the same item is reopened
+	mock.
+		ExpectExec("INSERT INTO reports .* ON DUPLICATE KEY UPDATE").
+		WithArgs(int64(2), report.KindComment, uint64(1), uint64(3), "rude").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.
+		ExpectQuery("SELECT COUNT").
+		WithArgs(report.KindComment, uint64(1), uint64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	count, err = repo.Add(report.Report{Target: target, ReporterID: 2, Reason: "rude"})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+
+	// an open report of the same item is left as is
+	mock.
+		ExpectExec("INSERT INTO reports .* ON DUPLICATE KEY UPDATE").
+		WithArgs(int64(2), report.KindComment, uint64(1), uint64(3), "spam").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = repo.Add(report.Report{Target: target, ReporterID: 2, Reason: "spam"})
+	require.Equal(t, ErrAlreadyReported, err)
+
+	rows := sqlmock.NewRows([]string{"kind", "post_id", "comment_id", "reports", "reasons", "last_reported"})
+	rows.AddRow(report.KindComment, 1, 3, 4, "spam"+reasonsSeparator+"rude", "2022-05-02 18:32:00")
+	rows.AddRow(report.KindPost, 2, 0, 1, "spam", "2022-05-02 18:31:00")
+	mock.
+		ExpectQuery("SELECT kind, post_id, comment_id, COUNT").
+		WithArgs(int64(10), int64(10)).
+		WillReturnRows(rows)
+	items, err := repo.Queue(1, 10)
+	require.NoError(t, err)
+	require.Equal(t, []report.Item{
+		{Target: target, Reports: 4, Reasons: []string{"spam", "rude"}, LastReported: "2022-05-02T18:32:00Z"},
+		{Target: report.Target{Kind: report.KindPost, PostID: 2}, Reports: 1, Reasons: []string{"spam"}, LastReported: "2022-05-02T18:31:00Z"},
+	}, items)
+
+	mock.
+		ExpectExec("UPDATE reports SET resolved = 1 WHERE kind").
+		WithArgs(report.KindComment, uint64(1), uint64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	require.NoError(t, repo.Resolve(target))
+
+	mock.
+		ExpectExec("UPDATE reports SET resolved = 1 WHERE post_id").
+		WithArgs(uint64(1)).
+		WillReturnError(fmt.Errorf("test error"))
+	require.Error(t, repo.ResolvePost(1))
+
+	mock.
+		ExpectQuery("SELECT role FROM users").
+		WithArgs(int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("moderator"))
+	role, err := NewUserRepo(&DatabaseUser{database: db}).Role(2)
+	require.NoError(t, err)
+	require.Equal(t, "moderator", role)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,45 @@
+package database
+
+func (d *DatabaseUser) AddUserToken(tokenHash string, userID int64, kind, email string, expiresAt int64) (err error) {
+	_, err = d.database.Exec(
+		"INSERT INTO user_tokens (`token_hash`, `user_id`, `kind`, `email`, `expires_at`) VALUES (?, ?, ?, ?, ?)",
+		tokenHash,
+		userID,
+		kind,
+		email,
+		expiresAt,
+	)
+	return
+}
+
+func (d *DatabaseUser) GetUserToken(tokenHash, kind string) (userID int64, email string, expiresAt int64, err error) {
+	row := d.database.QueryRow(
+		"SELECT user_id, email, expires_at FROM user_tokens WHERE token_hash = ? AND kind = ? LIMIT 1",
+		tokenHash,
+		kind,
+	)
+	err = row.Scan(&userID, &email, &expiresAt)
+	return
+}
+
+func (d *DatabaseUser) RemoveUserToken(tokenHash string) (removed bool, err error) {
+	result, err := d.database.Exec("DELETE FROM user_tokens WHERE token_hash = ?", tokenHash)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected != 0, err
+}
+
+func (d *DatabaseUser) RemoveUserTokens(userID int64, kind string) (err error) {
+	_, err = d.database.Exec("DELETE FROM user_tokens WHERE user_id = ? AND kind = ?", userID, kind)
+	return
+}
+
+func (d *DatabaseUser) RemoveExpiredTokens(now int64) (removed int64, err error) {
+	result, err := d.database.Exec("DELETE FROM user_tokens WHERE expires_at < ?", now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
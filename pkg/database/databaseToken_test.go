@@ -0,0 +1,96 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestTokenRepo(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err, "can`t create mock")
+	defer db.Close()
+
+	repo := NewTokenRepo(&DatabaseUser{database: db})
+
+	mock.
+		ExpectExec("INSERT INTO user_tokens").
+		WithArgs(sqlmock.AnyArg(), int64(1), TokenVerify, "test1@example.com", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	token, err := repo.Issue(1, TokenVerify, "test1@example.com", time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, len(token), 2*tokenBytes)
+
+	type testCase struct {
+		expiresAt int64
+		getError  error
+		removed   int64
+
+		userID int64
+		email  string
+		err    error
+	}
+	testCases := []testCase{
+		{
+			expiresAt: time.Now().Add(time.Hour).Unix(),
+			removed:   1,
+			userID:    1,
+			email:     "test1@example.com",
+		},
+		{
+			getError: sql.ErrNoRows,
+			err:      ErrTokenInvalid,
+		},
+		{
+			expiresAt: time.Now().Add(-time.Hour).Unix(),
+			removed:   1,
+			err:       ErrTokenInvalid,
+		},
+		{
+			expiresAt: time.Now().Add(time.Hour).Unix(),
+			removed:   0,
+			err:       ErrTokenInvalid,
+		},
+	}
+	for caseNum, testCase := range testCases {
+		query := mock.
+			ExpectQuery("SELECT user_id, email, expires_at FROM user_tokens WHERE").
+			WithArgs(hashToken(token), TokenVerify)
+		if testCase.getError != nil {
+			query.WillReturnError(testCase.getError)
+		} else {
+			rows := sqlmock.NewRows([]string{"user_id", "email", "expires_at"})
+			rows.AddRow(1, "test1@example.com", testCase.expiresAt)
+			query.WillReturnRows(rows)
+			mock.
+				ExpectExec("DELETE FROM user_tokens WHERE token_hash").
+				WithArgs(hashToken(token)).
+				WillReturnResult(sqlmock.NewResult(0, testCase.removed))
+		}
+		userID, email, errTake := repo.Take(token, TokenVerify)
+		require.Equal(t, errTake, testCase.err, "case %d", caseNum)
+		require.Equal(t, userID, testCase.userID, "case %d", caseNum)
+		require.Equal(t, email, testCase.email, "case %d", caseNum)
+	}
+
+	mock.
+		ExpectExec("DELETE FROM user_tokens WHERE user_id").
+		WithArgs(int64(1), TokenReset).
+		WillReturnError(fmt.Errorf("test error"))
+	require.Error(t, repo.Revoke(1, TokenReset))
+
+	now := time.Now()
+	mock.
+		ExpectExec("DELETE FROM user_tokens WHERE expires_at").
+		WithArgs(now.Unix()).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	removed, err := repo.Purge(now)
+	require.NoError(t, err)
+	require.Equal(t, removed, int64(3))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
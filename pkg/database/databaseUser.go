@@ -4,10 +4,14 @@ import (
 	"database/sql"
 	"fmt"
 	"redditclone/pkg/user"
+	"strings"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 )
 
+const sqlTimeLayout = "2006-01-02 15:04:05"
+
 type DatabaseUser struct {
 	database *sql.DB
 }
@@ -32,9 +36,10 @@ func (d *DatabaseUser) Close() error {
 
 func (d *DatabaseUser) Add(usr user.User) (id int64, err error) {
 	result, err := d.database.Exec(
-		"INSERT INTO users (`username`, `password`) VALUES (?, ?)",
+		"INSERT INTO users (`username`, `password`, `email`) VALUES (?, ?, NULLIF(?, ''))",
 		usr.Username,
 		usr.PasswordHash,
+		usr.Email,
 	)
 	if err == nil {
 		id, err = result.LastInsertId()
@@ -43,7 +48,180 @@ func (d *DatabaseUser) Add(usr user.User) (id int64, err error) {
 }
 
 func (d *DatabaseUser) Get(username string) (usr user.User, err error) {
-	row := d.database.QueryRow("SELECT username, password, user_id FROM users WHERE username = ? LIMIT 1", username)
-	err = row.Scan(&usr.Username, &usr.PasswordHash, &usr.UserID)
+	row := d.database.QueryRow(
+		"SELECT username, password, user_id, COALESCE(email, ''), email_verified FROM users WHERE username = ? LIMIT 1",
+		username,
+	)
+	err = row.Scan(&usr.Username, &usr.PasswordHash, &usr.UserID, &usr.Email, &usr.EmailVerified)
+	return
+}
+
+func (d *DatabaseUser) GetByEmail(email string) (usr user.User, err error) {
+	row := d.database.QueryRow(
+		"SELECT username, password, user_id, email, email_verified FROM users WHERE email = ? LIMIT 1",
+		email,
+	)
+	err = row.Scan(&usr.Username, &usr.PasswordHash, &usr.UserID, &usr.Email, &usr.EmailVerified)
+	return
+}
+
+// UpdateEmail sets a new unverified email, empty email removes it.
+func (d *DatabaseUser) UpdateEmail(userID int64, email string) (err error) {
+	_, err = d.database.Exec(
+		"UPDATE users SET email = NULLIF(?, ''), email_verified = 0 WHERE user_id = ?",
+		email,
+		userID,
+	)
+	return
+}
+
+// VerifyEmail marks the email verified if the user still has it.
+func (d *DatabaseUser) VerifyEmail(userID int64, email string) (verified bool, err error) {
+	result, err := d.database.Exec(
+		"UPDATE users SET email_verified = 1 WHERE user_id = ? AND email = ?",
+		userID,
+		email,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected != 0, err
+}
+
+func (d *DatabaseUser) GetProfile(username string) (prf user.Profile, err error) {
+	var created string
+	row := d.database.QueryRow(
+		"SELECT username, user_id, created_at, bio, avatar_url, post_karma, comment_karma FROM users WHERE username = ? LIMIT 1",
+		username,
+	)
+	err = row.Scan(&prf.Username, &prf.UserID, &created, &prf.Bio, &prf.AvatarURL, &prf.PostKarma, &prf.CommentKarma)
+	if err != nil {
+		return
+	}
+	prf.Created = sqlTimeToRFC3339(created)
+	return
+}
+
+func sqlTimeToRFC3339(value string) string {
+	parsed, errParse := time.Parse(sqlTimeLayout, value)
+	if errParse != nil {
+		return value
+	}
+	return parsed.Format(time.RFC3339)
+}
+
+func (d *DatabaseUser) GetRole(userID int64) (role string, err error) {
+	row := d.database.QueryRow("SELECT role FROM users WHERE user_id = ? LIMIT 1", userID)
+	err = row.Scan(&role)
+	return
+}
+
+func (d *DatabaseUser) UpdateProfile(userID int64, bio, avatarURL string) (err error) {
+	_, err = d.database.Exec(
+		"UPDATE users SET bio = ?, avatar_url = ? WHERE user_id = ?",
+		bio,
+		avatarURL,
+		userID,
+	)
+	return
+}
+
+func (d *DatabaseUser) UpdatePassword(userID int64, passwordHash string) (err error) {
+	_, err = d.database.Exec("UPDATE users SET password = ? WHERE user_id = ?", passwordHash, userID)
+	return
+}
+
+func (d *DatabaseUser) UpdateUsername(userID int64, username string) (err error) {
+	_, err = d.database.Exec("UPDATE users SET username = ? WHERE user_id = ?", username, userID)
+	return
+}
+
+// Remove deletes the user row together with everything keyed by the user in
+// MySQL. Messages are kept so the other side of a conversation still has them.
+func (d *DatabaseUser) Remove(userID int64) (err error) {
+	queries := []string{
+		"DELETE FROM post_marks WHERE user_id = ?",
+		"DELETE FROM user_blocks WHERE user_id = ? OR blocked_id = ?",
+		"DELETE FROM category_owners WHERE user_id = ?",
+		"DELETE FROM user_tokens WHERE user_id = ?",
+		"DELETE FROM users WHERE user_id = ?",
+	}
+	for _, query := range queries {
+		args := []interface{}{userID}
+		if strings.Count(query, "?") == 2 {
+			args = append(args, userID)
+		}
+		if _, err = d.database.Exec(query, args...); err != nil {
+			return fmt.Errorf("remove user %d: %w", userID, err)
+		}
+	}
+	return nil
+}
+
+func (d *DatabaseUser) GetPreferences(userID int64) (prefs user.Preferences, err error) {
+	row := d.database.QueryRow("SELECT nsfw_mode, spoiler_mode FROM users WHERE user_id = ? LIMIT 1", userID)
+	err = row.Scan(&prefs.NSFW, &prefs.Spoiler)
+	return
+}
+
+func (d *DatabaseUser) UpdatePreferences(userID int64, prefs user.Preferences) (err error) {
+	_, err = d.database.Exec(
+		"UPDATE users SET nsfw_mode = ?, spoiler_mode = ? WHERE user_id = ?",
+		prefs.NSFW,
+		prefs.Spoiler,
+		userID,
+	)
+	return
+}
+
+func (d *DatabaseUser) AddKarma(userID int64, postKarma, commentKarma int64) (err error) {
+	_, err = d.database.Exec(
+		"UPDATE users SET post_karma = post_karma + ?, comment_karma = comment_karma + ? WHERE user_id = ?",
+		postKarma,
+		commentKarma,
+		userID,
+	)
+	return
+}
+
+func (d *DatabaseUser) AddPostMark(userID int64, postID uint64, kind string) (err error) {
+	_, err = d.database.Exec(
+		"INSERT IGNORE INTO post_marks (`user_id`, `post_id`, `kind`) VALUES (?, ?, ?)",
+		userID,
+		postID,
+		kind,
+	)
+	return
+}
+
+func (d *DatabaseUser) RemovePostMark(userID int64, postID uint64, kind string) (err error) {
+	_, err = d.database.Exec(
+		"DELETE FROM post_marks WHERE user_id = ? AND post_id = ? AND kind = ?",
+		userID,
+		postID,
+		kind,
+	)
 	return
 }
+
+func (d *DatabaseUser) GetPostMarks(userID int64, kind string) (res []uint64, err error) {
+	rows, err := d.database.Query(
+		"SELECT post_id FROM post_marks WHERE user_id = ? AND kind = ? ORDER BY created_at DESC",
+		userID,
+		kind,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res = []uint64{}
+	for rows.Next() {
+		var postID uint64
+		if err = rows.Scan(&postID); err != nil {
+			return nil, err
+		}
+		res = append(res, postID)
+	}
+	return res, rows.Err()
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"database/sql"
 	"fmt"
 	"redditclone/pkg/user"
 	"reflect"
@@ -53,7 +54,7 @@ func TestAddUser(t *testing.T) {
 		id := int64(i + 1)
 		mock.
 			ExpectExec("INSERT INTO users").
-			WithArgs(usr.Username, usr.PasswordHash).
+			WithArgs(usr.Username, usr.PasswordHash, usr.Email).
 			WillReturnResult(sqlmock.NewResult(id, 1))
 		err := repo.Add(usr)
 		if err != nil {
@@ -103,10 +104,10 @@ func TestGetUser(t *testing.T) {
 	}
 
 	for _, usr := range users {
-		rows := sqlmock.NewRows([]string{"username", "password", "user_id"})
-		rows.AddRow(usr.Username, usr.PasswordHash, usr.UserID)
+		rows := sqlmock.NewRows([]string{"username", "password", "user_id", "email", "email_verified"})
+		rows.AddRow(usr.Username, usr.PasswordHash, usr.UserID, usr.Email, usr.EmailVerified)
 		mock.
-			ExpectQuery("SELECT username, password, user_id FROM users WHERE").
+			ExpectQuery("SELECT username, password, user_id, (.+) FROM users WHERE username").
 			WithArgs(usr.Username).
 			WillReturnRows(rows)
 		user, ok := repo.Find(usr.Username)
@@ -119,7 +120,7 @@ func TestGetUser(t *testing.T) {
 	}
 
 	mock.
-		ExpectQuery("SELECT username, password, user_id FROM users WHERE").
+		ExpectQuery("SELECT username, password, user_id, (.+) FROM users WHERE username").
 		WithArgs("new user").
 		WillReturnError(fmt.Errorf("user not found"))
 	_, ok := repo.Find("new user")
@@ -134,3 +135,213 @@ func TestInitUserRepo(t *testing.T) {
 	require.Nil(t, res.data)
 	require.NotNil(t, res.mx)
 }
+
+func TestProfileUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err, "can`t create mock")
+	defer db.Close()
+
+	repo := UserRepoStruct{
+		data: &DatabaseUser{
+			database: db,
+		},
+		mx: &sync.Mutex{},
+	}
+
+	rows := sqlmock.NewRows([]string{"username", "user_id", "created_at", "bio", "avatar_url", "post_karma", "comment_karma"})
+	rows.AddRow("test1", 1, "2022-05-02 18:32:00", "bio", "https://example.com/a.png", 5, -1)
+	mock.
+		ExpectQuery("SELECT username, user_id, created_at, bio, avatar_url, post_karma, comment_karma FROM users WHERE").
+		WithArgs("test1").
+		WillReturnRows(rows)
+	prf, err := repo.Profile("test1")
+	require.NoError(t, err)
+	require.Equal(t, user.Profile{
+		Username:     "test1",
+		UserID:       1,
+		Created:      "2022-05-02T18:32:00Z",
+		Bio:          "bio",
+		AvatarURL:    "https://example.com/a.png",
+		PostKarma:    5,
+		CommentKarma: -1,
+	}, prf)
+
+	mock.
+		ExpectQuery("SELECT username, user_id, created_at, bio, avatar_url, post_karma, comment_karma FROM users WHERE").
+		WithArgs("new user").
+		WillReturnError(fmt.Errorf("user not found"))
+	_, err = repo.Profile("new user")
+	require.Error(t, err)
+
+	mock.
+		ExpectExec("UPDATE users SET bio").
+		WithArgs("new bio", "", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	err = repo.UpdateProfile(user.User{Username: "test1", UserID: 1}, "new bio", "")
+	require.NoError(t, err)
+
+	mock.
+		ExpectExec("UPDATE users SET post_karma").
+		WithArgs(int64(1), int64(0), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	err = repo.AddKarma(1, 1, 0)
+	require.NoError(t, err)
+
+	mock.
+		ExpectQuery("SELECT nsfw_mode, spoiler_mode FROM users WHERE").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"nsfw_mode", "spoiler_mode"}).AddRow("blur", "show"))
+	prefs, err := repo.Preferences(1)
+	require.NoError(t, err)
+	require.Equal(t, user.Preferences{NSFW: user.ContentBlur, Spoiler: user.ContentShow}, prefs)
+
+	mock.
+		ExpectExec("UPDATE users SET nsfw_mode").
+		WithArgs("show", "hide", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	err = repo.UpdatePreferences(user.User{Username: "test1", UserID: 1}, user.Preferences{NSFW: user.ContentShow, Spoiler: user.ContentHide})
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccountUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err, "can`t create mock")
+	defer db.Close()
+
+	repo := NewUserRepo(&DatabaseUser{database: db})
+	usr := user.User{Username: "test1", UserID: 1}
+
+	mock.
+		ExpectExec("UPDATE users SET password").
+		WithArgs("hash", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.UpdatePassword(usr, "hash"))
+
+	mock.
+		ExpectExec("UPDATE users SET username").
+		WithArgs("test2", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.Rename(usr, "test2"))
+
+	mock.
+		ExpectExec("DELETE FROM post_marks WHERE").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.
+		ExpectExec("DELETE FROM user_blocks WHERE").
+		WithArgs(int64(1), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.
+		ExpectExec("DELETE FROM category_owners WHERE").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.
+		ExpectExec("DELETE FROM user_tokens WHERE").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.
+		ExpectExec("DELETE FROM users WHERE").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.Remove(usr))
+
+	mock.
+		ExpectExec("DELETE FROM post_marks WHERE").
+		WithArgs(int64(1)).
+		WillReturnError(fmt.Errorf("test error"))
+	require.Error(t, repo.Remove(usr))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEmailUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err, "can`t create mock")
+	defer db.Close()
+
+	repo := NewUserRepo(&DatabaseUser{database: db})
+	usr := user.User{Username: "test1", PasswordHash: "hash", UserID: 1, Email: "test1@example.com", EmailVerified: true}
+
+	rows := sqlmock.NewRows([]string{"username", "password", "user_id", "email", "email_verified"})
+	rows.AddRow(usr.Username, usr.PasswordHash, usr.UserID, usr.Email, usr.EmailVerified)
+	mock.
+		ExpectQuery("SELECT username, password, user_id, email, email_verified FROM users WHERE email").
+		WithArgs(usr.Email).
+		WillReturnRows(rows)
+	found, ok := repo.FindByEmail(usr.Email)
+	require.True(t, ok)
+	require.Equal(t, found, usr)
+
+	mock.
+		ExpectQuery("SELECT username, password, user_id, email, email_verified FROM users WHERE email").
+		WithArgs("other@example.com").
+		WillReturnError(sql.ErrNoRows)
+	_, ok = repo.FindByEmail("other@example.com")
+	require.False(t, ok)
+
+	mock.
+		ExpectExec("UPDATE users SET email").
+		WithArgs("new@example.com", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.SetEmail(usr, "new@example.com"))
+
+	mock.
+		ExpectExec("UPDATE users SET email_verified = 1").
+		WithArgs(int64(1), "new@example.com").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	verified, err := repo.VerifyEmail(1, "new@example.com")
+	require.NoError(t, err)
+	require.True(t, verified)
+
+	mock.
+		ExpectExec("UPDATE users SET email_verified = 1").
+		WithArgs(int64(1), "test1@example.com").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	verified, err = repo.VerifyEmail(1, "test1@example.com")
+	require.NoError(t, err)
+	require.False(t, verified)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostMarkRepo(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err, "can`t create mock")
+	defer db.Close()
+
+	repo := NewPostMarkRepo(&DatabaseUser{database: db})
+
+	mock.
+		ExpectExec("INSERT IGNORE INTO post_marks").
+		WithArgs(int64(1), uint64(2), MarkSaved).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.Add(1, 2, MarkSaved))
+
+	mock.
+		ExpectExec("DELETE FROM post_marks WHERE").
+		WithArgs(int64(1), uint64(2), MarkHidden).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.Remove(1, 2, MarkHidden))
+
+	rows := sqlmock.NewRows([]string{"post_id"})
+	rows.AddRow(3)
+	rows.AddRow(2)
+	mock.
+		ExpectQuery("SELECT post_id FROM post_marks WHERE").
+		WithArgs(int64(1), MarkSaved).
+		WillReturnRows(rows)
+	ids, err := repo.List(1, MarkSaved)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{3, 2}, ids)
+
+	mock.
+		ExpectQuery("SELECT post_id FROM post_marks WHERE").
+		WithArgs(int64(1), MarkHidden).
+		WillReturnError(fmt.Errorf("test error"))
+	_, err = repo.List(1, MarkHidden)
+	require.Error(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -11,6 +11,41 @@ type DatabasePost struct {
 	mock.Mock
 }
 
+// Aggregate provides a mock function with given fields: pipeline, results
+func (_m *DatabasePost) Aggregate(pipeline interface{}, results interface{}) error {
+	ret := _m.Called(pipeline, results)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(interface{}, interface{}) error); ok {
+		r0 = rf(pipeline, results)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Count provides a mock function with given fields: filter
+func (_m *DatabasePost) Count(filter interface{}) (int64, error) {
+	ret := _m.Called(filter)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(interface{}) int64); ok {
+		r0 = rf(filter)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(interface{}) error); ok {
+		r1 = rf(filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Delete provides a mock function with given fields: id
 func (_m *DatabasePost) Delete(id uint64) error {
 	ret := _m.Called(id)
@@ -96,3 +131,24 @@ func (_m *DatabasePost) Replace(pst post.Post) error {
 
 	return r0
 }
+
+// UpdateMany provides a mock function with given fields: filter, update
+func (_m *DatabasePost) UpdateMany(filter interface{}, update interface{}) (int64, error) {
+	ret := _m.Called(filter, update)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(interface{}, interface{}) int64); ok {
+		r0 = rf(filter, update)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(interface{}, interface{}) error); ok {
+		r1 = rf(filter, update)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -3,7 +3,10 @@
 package mocks
 
 import mock "github.com/stretchr/testify/mock"
+import comment "redditclone/pkg/comment"
 import post "redditclone/pkg/post"
+import time "time"
+import user "redditclone/pkg/user"
 
 // PostRepo is an autogenerated mock type for the PostRepo type
 type PostRepo struct {
@@ -24,6 +27,108 @@ func (_m *PostRepo) Add(pst *post.Post) error {
 	return r0
 }
 
+// Archive provides a mock function with given fields: before
+func (_m *PostRepo) Archive(before time.Time) (int64, error) {
+	ret := _m.Called(before)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(time.Time) int64); ok {
+		r0 = rf(before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Authored provides a mock function with given fields: usr
+func (_m *PostRepo) Authored(usr user.User) ([]post.Post, []comment.UserComment, error) {
+	ret := _m.Called(usr)
+
+	var r0 []post.Post
+	if rf, ok := ret.Get(0).(func(user.User) []post.Post); ok {
+		r0 = rf(usr)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]post.Post)
+		}
+	}
+
+	var r1 []comment.UserComment
+	if rf, ok := ret.Get(1).(func(user.User) []comment.UserComment); ok {
+		r1 = rf(usr)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]comment.UserComment)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(user.User) error); ok {
+		r2 = rf(usr)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Counts provides a mock function with given fields: usr
+func (_m *PostRepo) Counts(usr user.User) (uint64, uint64, error) {
+	ret := _m.Called(usr)
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func(user.User) uint64); ok {
+		r0 = rf(usr)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	var r1 uint64
+	if rf, ok := ret.Get(1).(func(user.User) uint64); ok {
+		r1 = rf(usr)
+	} else {
+		r1 = ret.Get(1).(uint64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(user.User) error); ok {
+		r2 = rf(usr)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Duplicate provides a mock function with given fields: category, url, since
+func (_m *PostRepo) Duplicate(category string, url string, since time.Time) (uint64, error) {
+	ret := _m.Called(category, url, since)
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func(string, string, time.Time) uint64); ok {
+		r0 = rf(category, url, since)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, time.Time) error); ok {
+		r1 = rf(category, url, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Find provides a mock function with given fields: id
 func (_m *PostRepo) Find(id uint64) bool {
 	ret := _m.Called(id)
@@ -73,6 +178,85 @@ func (_m *PostRepo) Lock(id uint64) bool {
 	return r0
 }
 
+// LockDeleted provides a mock function with given fields: id
+func (_m *PostRepo) LockDeleted(id uint64) bool {
+	ret := _m.Called(id)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(uint64) bool); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// MarkCrossposts provides a mock function with given fields: originID, removed
+func (_m *PostRepo) MarkCrossposts(originID uint64, removed bool) error {
+	ret := _m.Called(originID, removed)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint64, bool) error); ok {
+		r0 = rf(originID, removed)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Publish provides a mock function with given fields: now
+func (_m *PostRepo) Publish(now time.Time) ([]post.Post, error) {
+	ret := _m.Called(now)
+
+	var r0 []post.Post
+	if rf, ok := ret.Get(0).(func(time.Time) []post.Post); ok {
+		r0 = rf(now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]post.Post)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Purge provides a mock function with given fields: before
+func (_m *PostRepo) Purge(before time.Time) (int64, int64, error) {
+	ret := _m.Called(before)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(time.Time) int64); ok {
+		r0 = rf(before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(time.Time) int64); ok {
+		r1 = rf(before)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(time.Time) error); ok {
+		r2 = rf(before)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // Remove provides a mock function with given fields: id
 func (_m *PostRepo) Remove(id uint64) bool {
 	ret := _m.Called(id)
@@ -87,13 +271,34 @@ func (_m *PostRepo) Remove(id uint64) bool {
 	return r0
 }
 
-// ToJson provides a mock function with given fields: category, username
-func (_m *PostRepo) ToJson(category string, username string) ([]byte, error) {
-	ret := _m.Called(category, username)
+// RenameAuthor provides a mock function with given fields: from, to
+func (_m *PostRepo) RenameAuthor(from user.User, to user.User) (int64, error) {
+	ret := _m.Called(from, to)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(user.User, user.User) int64); ok {
+		r0 = rf(from, to)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(user.User, user.User) error); ok {
+		r1 = rf(from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ToJson provides a mock function with given fields: filter
+func (_m *PostRepo) ToJson(filter post.Filter) ([]byte, error) {
+	ret := _m.Called(filter)
 
 	var r0 []byte
-	if rf, ok := ret.Get(0).(func(string, string) []byte); ok {
-		r0 = rf(category, username)
+	if rf, ok := ret.Get(0).(func(post.Filter) []byte); ok {
+		r0 = rf(filter)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]byte)
@@ -101,8 +306,8 @@ func (_m *PostRepo) ToJson(category string, username string) ([]byte, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(category, username)
+	if rf, ok := ret.Get(1).(func(post.Filter) error); ok {
+		r1 = rf(filter)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -138,6 +343,29 @@ func (_m *PostRepo) Update(pst post.Post) error {
 	return r0
 }
 
+// UserComments provides a mock function with given fields: username, sortBy, page, limit
+func (_m *PostRepo) UserComments(username string, sortBy string, page int64, limit int64) ([]comment.UserComment, error) {
+	ret := _m.Called(username, sortBy, page, limit)
+
+	var r0 []comment.UserComment
+	if rf, ok := ret.Get(0).(func(string, string, int64, int64) []comment.UserComment); ok {
+		r0 = rf(username, sortBy, page, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]comment.UserComment)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, int64, int64) error); ok {
+		r1 = rf(username, sortBy, page, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // getUserID provides a mock function with given fields: username
 func (_m *PostRepo) getUserID(username string) int64 {
 	ret := _m.Called(username)
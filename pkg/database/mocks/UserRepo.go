@@ -24,6 +24,20 @@ func (_m *UserRepo) Add(_a0 *user.User) error {
 	return r0
 }
 
+// AddKarma provides a mock function with given fields: userID, postKarma, commentKarma
+func (_m *UserRepo) AddKarma(userID int64, postKarma int64, commentKarma int64) error {
+	ret := _m.Called(userID, postKarma, commentKarma)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64, int64, int64) error); ok {
+		r0 = rf(userID, postKarma, commentKarma)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Find provides a mock function with given fields: username
 func (_m *UserRepo) Find(username string) (user.User, bool) {
 	ret := _m.Called(username)
@@ -44,3 +58,192 @@ func (_m *UserRepo) Find(username string) (user.User, bool) {
 
 	return r0, r1
 }
+
+// FindByEmail provides a mock function with given fields: email
+func (_m *UserRepo) FindByEmail(email string) (user.User, bool) {
+	ret := _m.Called(email)
+
+	var r0 user.User
+	if rf, ok := ret.Get(0).(func(string) user.User); ok {
+		r0 = rf(email)
+	} else {
+		r0 = ret.Get(0).(user.User)
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(string) bool); ok {
+		r1 = rf(email)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// Preferences provides a mock function with given fields: userID
+func (_m *UserRepo) Preferences(userID int64) (user.Preferences, error) {
+	ret := _m.Called(userID)
+
+	var r0 user.Preferences
+	if rf, ok := ret.Get(0).(func(int64) user.Preferences); ok {
+		r0 = rf(userID)
+	} else {
+		r0 = ret.Get(0).(user.Preferences)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int64) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Profile provides a mock function with given fields: username
+func (_m *UserRepo) Profile(username string) (user.Profile, error) {
+	ret := _m.Called(username)
+
+	var r0 user.Profile
+	if rf, ok := ret.Get(0).(func(string) user.Profile); ok {
+		r0 = rf(username)
+	} else {
+		r0 = ret.Get(0).(user.Profile)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Remove provides a mock function with given fields: usr
+func (_m *UserRepo) Remove(usr user.User) error {
+	ret := _m.Called(usr)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(user.User) error); ok {
+		r0 = rf(usr)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Rename provides a mock function with given fields: usr, username
+func (_m *UserRepo) Rename(usr user.User, username string) error {
+	ret := _m.Called(usr, username)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(user.User, string) error); ok {
+		r0 = rf(usr, username)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Role provides a mock function with given fields: userID
+func (_m *UserRepo) Role(userID int64) (string, error) {
+	ret := _m.Called(userID)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(int64) string); ok {
+		r0 = rf(userID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int64) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetEmail provides a mock function with given fields: usr, email
+func (_m *UserRepo) SetEmail(usr user.User, email string) error {
+	ret := _m.Called(usr, email)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(user.User, string) error); ok {
+		r0 = rf(usr, email)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdatePassword provides a mock function with given fields: usr, passwordHash
+func (_m *UserRepo) UpdatePassword(usr user.User, passwordHash string) error {
+	ret := _m.Called(usr, passwordHash)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(user.User, string) error); ok {
+		r0 = rf(usr, passwordHash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdatePreferences provides a mock function with given fields: usr, prefs
+func (_m *UserRepo) UpdatePreferences(usr user.User, prefs user.Preferences) error {
+	ret := _m.Called(usr, prefs)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(user.User, user.Preferences) error); ok {
+		r0 = rf(usr, prefs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateProfile provides a mock function with given fields: usr, bio, avatarURL
+func (_m *UserRepo) UpdateProfile(usr user.User, bio string, avatarURL string) error {
+	ret := _m.Called(usr, bio, avatarURL)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(user.User, string, string) error); ok {
+		r0 = rf(usr, bio, avatarURL)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// VerifyEmail provides a mock function with given fields: userID, email
+func (_m *UserRepo) VerifyEmail(userID int64, email string) (bool, error) {
+	ret := _m.Called(userID, email)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(int64, string) bool); ok {
+		r0 = rf(userID, email)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int64, string) error); ok {
+		r1 = rf(userID, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
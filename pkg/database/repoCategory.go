@@ -0,0 +1,74 @@
+package database
+
+import (
+	"redditclone/pkg/flair"
+	"redditclone/pkg/user"
+	"sync"
+)
+
+// CategoryRepo keeps owners of categories and the flairs they define.
+type CategoryRepo interface {
+	Owners(category string) ([]user.User, error)
+	AddOwner(category string, owner user.User) (err error)
+	RemoveOwner(category string, owner user.User) (err error)
+	IsOwner(category string, usr user.User) (bool, error)
+	Flairs(category string) ([]flair.Flair, error)
+	SetFlair(category string, flr flair.Flair) (err error)
+	RemoveFlair(category, text string) (removed bool, err error)
+}
+
+type CategoryRepoStruct struct {
+	data *DatabaseUser
+	mx   *sync.Mutex
+}
+
+func NewCategoryRepo(databaseUser *DatabaseUser) *CategoryRepoStruct {
+	return &CategoryRepoStruct{
+		data: databaseUser,
+		mx:   &sync.Mutex{},
+	}
+}
+
+func (d *CategoryRepoStruct) Owners(category string) ([]user.User, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.GetCategoryOwners(category)
+}
+
+func (d *CategoryRepoStruct) AddOwner(category string, owner user.User) (err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.AddCategoryOwner(category, owner.UserID)
+}
+
+func (d *CategoryRepoStruct) RemoveOwner(category string, owner user.User) (err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.RemoveCategoryOwner(category, owner.UserID)
+}
+
+func (d *CategoryRepoStruct) IsOwner(category string, usr user.User) (bool, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.IsCategoryOwner(category, usr.UserID)
+}
+
+func (d *CategoryRepoStruct) Flairs(category string) ([]flair.Flair, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.GetFlairs(category)
+}
+
+// SetFlair adds the flair to the category or changes the color of an existing
+// one with the same text.
+func (d *CategoryRepoStruct) SetFlair(category string, flr flair.Flair) (err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.SetFlair(category, flr)
+}
+
+func (d *CategoryRepoStruct) RemoveFlair(category, text string) (removed bool, err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.RemoveFlair(category, text)
+}
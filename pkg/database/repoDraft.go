@@ -0,0 +1,100 @@
+package database
+
+import (
+	"fmt"
+	"redditclone/pkg/draft"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DraftRepo interface {
+	Add(drf *draft.Draft) (err error)
+	List(userID int64) ([]draft.Draft, error)
+	Get(userID int64, id string) (drf draft.Draft, ok bool, err error)
+	Update(drf *draft.Draft) (ok bool, err error)
+	Remove(userID int64, id string) (ok bool, err error)
+	Count(userID int64) (int64, error)
+	RemoveAll(userID int64) (err error)
+}
+
+type DraftRepoStruct struct {
+	data DatabaseDraft
+}
+
+func NewDraftRepo(databaseDraft DatabaseDraft) *DraftRepoStruct {
+	return &DraftRepoStruct{
+		data: databaseDraft,
+	}
+}
+
+func (d *DraftRepoStruct) Add(drf *draft.Draft) (err error) {
+	drf.ID = primitive.NewObjectID()
+	drf.Updated = time.Now()
+	return d.data.Insert(drf)
+}
+
+func (d *DraftRepoStruct) List(userID int64) ([]draft.Draft, error) {
+	drafts, err := d.data.GetAll(
+		bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "updated", Value: -1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("can`t get drafts: %w", err)
+	}
+	if drafts == nil {
+		drafts = []draft.Draft{}
+	}
+	return drafts, nil
+}
+
+// Get returns the draft with id if it belongs to the user.
+func (d *DraftRepoStruct) Get(userID int64, id string) (drf draft.Draft, ok bool, err error) {
+	objectID, errID := primitive.ObjectIDFromHex(id)
+	if errID != nil {
+		return drf, false, nil
+	}
+	err = d.data.Find(bson.M{"_id": objectID, "user_id": userID}, &drf)
+	if err == mongo.ErrNoDocuments {
+		return drf, false, nil
+	}
+	return drf, err == nil, err
+}
+
+// Update replaces the draft of its user and prolongs its life.
+func (d *DraftRepoStruct) Update(drf *draft.Draft) (ok bool, err error) {
+	drf.Updated = time.Now()
+	matched, err := d.data.Replace(bson.M{"_id": drf.ID, "user_id": drf.UserID}, drf)
+	if err != nil {
+		return false, err
+	}
+	return matched != 0, nil
+}
+
+func (d *DraftRepoStruct) Remove(userID int64, id string) (ok bool, err error) {
+	objectID, errID := primitive.ObjectIDFromHex(id)
+	if errID != nil {
+		return false, nil
+	}
+	deleted, err := d.data.Delete(bson.M{"_id": objectID, "user_id": userID})
+	if err != nil {
+		return false, err
+	}
+	return deleted != 0, nil
+}
+
+func (d *DraftRepoStruct) Count(userID int64) (int64, error) {
+	count, err := d.data.Count(bson.M{"user_id": userID})
+	if err != nil {
+		return 0, fmt.Errorf("can`t count drafts: %w", err)
+	}
+	return count, nil
+}
+
+func (d *DraftRepoStruct) RemoveAll(userID int64) (err error) {
+	_, err = d.data.DeleteMany(bson.M{"user_id": userID})
+	return
+}
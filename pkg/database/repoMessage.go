@@ -0,0 +1,87 @@
+package database
+
+import (
+	"redditclone/pkg/message"
+	"redditclone/pkg/user"
+	"sync"
+)
+
+// BlockRepo keeps users blocked by each other, a blocked user can't reach
+// the blocker and isn't shown to them.
+type BlockRepo interface {
+	Block(usr, blocked user.User) (err error)
+	Unblock(usr, blocked user.User) (err error)
+	IsBlocked(usr, blocked user.User) (bool, error)
+	Blocks(usr user.User) ([]user.User, error)
+}
+
+type MessageRepo interface {
+	BlockRepo
+	Send(from, to user.User, body string) (message.Message, error)
+	Conversations(usr user.User) ([]message.Conversation, error)
+	Thread(usr, with user.User, page, limit int64) ([]message.Message, error)
+	MarkRead(usr, with user.User) (err error)
+}
+
+type MessageRepoStruct struct {
+	data *DatabaseUser
+	mx   *sync.Mutex
+}
+
+func NewMessageRepo(databaseUser *DatabaseUser) *MessageRepoStruct {
+	return &MessageRepoStruct{
+		data: databaseUser,
+		mx:   &sync.Mutex{},
+	}
+}
+
+func (d *MessageRepoStruct) Send(from, to user.User, body string) (message.Message, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	msg, err := d.data.AddMessage(from.UserID, to.UserID, body)
+	msg.From = from
+	msg.To = to
+	return msg, err
+}
+
+func (d *MessageRepoStruct) Conversations(usr user.User) ([]message.Conversation, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.GetConversations(usr.UserID)
+}
+
+func (d *MessageRepoStruct) Thread(usr, with user.User, page, limit int64) ([]message.Message, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.GetThread(usr, with, page, limit)
+}
+
+func (d *MessageRepoStruct) MarkRead(usr, with user.User) (err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.MarkThreadRead(usr.UserID, with.UserID)
+}
+
+func (d *MessageRepoStruct) Block(usr, blocked user.User) (err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.AddBlock(usr.UserID, blocked.UserID)
+}
+
+func (d *MessageRepoStruct) Unblock(usr, blocked user.User) (err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.RemoveBlock(usr.UserID, blocked.UserID)
+}
+
+func (d *MessageRepoStruct) IsBlocked(usr, blocked user.User) (bool, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.IsBlocked(usr.UserID, blocked.UserID)
+}
+
+func (d *MessageRepoStruct) Blocks(usr user.User) ([]user.User, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.GetBlocks(usr.UserID)
+}
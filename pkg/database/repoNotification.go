@@ -0,0 +1,100 @@
+package database
+
+import (
+	"fmt"
+	"redditclone/pkg/notification"
+	"redditclone/pkg/user"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type NotificationRepo interface {
+	Add(ntf *notification.Notification) (err error)
+	List(userID int64, unreadOnly bool, page, limit int64) (notification.Inbox, error)
+	MarkRead(userID int64, id string) (ok bool, err error)
+	MarkAllRead(userID int64) (err error)
+	RemoveAll(userID int64) (err error)
+	RenameSender(from, to user.User) (err error)
+}
+
+type NotificationRepoStruct struct {
+	data DatabaseNotification
+}
+
+func NewNotificationRepo(databaseNotification DatabaseNotification) *NotificationRepoStruct {
+	return &NotificationRepoStruct{
+		data: databaseNotification,
+	}
+}
+
+func (d *NotificationRepoStruct) Add(ntf *notification.Notification) (err error) {
+	ntf.ID = primitive.NewObjectID()
+	ntf.Read = false
+	if ntf.Created.IsZero() {
+		ntf.Created = time.Now()
+	}
+	return d.data.Insert(ntf)
+}
+
+func (d *NotificationRepoStruct) List(userID int64, unreadOnly bool, page, limit int64) (notification.Inbox, error) {
+	filter := bson.M{"user_id": userID}
+	if unreadOnly {
+		filter["read"] = false
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created", Value: -1}}).
+		SetSkip(page * limit).
+		SetLimit(limit)
+	ntfs, err := d.data.GetAll(filter, opts)
+	if err != nil {
+		return notification.Inbox{}, fmt.Errorf("can`t get notifications: %w", err)
+	}
+	if ntfs == nil {
+		ntfs = []notification.Notification{}
+	}
+	unread, err := d.data.Count(bson.M{"user_id": userID, "read": false})
+	if err != nil {
+		return notification.Inbox{}, fmt.Errorf("can`t count unread notifications: %w", err)
+	}
+	return notification.Inbox{
+		Unread:        uint64(unread),
+		Notifications: ntfs,
+	}, nil
+}
+
+func (d *NotificationRepoStruct) MarkRead(userID int64, id string) (ok bool, err error) {
+	objectID, errID := primitive.ObjectIDFromHex(id)
+	if errID != nil {
+		return false, nil
+	}
+	matched, err := d.data.UpdateMany(
+		bson.M{"_id": objectID, "user_id": userID},
+		bson.M{"$set": bson.M{"read": true}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return matched != 0, nil
+}
+
+func (d *NotificationRepoStruct) MarkAllRead(userID int64) (err error) {
+	_, err = d.data.UpdateMany(
+		bson.M{"user_id": userID, "read": false},
+		bson.M{"$set": bson.M{"read": true}},
+	)
+	return
+}
+
+func (d *NotificationRepoStruct) RemoveAll(userID int64) (err error) {
+	_, err = d.data.DeleteMany(bson.M{"user_id": userID})
+	return
+}
+
+// RenameSender replaces from with to in notifications sent by from.
+func (d *NotificationRepoStruct) RenameSender(from, to user.User) (err error) {
+	_, err = d.data.UpdateMany(bson.M{"from": from}, bson.M{"$set": bson.M{"from": to}})
+	return
+}
@@ -3,28 +3,43 @@ package database
 import (
 	"encoding/json"
 	"fmt"
+	"redditclone/pkg/comment"
 	"redditclone/pkg/post"
 	"redditclone/pkg/user"
+	"sort"
 	"sync"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type PostRepo interface {
 	Lock(id uint64) bool
+	LockDeleted(id uint64) bool
 	Unlock(id uint64) bool
 	Add(pst *post.Post) (err error)
 	Find(id uint64) (ok bool)
 	Get(id uint64) (pst post.Post, err error)
 	Update(pst post.Post) (err error)
 	Remove(id uint64) bool
-	ToJson(category, username string) ([]byte, error)
+	ToJson(filter post.Filter) ([]byte, error)
+	Counts(usr user.User) (posts, comments uint64, err error)
+	UserComments(username, sortBy string, page, limit int64) ([]comment.UserComment, error)
+	Authored(usr user.User) (posts []post.Post, comments []comment.UserComment, err error)
+	Purge(before time.Time) (posts, comments int64, err error)
+	Duplicate(category, url string, since time.Time) (id uint64, err error)
+	Archive(before time.Time) (archived int64, err error)
+	Publish(now time.Time) (published []post.Post, err error)
+	MarkCrossposts(originID uint64, removed bool) (err error)
+	RenameAuthor(from, to user.User) (changed int64, err error)
 }
 
 type PostRepoStruct struct {
 	users      *DatabaseUser
 	data       DatabasePost
+	archive    DatabasePost
 	mx         *sync.Mutex
 	postsMuxes map[uint64]*sync.Mutex
 	idGetter   uint64
@@ -52,6 +67,36 @@ func NewPostRepo(databasePost DatabasePost, databaseUser *DatabaseUser) (*PostRe
 	}, nil
 }
 
+// AttachArchive makes the repo move archived posts to the archive collection.
+// Posts stay available by id, but disappear from listings.
+func (d *PostRepoStruct) AttachArchive(archive DatabasePost) error {
+	posts, err := archive.GetAll(bson.M{}, options.Find())
+	if err != nil {
+		return err
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	for _, pst := range posts {
+		if _, ok := d.postsMuxes[pst.ID]; !ok {
+			d.postsMuxes[pst.ID] = &sync.Mutex{}
+		}
+		if d.idGetter < pst.ID {
+			d.idGetter = pst.ID
+		}
+	}
+	d.archive = archive
+	return nil
+}
+
+// find looks for the post in the live collection, then in the archive.
+func (d *PostRepoStruct) find(id uint64, pst *post.Post) error {
+	err := d.data.Find(id, pst)
+	if err == mongo.ErrNoDocuments && d.archive != nil {
+		return d.archive.Find(id, pst)
+	}
+	return err
+}
+
 func (d *PostRepoStruct) Lock(id uint64) bool {
 	mu, ok := d.postsMuxes[id]
 	if !ok {
@@ -65,6 +110,21 @@ func (d *PostRepoStruct) Lock(id uint64) bool {
 	return true
 }
 
+// LockDeleted locks the post only if it is soft-deleted, so it can be restored.
+func (d *PostRepoStruct) LockDeleted(id uint64) bool {
+	mu, ok := d.postsMuxes[id]
+	if !ok {
+		return false
+	}
+	mu.Lock()
+	pst, err := d.Get(id)
+	if err != nil || pst.DeletedAt == nil {
+		mu.Unlock()
+		return false
+	}
+	return true
+}
+
 func (d *PostRepoStruct) Unlock(id uint64) bool {
 	mu, ok := d.postsMuxes[id]
 	if !ok {
@@ -82,6 +142,7 @@ func (d *PostRepoStruct) Add(pst *post.Post) (err error) {
 	defer d.mx.Unlock()
 	d.idGetter++
 	pst.ID = d.idGetter
+	pst.Render()
 	err = d.data.Insert(*pst)
 	if err != nil {
 		d.idGetter--
@@ -95,14 +156,14 @@ func (d *PostRepoStruct) Find(id uint64) (ok bool) {
 	d.mx.Lock()
 	defer d.mx.Unlock()
 	var pst post.Post
-	err := d.data.Find(id, &pst)
-	return err == nil
+	err := d.find(id, &pst)
+	return err == nil && pst.DeletedAt == nil
 }
 
 func (d *PostRepoStruct) Get(id uint64) (pst post.Post, err error) {
 	d.mx.Lock()
 	defer d.mx.Unlock()
-	err = d.data.Find(id, &pst)
+	err = d.find(id, &pst)
 	return
 }
 
@@ -110,6 +171,9 @@ func (d *PostRepoStruct) Update(pst post.Post) (err error) {
 	d.mx.Lock()
 	defer d.mx.Unlock()
 	err = d.data.Replace(pst)
+	if err == nil && pst.Archived && d.archive != nil {
+		err = d.archive.Replace(pst)
+	}
 	return
 }
 
@@ -117,6 +181,9 @@ func (d *PostRepoStruct) Remove(id uint64) bool {
 	d.mx.Lock()
 	defer d.mx.Unlock()
 	err := d.data.Delete(id)
+	if err == nil && d.archive != nil {
+		err = d.archive.Delete(id)
+	}
 	if err != nil {
 		return false
 	}
@@ -132,20 +199,367 @@ func (d *PostRepoStruct) getUserID(username string) int64 {
 	return usr.UserID
 }
 
-func (d *PostRepoStruct) ToJson(category, username string) ([]byte, error) {
+func (d *PostRepoStruct) ToJson(postFilter post.Filter) ([]byte, error) {
 	findOptions := options.Find()
-	findOptions.SetSort(bson.M{"score": -1})
-	filter := bson.M{}
-	if category != "" {
-		filter["category"] = category
+	findOptions.SetSort(bson.D{{Key: "score", Value: -1}})
+	filter := bson.M{
+		"hidden":     bson.M{"$ne": true},
+		"deleted_at": bson.M{"$exists": false},
+		"publish_at": bson.M{"$exists": postFilter.Scheduled},
+	}
+	if postFilter.Category != "" {
+		// pins are set by category owners, so they only lead their category
+		findOptions.SetSort(bson.D{{Key: "pinned", Value: -1}, {Key: "score", Value: -1}})
+		filter["category"] = postFilter.Category
+	}
+	if postFilter.Flair != "" {
+		filter["flair.text"] = postFilter.Flair
 	}
-	if username != "" {
-		filter["author"] = user.User{Username: username, UserID: d.getUserID(username)}
+	if len(postFilter.Blocked) != 0 {
+		filter["author.userid"] = bson.M{"$nin": postFilter.Blocked}
 	}
-	resArr, err := d.data.GetAll(filter, findOptions)
+	if postFilter.Preferences.NSFW == user.ContentHide {
+		filter["nsfw"] = bson.M{"$ne": true}
+	}
+	if postFilter.Preferences.Spoiler == user.ContentHide {
+		filter["spoiler"] = bson.M{"$ne": true}
+	}
+	if postFilter.Domain != "" {
+		filter["domain"] = postFilter.Domain
+	}
+	if postFilter.Username != "" {
+		filter["author"] = user.User{Username: postFilter.Username, UserID: d.getUserID(postFilter.Username)}
+	}
+	if len(postFilter.IDs) != 0 || len(postFilter.Exclude) != 0 {
+		ids := bson.M{}
+		if len(postFilter.IDs) != 0 {
+			ids["$in"] = postFilter.IDs
+		}
+		if len(postFilter.Exclude) != 0 {
+			ids["$nin"] = postFilter.Exclude
+		}
+		filter["id"] = ids
+	}
+	data := d.data
+	if postFilter.Archive {
+		if d.archive == nil {
+			return []byte("[]"), nil
+		}
+		data = d.archive
+	}
+	resArr, err := data.GetAll(filter, findOptions)
 	if err != nil {
 		return nil, err
 	}
+	if len(postFilter.IDs) != 0 {
+		position := make(map[uint64]int, len(postFilter.IDs))
+		for i, id := range postFilter.IDs {
+			position[id] = i
+		}
+		sort.SliceStable(resArr, func(i, j int) bool {
+			return position[resArr[i].ID] < position[resArr[j].ID]
+		})
+	}
+	for _, pst := range resArr {
+		pst.ForViewer(postFilter.Viewer)
+		pst.DropBlocked(postFilter.Blocked)
+		pst.ApplyPreferences(postFilter.Preferences)
+	}
 	res, errMarshal := json.Marshal(resArr)
 	return res, errMarshal
 }
+
+func (d *PostRepoStruct) Counts(usr user.User) (posts, comments uint64, err error) {
+	author := user.User{Username: usr.Username, UserID: usr.UserID}
+	postCount, err := d.data.Count(bson.M{"author": author, "deleted_at": bson.M{"$exists": false}})
+	if err != nil {
+		return
+	}
+	var commentCount []struct {
+		Count uint64 `bson:"count"`
+	}
+	err = d.data.Aggregate(bson.A{
+		bson.M{"$match": bson.M{"comments.author": author, "deleted_at": bson.M{"$exists": false}}},
+		bson.M{"$unwind": "$comments"},
+		bson.M{"$match": bson.M{"comments.author": author, "comments.deleted_at": bson.M{"$exists": false}}},
+		bson.M{"$count": "count"},
+	}, &commentCount)
+	if err != nil {
+		return
+	}
+	posts = uint64(postCount)
+	if len(commentCount) != 0 {
+		comments = commentCount[0].Count
+	}
+	return
+}
+
+func (d *PostRepoStruct) UserComments(username, sortBy string, page, limit int64) ([]comment.UserComment, error) {
+	author := user.User{Username: username, UserID: d.getUserID(username)}
+	sort := bson.D{{Key: "time", Value: -1}}
+	if sortBy == comment.SortTop {
+		sort = bson.D{{Key: "score", Value: -1}, {Key: "time", Value: -1}}
+	}
+	res := []comment.UserComment{}
+	err := d.data.Aggregate(bson.A{
+		bson.M{"$match": bson.M{
+			"comments.author": author,
+			"hidden":          bson.M{"$ne": true},
+			"deleted_at":      bson.M{"$exists": false},
+		}},
+		bson.M{"$unwind": "$comments"},
+		bson.M{"$match": bson.M{
+			"comments.author":     author,
+			"comments.hidden":     bson.M{"$ne": true},
+			"comments.deleted_at": bson.M{"$exists": false},
+		}},
+		bson.M{"$replaceRoot": bson.M{"newRoot": bson.M{"$mergeObjects": bson.A{
+			"$comments",
+			bson.M{"postid": "$id", "posttitle": "$title"},
+		}}}},
+		bson.M{"$sort": sort},
+		bson.M{"$skip": page * limit},
+		bson.M{"$limit": limit},
+	}, &res)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Authored returns everything written by usr in both collections, newest
+// first: unlike listings it keeps scheduled, hidden and not yet purged
+// deleted posts and comments. It is meant for exporting personal data.
+func (d *PostRepoStruct) Authored(usr user.User) (posts []post.Post, comments []comment.UserComment, err error) {
+	author := user.User{Username: usr.Username, UserID: usr.UserID}
+	collections := []DatabasePost{d.data}
+	if d.archive != nil {
+		collections = append(collections, d.archive)
+	}
+	posts, comments = []post.Post{}, []comment.UserComment{}
+	for _, data := range collections {
+		found, errGet := data.GetAll(bson.M{"author": author}, options.Find().SetSort(bson.D{{Key: "time", Value: -1}}))
+		if errGet != nil {
+			return nil, nil, errGet
+		}
+		for _, pst := range found {
+			posts = append(posts, *pst)
+		}
+		res := []comment.UserComment{}
+		errComments := data.Aggregate(bson.A{
+			bson.M{"$match": bson.M{"comments.author": author}},
+			bson.M{"$unwind": "$comments"},
+			bson.M{"$match": bson.M{"comments.author": author}},
+			bson.M{"$replaceRoot": bson.M{"newRoot": bson.M{"$mergeObjects": bson.A{
+				"$comments",
+				bson.M{"postid": "$id", "posttitle": "$title"},
+			}}}},
+			bson.M{"$sort": bson.D{{Key: "time", Value: -1}}},
+		}, &res)
+		if errComments != nil {
+			return nil, nil, errComments
+		}
+		comments = append(comments, res...)
+	}
+	return posts, comments, nil
+}
+
+// Purge hard-deletes posts and comments which were soft-deleted before the given time.
+func (d *PostRepoStruct) Purge(before time.Time) (posts, comments int64, err error) {
+	collections := []DatabasePost{d.data}
+	if d.archive != nil {
+		collections = append(collections, d.archive)
+	}
+	for _, data := range collections {
+		deleted, err := data.GetAll(bson.M{"deleted_at": bson.M{"$lt": before}})
+		if err != nil {
+			return posts, comments, err
+		}
+		for _, pst := range deleted {
+			if !d.LockDeleted(pst.ID) {
+				continue
+			}
+			mu := d.postsMuxes[pst.ID]
+			if d.Remove(pst.ID) {
+				posts++
+			}
+			mu.Unlock()
+		}
+		pulled, err := data.UpdateMany(
+			bson.M{"comments.deleted_at": bson.M{"$lt": before}},
+			bson.M{"$pull": bson.M{"comments": bson.M{"deleted_at": bson.M{"$lt": before}}}},
+		)
+		comments += pulled
+		if err != nil {
+			return posts, comments, err
+		}
+	}
+	return posts, comments, nil
+}
+
+// createdExpr compares the creation time of posts with t by op ("$gte",
+// "$lt"). Creation times are RFC 3339 strings with an offset, so they are
+// parsed on the server instead of being compared as strings.
+func createdExpr(op string, t time.Time) bson.M {
+	return bson.M{op: bson.A{bson.M{"$dateFromString": bson.M{"dateString": "$time"}}, t}}
+}
+
+// Duplicate returns the id of the newest live post with the same link in the
+// category created after since, 0 if there is none.
+func (d *PostRepoStruct) Duplicate(category, url string, since time.Time) (id uint64, err error) {
+	findOptions := options.Find()
+	findOptions.SetSort(bson.M{"id": -1})
+	findOptions.SetLimit(1)
+	posts, err := d.data.GetAll(bson.M{
+		"category":   category,
+		"url":        url,
+		"deleted_at": bson.M{"$exists": false},
+		"$expr":      createdExpr("$gte", since),
+	}, findOptions)
+	if err != nil || len(posts) == 0 {
+		return 0, err
+	}
+	return posts[0].ID, nil
+}
+
+// Archive marks live posts created before the given time as archived, so
+// they refuse new votes and comments. With an attached archive collection
+// the posts are moved there.
+func (d *PostRepoStruct) Archive(before time.Time) (archived int64, err error) {
+	posts, err := d.data.GetAll(bson.M{
+		"archived":   bson.M{"$ne": true},
+		"deleted_at": bson.M{"$exists": false},
+		"publish_at": bson.M{"$exists": false},
+		"$expr":      createdExpr("$lt", before),
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, pst := range posts {
+		if !d.Lock(pst.ID) {
+			continue
+		}
+		errArchive := d.archivePost(pst.ID)
+		d.Unlock(pst.ID)
+		if errArchive != nil {
+			return archived, errArchive
+		}
+		archived++
+	}
+	return archived, nil
+}
+
+func (d *PostRepoStruct) archivePost(id uint64) error {
+	pst, err := d.Get(id)
+	if err != nil {
+		return err
+	}
+	pst.Archived = true
+	if d.archive == nil {
+		return d.Update(pst)
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	err = d.archive.Insert(pst)
+	if err != nil {
+		return err
+	}
+	return d.data.Delete(id)
+}
+
+// Publish makes scheduled posts due by now visible, their creation time
+// becomes the time of publication.
+func (d *PostRepoStruct) Publish(now time.Time) (published []post.Post, err error) {
+	due, err := d.data.GetAll(bson.M{
+		"publish_at": bson.M{"$lte": now},
+		"deleted_at": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, scheduled := range due {
+		if !d.Lock(scheduled.ID) {
+			continue
+		}
+		pst, errGet := d.Get(scheduled.ID)
+		if errGet != nil || pst.PublishAt == nil || pst.PublishAt.After(now) {
+			d.Unlock(scheduled.ID)
+			if errGet != nil {
+				return published, errGet
+			}
+			continue
+		}
+		pst.PublishAt = nil
+		pst.Time = now.Format(time.RFC3339)
+		errUpdate := d.Update(pst)
+		d.Unlock(scheduled.ID)
+		if errUpdate != nil {
+			return published, errUpdate
+		}
+		published = append(published, pst)
+	}
+	return published, nil
+}
+
+// MarkCrossposts flags crossposts of the post as referencing removed content
+// or clears the flag when the post is restored.
+func (d *PostRepoStruct) MarkCrossposts(originID uint64, removed bool) (err error) {
+	collections := []DatabasePost{d.data}
+	if d.archive != nil {
+		collections = append(collections, d.archive)
+	}
+	update := bson.M{"$set": bson.M{"crosspost.removed": true}}
+	if !removed {
+		update = bson.M{"$unset": bson.M{"crosspost.removed": ""}}
+	}
+	for _, data := range collections {
+		if _, err = data.UpdateMany(bson.M{"crosspost.post_id": originID}, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenameAuthor replaces the author of posts and comments written by from in
+// both collections, deleted ones included. Deleted accounts are anonymized
+// this way with to set to user.DeletedUsername.
+func (d *PostRepoStruct) RenameAuthor(from, to user.User) (changed int64, err error) {
+	collections := []DatabasePost{d.data}
+	if d.archive != nil {
+		collections = append(collections, d.archive)
+	}
+	filter := bson.M{"$or": bson.A{
+		bson.M{"author": from},
+		bson.M{"comments.author": from},
+		bson.M{"crosspost.author": from},
+		bson.M{"mentions": from},
+		bson.M{"comments.mentions": from},
+		bson.M{"deleted_by": from},
+		bson.M{"comments.deleted_by": from},
+	}}
+	for _, data := range collections {
+		posts, errGet := data.GetAll(filter)
+		if errGet != nil {
+			return changed, errGet
+		}
+		for _, found := range posts {
+			mu, ok := d.postsMuxes[found.ID]
+			if !ok {
+				continue
+			}
+			mu.Lock()
+			pst, errPost := d.Get(found.ID)
+			if errPost == nil && pst.RenameAuthor(from, to) {
+				errPost = d.Update(pst)
+				if errPost == nil {
+					changed++
+				}
+			}
+			mu.Unlock()
+			if errPost != nil {
+				return changed, errPost
+			}
+		}
+	}
+	return changed, nil
+}
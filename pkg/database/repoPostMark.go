@@ -0,0 +1,46 @@
+package database
+
+import (
+	"sync"
+)
+
+const (
+	MarkSaved  = "saved"
+	MarkHidden = "hidden"
+)
+
+type PostMarkRepo interface {
+	Add(userID int64, postID uint64, kind string) (err error)
+	Remove(userID int64, postID uint64, kind string) (err error)
+	List(userID int64, kind string) ([]uint64, error)
+}
+
+type PostMarkRepoStruct struct {
+	data *DatabaseUser
+	mx   *sync.Mutex
+}
+
+func NewPostMarkRepo(databaseUser *DatabaseUser) *PostMarkRepoStruct {
+	return &PostMarkRepoStruct{
+		data: databaseUser,
+		mx:   &sync.Mutex{},
+	}
+}
+
+func (d *PostMarkRepoStruct) Add(userID int64, postID uint64, kind string) (err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.AddPostMark(userID, postID, kind)
+}
+
+func (d *PostMarkRepoStruct) Remove(userID int64, postID uint64, kind string) (err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.RemovePostMark(userID, postID, kind)
+}
+
+func (d *PostMarkRepoStruct) List(userID int64, kind string) ([]uint64, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.GetPostMarks(userID, kind)
+}
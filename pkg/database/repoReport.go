@@ -0,0 +1,55 @@
+package database
+
+import (
+	"errors"
+	"redditclone/pkg/report"
+	"sync"
+)
+
+// ErrAlreadyReported is returned when the user already has an open report of
+// the item.
+var ErrAlreadyReported = errors.New("item is already reported")
+
+type ReportRepo interface {
+	Add(rpt report.Report) (count int64, err error)
+	Queue(page, limit int64) ([]report.Item, error)
+	Resolve(target report.Target) (err error)
+	ResolvePost(postID uint64) (err error)
+}
+
+type ReportRepoStruct struct {
+	data *DatabaseUser
+	mx   *sync.Mutex
+}
+
+func NewReportRepo(databaseUser *DatabaseUser) *ReportRepoStruct {
+	return &ReportRepoStruct{
+		data: databaseUser,
+		mx:   &sync.Mutex{},
+	}
+}
+
+func (d *ReportRepoStruct) Add(rpt report.Report) (count int64, err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.AddReport(rpt)
+}
+
+func (d *ReportRepoStruct) Queue(page, limit int64) ([]report.Item, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.GetReportQueue(page, limit)
+}
+
+func (d *ReportRepoStruct) Resolve(target report.Target) (err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.ResolveReports(target)
+}
+
+// ResolvePost resolves reports of the post and of all its comments.
+func (d *ReportRepoStruct) ResolvePost(postID uint64) (err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.ResolvePostReports(postID)
+}
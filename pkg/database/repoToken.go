@@ -0,0 +1,96 @@
+package database
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	TokenVerify = "verify"
+	TokenReset  = "reset"
+
+	tokenBytes = 32
+)
+
+// ErrTokenInvalid is returned for unknown, already used and expired tokens.
+var ErrTokenInvalid = errors.New("token is invalid or expired")
+
+// TokenRepo keeps single-use tokens sent to users by email. Only hashes of
+// the tokens are stored.
+type TokenRepo interface {
+	Issue(userID int64, kind, email string, ttl time.Duration) (token string, err error)
+	Take(token, kind string) (userID int64, email string, err error)
+	Revoke(userID int64, kind string) (err error)
+	Purge(now time.Time) (removed int64, err error)
+}
+
+type TokenRepoStruct struct {
+	data *DatabaseUser
+	mx   *sync.Mutex
+}
+
+func NewTokenRepo(databaseUser *DatabaseUser) *TokenRepoStruct {
+	return &TokenRepoStruct{
+		data: databaseUser,
+		mx:   &sync.Mutex{},
+	}
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (d *TokenRepoStruct) Issue(userID int64, kind, email string, ttl time.Duration) (token string, err error) {
+	raw := make([]byte, tokenBytes)
+	if _, err = rand.Read(raw); err != nil {
+		return "", err
+	}
+	token = hex.EncodeToString(raw)
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	err = d.data.AddUserToken(hashToken(token), userID, kind, email, time.Now().Add(ttl).Unix())
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Take uses up the token, so it can't be taken twice.
+func (d *TokenRepoStruct) Take(token, kind string) (userID int64, email string, err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	tokenHash := hashToken(token)
+	userID, email, expiresAt, err := d.data.GetUserToken(tokenHash, kind)
+	if err == sql.ErrNoRows {
+		return 0, "", ErrTokenInvalid
+	}
+	if err != nil {
+		return 0, "", err
+	}
+	removed, err := d.data.RemoveUserToken(tokenHash)
+	if err != nil {
+		return 0, "", err
+	}
+	if !removed || expiresAt < time.Now().Unix() {
+		return 0, "", ErrTokenInvalid
+	}
+	return userID, email, nil
+}
+
+func (d *TokenRepoStruct) Revoke(userID int64, kind string) (err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.RemoveUserTokens(userID, kind)
+}
+
+func (d *TokenRepoStruct) Purge(now time.Time) (removed int64, err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.RemoveExpiredTokens(now.Unix())
+}
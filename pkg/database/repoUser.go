@@ -8,6 +8,18 @@ import (
 type UserRepo interface {
 	Add(user *user.User) (err error)
 	Find(username string) (user.User, bool)
+	FindByEmail(email string) (user.User, bool)
+	SetEmail(usr user.User, email string) (err error)
+	VerifyEmail(userID int64, email string) (verified bool, err error)
+	Profile(username string) (user.Profile, error)
+	UpdateProfile(usr user.User, bio, avatarURL string) (err error)
+	Preferences(userID int64) (user.Preferences, error)
+	UpdatePreferences(usr user.User, prefs user.Preferences) (err error)
+	UpdatePassword(usr user.User, passwordHash string) (err error)
+	Rename(usr user.User, username string) (err error)
+	Remove(usr user.User) (err error)
+	AddKarma(userID int64, postKarma, commentKarma int64) (err error)
+	Role(userID int64) (string, error)
 }
 
 type UserRepoStruct struct {
@@ -41,3 +53,79 @@ func (d *UserRepoStruct) Find(username string) (user.User, bool) {
 	}
 	return usr, true
 }
+
+func (d *UserRepoStruct) FindByEmail(email string) (user.User, bool) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	usr, err := d.data.GetByEmail(email)
+	if err != nil {
+		return user.User{}, false
+	}
+	return usr, true
+}
+
+func (d *UserRepoStruct) SetEmail(usr user.User, email string) (err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.UpdateEmail(usr.UserID, email)
+}
+
+func (d *UserRepoStruct) VerifyEmail(userID int64, email string) (verified bool, err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.VerifyEmail(userID, email)
+}
+
+func (d *UserRepoStruct) Profile(username string) (user.Profile, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.GetProfile(username)
+}
+
+func (d *UserRepoStruct) UpdateProfile(usr user.User, bio, avatarURL string) (err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.UpdateProfile(usr.UserID, bio, avatarURL)
+}
+
+func (d *UserRepoStruct) Preferences(userID int64) (user.Preferences, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.GetPreferences(userID)
+}
+
+func (d *UserRepoStruct) UpdatePreferences(usr user.User, prefs user.Preferences) (err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.UpdatePreferences(usr.UserID, prefs)
+}
+
+func (d *UserRepoStruct) UpdatePassword(usr user.User, passwordHash string) (err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.UpdatePassword(usr.UserID, passwordHash)
+}
+
+func (d *UserRepoStruct) Rename(usr user.User, username string) (err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.UpdateUsername(usr.UserID, username)
+}
+
+func (d *UserRepoStruct) Remove(usr user.User) (err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.Remove(usr.UserID)
+}
+
+func (d *UserRepoStruct) AddKarma(userID int64, postKarma, commentKarma int64) (err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.AddKarma(userID, postKarma, commentKarma)
+}
+
+func (d *UserRepoStruct) Role(userID int64) (string, error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return d.data.GetRole(userID)
+}
@@ -0,0 +1,61 @@
+package draft
+
+import (
+	"redditclone/pkg/flair"
+	"redditclone/pkg/poll"
+	"redditclone/pkg/post"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Draft keeps an unfinished post or comment of a user, exactly one of Post
+// and Comment is set.
+type Draft struct {
+	ID      primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID  int64              `json:"-" bson:"user_id"`
+	Post    *Post              `json:"post,omitempty" bson:"post,omitempty"`
+	Comment *Comment           `json:"comment,omitempty" bson:"comment,omitempty"`
+	Updated time.Time          `json:"updated" bson:"updated"`
+}
+
+// Post holds the fields of a post a user can fill in before publishing.
+type Post struct {
+	Type      string       `json:"type" bson:"type"`
+	Title     string       `json:"title" bson:"title"`
+	Text      string       `json:"text,omitempty" bson:"text,omitempty"`
+	URL       string       `json:"url,omitempty" bson:"url,omitempty"`
+	Category  string       `json:"category" bson:"category"`
+	Flair     *flair.Flair `json:"flair,omitempty" bson:"flair,omitempty"`
+	Poll      *poll.Poll   `json:"poll,omitempty" bson:"poll,omitempty"`
+	NSFW      bool         `json:"nsfw,omitempty" bson:"nsfw,omitempty"`
+	Spoiler   bool         `json:"spoiler,omitempty" bson:"spoiler,omitempty"`
+	PublishAt *time.Time   `json:"publishAt,omitempty" bson:"publish_at,omitempty"`
+}
+
+type Comment struct {
+	PostID uint64  `json:"postId,string" bson:"post_id"`
+	Parent *uint64 `json:"parent,string,omitempty" bson:"parent,omitempty"`
+	Body   string  `json:"comment" bson:"body"`
+}
+
+// Valid reports whether the draft holds exactly one item.
+func (d *Draft) Valid() bool {
+	return (d.Post == nil) != (d.Comment == nil)
+}
+
+// ToPost makes a post ready for the same checks as a post sent by a client.
+func (p Post) ToPost() post.Post {
+	return post.Post{
+		Type:      p.Type,
+		Title:     p.Title,
+		Text:      p.Text,
+		URL:       p.URL,
+		Category:  p.Category,
+		Flair:     p.Flair,
+		Poll:      p.Poll,
+		NSFW:      p.NSFW,
+		Spoiler:   p.Spoiler,
+		PublishAt: p.PublishAt,
+	}
+}
@@ -0,0 +1,100 @@
+package events
+
+import (
+	"sync"
+)
+
+const (
+	PostCreated    = "post_created"
+	PostRemoved    = "post_removed"
+	CommentAdded   = "comment_added"
+	CommentRemoved = "comment_removed"
+	VoteChanged    = "vote_changed"
+
+	subscriptionBuffer = 16
+)
+
+type Event struct {
+	Type     string      `json:"type"`
+	PostID   uint64      `json:"postId,string"`
+	Category string      `json:"category"`
+	Data     interface{} `json:"data,omitempty"`
+}
+
+type CommentRef struct {
+	CommentID uint64 `json:"commentId,string"`
+}
+
+type Vote struct {
+	CommentID        *uint64 `json:"commentId,string,omitempty"`
+	Score            int64   `json:"score"`
+	UpvotePercentage int64   `json:"upvotePercentage,omitempty"`
+}
+
+type Subscription struct {
+	Events   chan Event
+	postID   uint64
+	byPost   bool
+	category string
+}
+
+func (s *Subscription) matches(evt Event) bool {
+	if s.byPost {
+		return evt.PostID == s.postID
+	}
+	return s.category == "" || s.category == evt.Category
+}
+
+type Hub struct {
+	mx            *sync.RWMutex
+	subscriptions map[*Subscription]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		mx:            &sync.RWMutex{},
+		subscriptions: map[*Subscription]struct{}{},
+	}
+}
+
+func (h *Hub) subscribe(sub *Subscription) *Subscription {
+	sub.Events = make(chan Event, subscriptionBuffer)
+	h.mx.Lock()
+	h.subscriptions[sub] = struct{}{}
+	h.mx.Unlock()
+	return sub
+}
+
+func (h *Hub) SubscribePost(postID uint64) *Subscription {
+	return h.subscribe(&Subscription{postID: postID, byPost: true})
+}
+
+// SubscribeCategory subscribes to events of posts in category, empty category means all posts.
+func (h *Hub) SubscribeCategory(category string) *Subscription {
+	return h.subscribe(&Subscription{category: category})
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mx.Lock()
+	delete(h.subscriptions, sub)
+	h.mx.Unlock()
+}
+
+// Publish never blocks: slow subscribers lose events instead of stalling handlers.
+// Publishing to a nil hub does nothing.
+func (h *Hub) Publish(evt Event) {
+	if h == nil {
+		return
+	}
+	h.mx.RLock()
+	defer h.mx.RUnlock()
+	for sub := range h.subscriptions {
+		if !sub.matches(evt) {
+			continue
+		}
+		select {
+		case sub.Events <- evt:
+		default:
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package flair
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxTextLen = 64
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Flair labels a post with one of the definitions of its category.
+type Flair struct {
+	Text  string `json:"text" bson:"text"`
+	Color string `json:"color" bson:"color"`
+}
+
+// Check trims the definition as it is sent by a client and returns the
+// invalid field with its problem, empty strings if there is none.
+func (f *Flair) Check() (param, problem string) {
+	f.Text = strings.TrimSpace(f.Text)
+	f.Color = strings.ToLower(f.Color)
+	if f.Text == "" || utf8.RuneCountInString(f.Text) > maxTextLen {
+		return "text", "must be from 1 to 64 characters long"
+	}
+	if !colorPattern.MatchString(f.Color) {
+		return "color", "must be a hex color like #ff4500"
+	}
+	return "", ""
+}
+
+// Find returns the definition with the text, the comparison ignores case.
+func Find(flairs []Flair, text string) (Flair, bool) {
+	text = strings.TrimSpace(text)
+	for _, flr := range flairs {
+		if strings.EqualFold(flr.Text, text) {
+			return flr, true
+		}
+	}
+	return Flair{}, false
+}
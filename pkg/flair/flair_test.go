@@ -0,0 +1,60 @@
+package flair
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	type testCase struct {
+		flair   Flair
+		param   string
+		problem string
+		result  Flair
+	}
+
+	testCases := []testCase{
+		{
+			flair:  Flair{Text: " News ", Color: "#FF4500"},
+			result: Flair{Text: "News", Color: "#ff4500"},
+		},
+		{
+			flair:   Flair{Text: "  ", Color: "#ff4500"},
+			param:   "text",
+			problem: "must be from 1 to 64 characters long",
+		},
+		{
+			flair:   Flair{Text: strings.Repeat("a", 65), Color: "#ff4500"},
+			param:   "text",
+			problem: "must be from 1 to 64 characters long",
+		},
+		{
+			flair:   Flair{Text: "News", Color: "red"},
+			param:   "color",
+			problem: "must be a hex color like #ff4500",
+		},
+	}
+
+	for caseNum, item := range testCases {
+		flr := item.flair
+		param, problem := flr.Check()
+		require.Equal(t, param, item.param, "case %d", caseNum)
+		require.Equal(t, problem, item.problem, "case %d", caseNum)
+		if problem == "" {
+			require.Equal(t, flr, item.result, "case %d", caseNum)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	flairs := []Flair{{Text: "News", Color: "#ff4500"}, {Text: "Meta", Color: "#0079d3"}}
+
+	flr, ok := Find(flairs, " meta")
+	require.True(t, ok)
+	require.Equal(t, flr, flairs[1])
+
+	_, ok = Find(flairs, "other")
+	require.False(t, ok)
+}
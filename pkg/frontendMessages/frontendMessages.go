@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"redditclone/pkg/errors"
+	"redditclone/pkg/user"
 
 	"go.uber.org/zap"
 )
@@ -29,6 +30,21 @@ type Vote struct {
 	Vote   int   `json:"vote"`
 }
 
+// ViewerVotes keeps only the vote of viewer (nil for anonymous visitors)
+// and returns its value, so voters of other users are not exposed.
+func ViewerVotes(votes []Vote, viewer *user.User) ([]Vote, int) {
+	res := []Vote{}
+	if viewer == nil {
+		return res, 0
+	}
+	for _, vote := range votes {
+		if vote.UserID == viewer.UserID {
+			return append(res, vote), vote.Vote
+		}
+	}
+	return res, 0
+}
+
 func SendMessage(w http.ResponseWriter, message string, code int, logger *zap.SugaredLogger, from string) {
 	res, errMarshal := json.Marshal(Message{
 		Message: message,
@@ -41,3 +57,16 @@ func SendMessage(w http.ResponseWriter, message string, code int, logger *zap.Su
 	}
 	http.Error(w, string(res), code)
 }
+
+func SendError(w http.ResponseWriter, errs []ErrorMessage, code int, logger *zap.SugaredLogger, from string) {
+	res, errMarshal := json.Marshal(Error{
+		Errors: errs,
+	})
+	if errMarshal != nil {
+		errors.SendHttpError(
+			logger, w, fmt.Errorf("%s: %w", from, errors.ErrMarshal{Err: errMarshal}),
+		)
+		return
+	}
+	http.Error(w, string(res), code)
+}
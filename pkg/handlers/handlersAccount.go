@@ -0,0 +1,429 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"redditclone/pkg/comment"
+	"redditclone/pkg/database"
+	"redditclone/pkg/draft"
+	"redditclone/pkg/errors"
+	"redditclone/pkg/frontendMessages"
+	"redditclone/pkg/message"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/notification"
+	"redditclone/pkg/post"
+	"redditclone/pkg/session"
+	"redditclone/pkg/token"
+	"redditclone/pkg/user"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	minPasswordLen = 8
+	// maxPasswordLen is the longest password user.GetPasswordHash can hash
+	maxPasswordLen = 32
+	exportPageLen  = 100
+)
+
+var usernameRe = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+type accountExport struct {
+	Profile       user.Profile                `json:"profile"`
+	Email         emailJson                   `json:"email"`
+	Preferences   user.Preferences            `json:"preferences"`
+	Posts         []post.Post                 `json:"posts"`
+	Scheduled     []post.Post                 `json:"scheduled"`
+	Archived      []post.Post                 `json:"archived"`
+	Drafts        []draft.Draft               `json:"drafts"`
+	Comments      []comment.UserComment       `json:"comments"`
+	Saved         []string                    `json:"saved"`
+	Hidden        []string                    `json:"hidden"`
+	Blocked       []user.User                 `json:"blocked"`
+	Conversations []conversationExport        `json:"conversations"`
+	Notifications []notification.Notification `json:"notifications"`
+	Exported      string                      `json:"exported"`
+}
+
+type conversationExport struct {
+	With     user.User         `json:"with"`
+	Messages []message.Message `json:"messages"`
+}
+
+// checkPassword reports whether password belongs to the user with the stored hash.
+func checkPassword(stored user.User, password string) bool {
+	if len(password) > maxPasswordLen {
+		return false
+	}
+	return stored.PasswordHash == user.GetPasswordHash(password)
+}
+
+func passwordProblem(password string) string {
+	if len(password) < minPasswordLen || len(password) > maxPasswordLen {
+		return fmt.Sprintf("must be from %d to %d characters long", minPasswordLen, maxPasswordLen)
+	}
+	return ""
+}
+
+// authorizeAccount reads the request body into v and checks the password of
+// the current user in it. On failure the response is already sent.
+func (h *UserHandler) authorizeAccount(w http.ResponseWriter, r *http.Request, v interface{}, password *string, from string) (usr user.User, ok bool) {
+	body, _ := ioutil.ReadAll(r.Body)
+	usr, ok = r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return usr, false
+	}
+	errUnmarshal := json.Unmarshal(body, v)
+	if errUnmarshal != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("%s: %w", from, errors.ErrUnmarshalRequest{Err: errUnmarshal}),
+		)
+		return usr, false
+	}
+	stored, found := h.UserRepo.Find(usr.Username)
+	if !found {
+		frontendMessages.SendMessage(w,
+			"user not found",
+			http.StatusNotFound,
+			h.Logger, from,
+		)
+		return usr, false
+	}
+	if !checkPassword(stored, *password) {
+		frontendMessages.SendMessage(w,
+			"invalid password",
+			http.StatusForbidden,
+			h.Logger, from,
+		)
+		return usr, false
+	}
+	return stored, true
+}
+
+func (h *UserHandler) PasswordChange(w http.ResponseWriter, r *http.Request) {
+	auth, ok := r.Context().Value(middleware.AuthtorizationContextKey).(session.SessionManager)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.AuthtorizationContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	passwordJson := struct {
+		OldPassword string `json:"oldPassword"`
+		NewPassword string `json:"newPassword"`
+	}{}
+	usr, ok := h.authorizeAccount(w, r, &passwordJson, &passwordJson.OldPassword, "passwordChange")
+	if !ok {
+		return
+	}
+	if problem := passwordProblem(passwordJson.NewPassword); problem != "" {
+		frontendMessages.SendError(w, []frontendMessages.ErrorMessage{{
+			Location: "body",
+			Param:    "newPassword",
+			Message:  problem,
+		}}, http.StatusUnprocessableEntity, h.Logger, "passwordChange")
+		return
+	}
+	errUpdate := h.UserRepo.UpdatePassword(usr, user.GetPasswordHash(passwordJson.NewPassword))
+	if errUpdate != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("passwordChange: can`t update password: %w", errUpdate),
+		)
+		return
+	}
+	errRevoke := auth.RevokeOthers(r, usr.UserID)
+	if errRevoke != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("passwordChange: can`t revoke sessions: %w", errRevoke),
+		)
+		return
+	}
+	h.Logger.Infof(`changed password of user: "%s", userID: "%d"`, usr.Username, usr.UserID)
+	frontendMessages.SendMessage(w, "success", http.StatusOK, h.Logger, "passwordChange")
+}
+
+func (h *UserHandler) UsernameChange(w http.ResponseWriter, r *http.Request) {
+	auth, ok := r.Context().Value(middleware.AuthtorizationContextKey).(session.SessionManager)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.AuthtorizationContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	usernameJson := struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}{}
+	usr, ok := h.authorizeAccount(w, r, &usernameJson, &usernameJson.Password, "usernameChange")
+	if !ok {
+		return
+	}
+	errMessage := frontendMessages.ErrorMessage{
+		Location: "body",
+		Param:    "username",
+		Value:    usernameJson.Username,
+	}
+	if !usernameRe.MatchString(usernameJson.Username) {
+		errMessage.Message = "must be from 1 to 32 letters, digits, _ or -"
+	} else if _, exists := h.UserRepo.Find(usernameJson.Username); exists {
+		errMessage.Message = "already exists"
+	}
+	if errMessage.Message != "" {
+		frontendMessages.SendError(w, []frontendMessages.ErrorMessage{errMessage},
+			http.StatusUnprocessableEntity, h.Logger, "usernameChange")
+		return
+	}
+	renamed := user.User{Username: usernameJson.Username, UserID: usr.UserID}
+	errRename := h.UserRepo.Rename(usr, renamed.Username)
+	if errRename != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("usernameChange: can`t rename user: %w", errRename),
+		)
+		return
+	}
+	from := user.User{Username: usr.Username, UserID: usr.UserID}
+	if _, errPosts := h.PostRepo.RenameAuthor(from, renamed); errPosts != nil {
+		h.undoRename(usr, from, renamed)
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("usernameChange: can`t rename author of posts: %w", errPosts),
+		)
+		return
+	}
+	if errSent := h.Notifications.RenameSender(from, renamed); errSent != nil {
+		h.undoRename(usr, from, renamed)
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("usernameChange: can`t rename sender of notifications: %w", errSent),
+		)
+		return
+	}
+	errRevoke := auth.RevokeOthers(r, usr.UserID)
+	if errRevoke != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("usernameChange: can`t revoke sessions: %w", errRevoke),
+		)
+		return
+	}
+	tokenStr, errToken := token.GetToken(renamed, h.SecretKey)
+	if errToken != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("usernameChange: %w", errToken),
+		)
+		return
+	}
+	send, errMarshal := json.Marshal(sendToken{Token: tokenStr})
+	if errMarshal != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("usernameChange: %w", errors.ErrMarshal{Err: errMarshal}),
+		)
+		return
+	}
+	h.Logger.Infof(`renamed user: "%s" to "%s", userID: "%d"`, usr.Username, renamed.Username, usr.UserID)
+	w.WriteHeader(http.StatusOK)
+	w.Write(send)
+}
+
+// undoRename gives the user and the posts and notifications already renamed
+// to renamed the old name back after a failed username change.
+func (h *UserHandler) undoRename(stored, from, renamed user.User) {
+	if _, err := h.PostRepo.RenameAuthor(renamed, from); err != nil {
+		h.Logger.Errorf("usernameChange: can`t restore author of posts: %s", err)
+	}
+	if err := h.Notifications.RenameSender(renamed, from); err != nil {
+		h.Logger.Errorf("usernameChange: can`t restore sender of notifications: %s", err)
+	}
+	current := stored
+	current.Username = renamed.Username
+	if err := h.UserRepo.Rename(current, from.Username); err != nil {
+		h.Logger.Errorf("usernameChange: can`t restore username %s: %s", from.Username, err)
+	}
+}
+
+// AccountDelete removes the account, then revokes its sessions, anonymizes
+// posts, comments and sent notifications of the user and removes drafts and
+// notifications. The account goes first, so a failure later never leaves a
+// half-anonymized account that can still log in; the cleanup steps match
+// the user by name and ID and are idempotent, so all of them run even if one
+// fails and the failed ones can be retried from the logs.
+func (h *UserHandler) AccountDelete(w http.ResponseWriter, r *http.Request) {
+	auth, ok := r.Context().Value(middleware.AuthtorizationContextKey).(session.SessionManager)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.AuthtorizationContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	deleteJson := struct {
+		Password string `json:"password"`
+	}{}
+	usr, ok := h.authorizeAccount(w, r, &deleteJson, &deleteJson.Password, "accountDelete")
+	if !ok {
+		return
+	}
+	errRemove := h.UserRepo.Remove(usr)
+	if errRemove != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("accountDelete: can`t remove user: %w", errRemove),
+		)
+		return
+	}
+	from := user.User{Username: usr.Username, UserID: usr.UserID}
+	deleted := user.User{Username: user.DeletedUsername}
+	failed := []string{}
+	for _, step := range []struct {
+		what string
+		run  func() error
+	}{
+		{"revoke sessions", func() error { return auth.RevokeAll(usr.UserID) }},
+		{"anonymize posts", func() error { _, err := h.PostRepo.RenameAuthor(from, deleted); return err }},
+		{"anonymize sent notifications", func() error { return h.Notifications.RenameSender(from, deleted) }},
+		{"remove notifications", func() error { return h.Notifications.RemoveAll(usr.UserID) }},
+		{"remove drafts", func() error { return h.Drafts.RemoveAll(usr.UserID) }},
+	} {
+		if err := step.run(); err != nil {
+			failed = append(failed, fmt.Sprintf("can`t %s: %s", step.what, err))
+		}
+	}
+	if len(failed) != 0 {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf(`accountDelete: deleted user: "%s", userID: "%d": %s`, usr.Username, usr.UserID, strings.Join(failed, "; ")),
+		)
+		return
+	}
+	h.Logger.Infof(`deleted user: "%s", userID: "%d"`, usr.Username, usr.UserID)
+	frontendMessages.SendMessage(w, "success", http.StatusOK, h.Logger, "accountDelete")
+}
+
+func idStrings(ids []uint64) []string {
+	res := make([]string, 0, len(ids))
+	for _, id := range ids {
+		res = append(res, strconv.FormatUint(id, 10))
+	}
+	return res
+}
+
+func (h *UserHandler) export(usr user.User) (res accountExport, err error) {
+	res.Exported = time.Now().Format(time.RFC3339)
+	if res.Profile, err = h.UserRepo.Profile(usr.Username); err != nil {
+		return res, fmt.Errorf("can`t get profile: %w", err)
+	}
+	stored, found := h.UserRepo.Find(usr.Username)
+	if !found {
+		return res, fmt.Errorf("user not found")
+	}
+	res.Email = emailJson{Email: stored.Email, Verified: stored.EmailVerified}
+	if res.Preferences, err = h.UserRepo.Preferences(usr.UserID); err != nil {
+		return res, fmt.Errorf("can`t get preferences: %w", err)
+	}
+	// listings skip hidden and deleted items which are still stored, so the
+	// export reads everything the user wrote directly
+	posts, comments, err := h.PostRepo.Authored(usr)
+	if err != nil {
+		return res, fmt.Errorf("can`t get posts: %w", err)
+	}
+	res.Posts, res.Scheduled, res.Archived = []post.Post{}, []post.Post{}, []post.Post{}
+	for _, pst := range posts {
+		pst.ForViewer(&usr)
+		switch {
+		case pst.PublishAt != nil:
+			res.Scheduled = append(res.Scheduled, pst)
+		case pst.Archived:
+			res.Archived = append(res.Archived, pst)
+		default:
+			res.Posts = append(res.Posts, pst)
+		}
+	}
+	for i := range comments {
+		comments[i].Votes, comments[i].MyVote = frontendMessages.ViewerVotes(comments[i].Votes, &usr)
+	}
+	res.Comments = comments
+	if res.Drafts, err = h.Drafts.List(usr.UserID); err != nil {
+		return res, fmt.Errorf("can`t get drafts: %w", err)
+	}
+	for _, mark := range []struct {
+		kind string
+		dest *[]string
+	}{{database.MarkSaved, &res.Saved}, {database.MarkHidden, &res.Hidden}} {
+		ids, errMarks := h.PostMarks.List(usr.UserID, mark.kind)
+		if errMarks != nil {
+			return res, fmt.Errorf("can`t get %s posts: %w", mark.kind, errMarks)
+		}
+		*mark.dest = idStrings(ids)
+	}
+	if res.Blocked, err = h.Messages.Blocks(usr); err != nil {
+		return res, fmt.Errorf("can`t get blocked users: %w", err)
+	}
+	conversations, err := h.Messages.Conversations(usr)
+	if err != nil {
+		return res, fmt.Errorf("can`t get conversations: %w", err)
+	}
+	res.Conversations = make([]conversationExport, 0, len(conversations))
+	for _, conv := range conversations {
+		exported := conversationExport{With: conv.With, Messages: []message.Message{}}
+		for page := int64(0); ; page++ {
+			messages, errThread := h.Messages.Thread(usr, conv.With, page, exportPageLen)
+			if errThread != nil {
+				return res, fmt.Errorf("can`t get messages: %w", errThread)
+			}
+			exported.Messages = append(exported.Messages, messages...)
+			if len(messages) < exportPageLen {
+				break
+			}
+		}
+		res.Conversations = append(res.Conversations, exported)
+	}
+	res.Notifications = []notification.Notification{}
+	for page := int64(0); ; page++ {
+		inbox, errInbox := h.Notifications.List(usr.UserID, false, page, exportPageLen)
+		if errInbox != nil {
+			return res, fmt.Errorf("can`t get notifications: %w", errInbox)
+		}
+		res.Notifications = append(res.Notifications, inbox.Notifications...)
+		if len(inbox.Notifications) < exportPageLen {
+			break
+		}
+	}
+	return res, nil
+}
+
+// Export sends all personal data of the current user as a JSON archive.
+func (h *UserHandler) Export(w http.ResponseWriter, r *http.Request) {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	data, errExport := h.export(usr)
+	if errExport != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("export: %w", errExport),
+		)
+		return
+	}
+	res, errMarshal := json.Marshal(data)
+	if errMarshal != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("export: %w", errors.ErrMarshal{Err: errMarshal}),
+		)
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="redditclone-%s.json"`, usr.Username))
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
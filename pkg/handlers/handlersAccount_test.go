@@ -0,0 +1,403 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"redditclone/pkg/comment"
+	"redditclone/pkg/database"
+	"redditclone/pkg/database/mocks"
+	"redditclone/pkg/draft"
+	"redditclone/pkg/frontendMessages"
+	"redditclone/pkg/message"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/notification"
+	"redditclone/pkg/post"
+	sessionMocks "redditclone/pkg/session/mocks"
+	"redditclone/pkg/token"
+	"redditclone/pkg/user"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordChange(t *testing.T) {
+	type testCase struct {
+		request     string
+		revokeError error
+
+		statusCode int
+		response   string
+		updated    bool
+	}
+
+	usr := user.User{Username: "test", UserID: 1}
+	stored := user.User{Username: "test", UserID: 1, PasswordHash: user.GetPasswordHash("password1")}
+	testCases := []testCase{
+		{
+			request:    `{"oldPassword":"password1","newPassword":"password2"}`,
+			statusCode: http.StatusOK,
+			response:   "{\"message\":\"success\"}\n",
+			updated:    true,
+		},
+		{
+			request:    `{"oldPassword":"password3","newPassword":"password2"}`,
+			statusCode: http.StatusForbidden,
+			response:   "{\"message\":\"invalid password\"}\n",
+		},
+		{
+			request:    `{"oldPassword":"` + strings.Repeat("a", 40) + `","newPassword":"password2"}`,
+			statusCode: http.StatusForbidden,
+			response:   "{\"message\":\"invalid password\"}\n",
+		},
+		{
+			request:    `{"oldPassword":"password1","newPassword":"short"}`,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"newPassword\",\"msg\":\"must be from 8 to 32 characters long\"}]}\n",
+		},
+		{
+			request:     `{"oldPassword":"password1","newPassword":"password2"}`,
+			revokeError: fmt.Errorf("test error"),
+			statusCode:  http.StatusInternalServerError,
+			response:    "",
+			updated:     true,
+		},
+		{
+			request:    `wrong json`,
+			statusCode: http.StatusInternalServerError,
+			response:   "",
+		},
+	}
+
+	for caseNum, testCase := range testCases {
+		userHandler := setupUser()
+		defer userHandler.Logger.Sync()
+		authorization := &sessionMocks.SessionManager{}
+
+		r := httptest.NewRequest("PUT", "/api/user/me/password", strings.NewReader(testCase.request))
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, usr)
+		ctx = context.WithValue(ctx, middleware.AuthtorizationContextKey, authorization)
+		w := httptest.NewRecorder()
+
+		userHandler.UserRepo.(*mocks.UserRepo).
+			On("Find", usr.Username).
+			Return(stored, true)
+		userHandler.UserRepo.(*mocks.UserRepo).
+			On("UpdatePassword", stored, user.GetPasswordHash("password2")).
+			Return(nil)
+		authorization.
+			On("RevokeOthers", mock.Anything, usr.UserID).
+			Return(testCase.revokeError)
+
+		userHandler.PasswordChange(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode, "case %d", caseNum)
+		require.Equal(t, string(body), testCase.response, "case %d", caseNum)
+		if testCase.updated {
+			authorization.AssertCalled(t, "RevokeOthers", mock.Anything, usr.UserID)
+		} else {
+			userHandler.UserRepo.(*mocks.UserRepo).AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything)
+			authorization.AssertNotCalled(t, "RevokeOthers", mock.Anything, mock.Anything)
+		}
+	}
+}
+
+func TestUsernameChange(t *testing.T) {
+	type testCase struct {
+		request     string
+		exists      bool
+		renameError error
+		senderError error
+		statusCode  int
+		response    string
+	}
+
+	usr := user.User{Username: "test", UserID: 1}
+	stored := user.User{Username: "test", UserID: 1, PasswordHash: user.GetPasswordHash("password1")}
+	renamed := user.User{Username: "renamed", UserID: 1}
+	renamedStored := user.User{Username: "renamed", UserID: 1, PasswordHash: stored.PasswordHash}
+	testCases := []testCase{
+		{
+			request:    `{"username":"renamed","password":"password1"}`,
+			statusCode: http.StatusOK,
+		},
+		{
+			request:    `{"username":"renamed","password":"password1"}`,
+			exists:     true,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"username\",\"value\":\"renamed\",\"msg\":\"already exists\"}]}\n",
+		},
+		{
+			request:    `{"username":"[deleted]","password":"password1"}`,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"username\",\"value\":\"[deleted]\",\"msg\":\"must be from 1 to 32 letters, digits, _ or -\"}]}\n",
+		},
+		{
+			request:    `{"username":"renamed","password":"password2"}`,
+			statusCode: http.StatusForbidden,
+			response:   "{\"message\":\"invalid password\"}\n",
+		},
+		{
+			request:     `{"username":"renamed","password":"password1"}`,
+			renameError: fmt.Errorf("test error"),
+			statusCode:  http.StatusInternalServerError,
+			response:    "",
+		},
+		{
+			request:     `{"username":"renamed","password":"password1"}`,
+			senderError: fmt.Errorf("test error"),
+			statusCode:  http.StatusInternalServerError,
+			response:    "",
+		},
+	}
+
+	for caseNum, testCase := range testCases {
+		userHandler := setupUser()
+		defer userHandler.Logger.Sync()
+		authorization := &sessionMocks.SessionManager{}
+
+		r := httptest.NewRequest("PUT", "/api/user/me/username", strings.NewReader(testCase.request))
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, usr)
+		ctx = context.WithValue(ctx, middleware.AuthtorizationContextKey, authorization)
+		w := httptest.NewRecorder()
+
+		userHandler.UserRepo.(*mocks.UserRepo).
+			On("Find", usr.Username).
+			Return(stored, true)
+		userHandler.UserRepo.(*mocks.UserRepo).
+			On("Find", renamed.Username).
+			Return(renamed, testCase.exists)
+		userHandler.UserRepo.(*mocks.UserRepo).
+			On("Rename", stored, renamed.Username).
+			Return(nil)
+		userHandler.UserRepo.(*mocks.UserRepo).
+			On("Rename", renamedStored, usr.Username).
+			Return(nil)
+		userHandler.PostRepo.(*mocks.PostRepo).
+			On("RenameAuthor", usr, renamed).
+			Return(int64(2), testCase.renameError)
+		userHandler.PostRepo.(*mocks.PostRepo).
+			On("RenameAuthor", renamed, usr).
+			Return(int64(1), nil)
+		userHandler.Notifications.(*mocks.NotificationRepo).
+			On("RenameSender", usr, renamed).
+			Return(testCase.senderError)
+		userHandler.Notifications.(*mocks.NotificationRepo).
+			On("RenameSender", renamed, usr).
+			Return(nil)
+		authorization.
+			On("RevokeOthers", mock.Anything, usr.UserID).
+			Return(nil)
+
+		userHandler.UsernameChange(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode, "case %d", caseNum)
+		if testCase.renameError != nil || testCase.senderError != nil {
+			userHandler.UserRepo.(*mocks.UserRepo).AssertCalled(t, "Rename", renamedStored, usr.Username)
+			userHandler.PostRepo.(*mocks.PostRepo).AssertCalled(t, "RenameAuthor", renamed, usr)
+			userHandler.Notifications.(*mocks.NotificationRepo).AssertCalled(t, "RenameSender", renamed, usr)
+			authorization.AssertNotCalled(t, "RevokeOthers", mock.Anything, mock.Anything)
+			continue
+		}
+		if testCase.statusCode != http.StatusOK {
+			require.Equal(t, string(body), testCase.response, "case %d", caseNum)
+			userHandler.UserRepo.(*mocks.UserRepo).AssertNotCalled(t, "Rename", mock.Anything, mock.Anything)
+			continue
+		}
+		tokenStr := strings.TrimSuffix(strings.TrimPrefix(string(body), `{"token":"`), `"}`)
+		tokenUser, errToken := token.CheckToken(tokenStr, userHandler.SecretKey)
+		require.NoError(t, errToken, "case %d", caseNum)
+		require.Equal(t, tokenUser, renamed, "case %d", caseNum)
+		userHandler.PostRepo.(*mocks.PostRepo).AssertCalled(t, "RenameAuthor", usr, renamed)
+		userHandler.Notifications.(*mocks.NotificationRepo).AssertCalled(t, "RenameSender", usr, renamed)
+	}
+}
+
+func TestAccountDelete(t *testing.T) {
+	type testCase struct {
+		request     string
+		removeError error
+		renameError error
+
+		statusCode int
+		response   string
+		removed    bool
+	}
+
+	usr := user.User{Username: "test", UserID: 1}
+	stored := user.User{Username: "test", UserID: 1, PasswordHash: user.GetPasswordHash("password1")}
+	deleted := user.User{Username: user.DeletedUsername}
+	testCases := []testCase{
+		{
+			request:    `{"password":"password1"}`,
+			statusCode: http.StatusOK,
+			response:   "{\"message\":\"success\"}\n",
+			removed:    true,
+		},
+		{
+			request:    `{"password":"password2"}`,
+			statusCode: http.StatusForbidden,
+			response:   "{\"message\":\"invalid password\"}\n",
+		},
+		{
+			request:     `{"password":"password1"}`,
+			renameError: fmt.Errorf("test error"),
+			statusCode:  http.StatusInternalServerError,
+			response:    "",
+			removed:     true,
+		},
+		{
+			request:     `{"password":"password1"}`,
+			removeError: fmt.Errorf("test error"),
+			statusCode:  http.StatusInternalServerError,
+			response:    "",
+		},
+	}
+
+	for caseNum, testCase := range testCases {
+		userHandler := setupUser()
+		defer userHandler.Logger.Sync()
+		authorization := &sessionMocks.SessionManager{}
+
+		r := httptest.NewRequest("DELETE", "/api/user/me", strings.NewReader(testCase.request))
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, usr)
+		ctx = context.WithValue(ctx, middleware.AuthtorizationContextKey, authorization)
+		w := httptest.NewRecorder()
+
+		userHandler.UserRepo.(*mocks.UserRepo).
+			On("Find", usr.Username).
+			Return(stored, true)
+		userHandler.PostRepo.(*mocks.PostRepo).
+			On("RenameAuthor", usr, deleted).
+			Return(int64(3), testCase.renameError)
+		userHandler.Notifications.(*mocks.NotificationRepo).
+			On("RenameSender", usr, deleted).
+			Return(nil)
+		userHandler.Notifications.(*mocks.NotificationRepo).
+			On("RemoveAll", usr.UserID).
+			Return(nil)
+		userHandler.Drafts.(*mocks.DraftRepo).
+			On("RemoveAll", usr.UserID).
+			Return(nil)
+		userHandler.UserRepo.(*mocks.UserRepo).
+			On("Remove", stored).
+			Return(testCase.removeError)
+		authorization.
+			On("RevokeAll", usr.UserID).
+			Return(nil)
+
+		userHandler.AccountDelete(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode, "case %d", caseNum)
+		require.Equal(t, string(body), testCase.response, "case %d", caseNum)
+		if testCase.removed {
+			authorization.AssertCalled(t, "RevokeAll", usr.UserID)
+			userHandler.Notifications.(*mocks.NotificationRepo).AssertCalled(t, "RemoveAll", usr.UserID)
+			userHandler.Drafts.(*mocks.DraftRepo).AssertCalled(t, "RemoveAll", usr.UserID)
+		} else {
+			authorization.AssertNotCalled(t, "RevokeAll", mock.Anything)
+			userHandler.PostRepo.(*mocks.PostRepo).AssertNotCalled(t, "RenameAuthor", mock.Anything, mock.Anything)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	usr := user.User{Username: "test", UserID: 1}
+	other := user.User{Username: "other", UserID: 2}
+
+	userHandler := setupUser()
+	defer userHandler.Logger.Sync()
+
+	r := httptest.NewRequest("GET", "/api/user/me/export", nil)
+	ctx := context.WithValue(r.Context(), middleware.UserContextKey, usr)
+	w := httptest.NewRecorder()
+
+	userHandler.UserRepo.(*mocks.UserRepo).
+		On("Profile", usr.Username).
+		Return(user.Profile{Username: "test", UserID: 1, Created: "2022-05-01T10:00:00Z"}, nil)
+	userHandler.UserRepo.(*mocks.UserRepo).
+		On("Find", usr.Username).
+		Return(user.User{Username: "test", UserID: 1, Email: "test@example.com", EmailVerified: true}, true)
+	userHandler.UserRepo.(*mocks.UserRepo).
+		On("Preferences", usr.UserID).
+		Return(user.DefaultPreferences(), nil)
+	publishAt := time.Date(2022, 5, 3, 10, 0, 0, 0, time.UTC)
+	userHandler.PostRepo.(*mocks.PostRepo).
+		On("Authored", usr).
+		Return(
+			[]post.Post{
+				{Title: "hidden", ID: 1, Hidden: true},
+				{Title: "old", ID: 2, Archived: true},
+				{Title: "later", ID: 6, PublishAt: &publishAt},
+			},
+			[]comment.UserComment{{
+				Comment: comment.Comment{ID: 3, Author: usr, Body: "hi", Time: "t", Votes: []frontendMessages.Vote{{UserID: 2, Vote: 1}}},
+				PostID:  1, PostTitle: "title",
+			}},
+			nil,
+		)
+	userHandler.Drafts.(*mocks.DraftRepo).
+		On("List", usr.UserID).
+		Return([]draft.Draft{{Comment: &draft.Comment{PostID: 1, Body: "later"}}}, nil)
+	userHandler.Notifications.(*mocks.NotificationRepo).
+		On("List", usr.UserID, false, int64(0), int64(exportPageLen)).
+		Return(notification.Inbox{Notifications: []notification.Notification{{Kind: notification.KindPostReply, PostID: 1}}}, nil)
+	userHandler.PostMarks.(*mocks.PostMarkRepo).
+		On("List", usr.UserID, database.MarkSaved).
+		Return([]uint64{5}, nil)
+	userHandler.PostMarks.(*mocks.PostMarkRepo).
+		On("List", usr.UserID, database.MarkHidden).
+		Return([]uint64{}, nil)
+	userHandler.Messages.(*mocks.MessageRepo).
+		On("Blocks", usr).
+		Return([]user.User{other}, nil)
+	userHandler.Messages.(*mocks.MessageRepo).
+		On("Conversations", usr).
+		Return([]message.Conversation{{With: other}}, nil)
+	userHandler.Messages.(*mocks.MessageRepo).
+		On("Thread", usr, other, int64(0), int64(exportPageLen)).
+		Return([]message.Message{{ID: 4, From: usr, To: other, Body: "hello", Created: "t"}}, nil)
+
+	userHandler.Export(w, r.WithContext(ctx))
+
+	resp := w.Result()
+	body, errRead := ioutil.ReadAll(resp.Body)
+	require.NoError(t, errRead)
+	require.Equal(t, resp.StatusCode, http.StatusOK)
+	require.Equal(t, resp.Header.Get("Content-Disposition"), `attachment; filename="redditclone-test.json"`)
+	require.Contains(t, string(body), `"email":{"email":"test@example.com","verified":true}`)
+	require.Contains(t, string(body), `"posts":[{"title":"hidden"`)
+	require.Contains(t, string(body), `"scheduled":[{"title":"later"`)
+	require.Contains(t, string(body), `"archived":[{"title":"old"`)
+	require.NotContains(t, string(body), `"user":"2"`)
+	require.Contains(t, string(body), `"comment":{"postId":"1","comment":"later"}`)
+	require.Contains(t, string(body), `"notifications":[{`)
+	require.Contains(t, string(body), `"comments":[{"author":{"username":"test","id":"1"},"body":"hi"`)
+	require.Contains(t, string(body), `"saved":["5"],"hidden":[],"blocked":[{"username":"other","id":"2"}]`)
+	require.Contains(t, string(body), `"conversations":[{"with":{"username":"other","id":"2"},"messages":[{"id":"4"`)
+
+	userHandler = setupUser()
+	w = httptest.NewRecorder()
+	userHandler.UserRepo.(*mocks.UserRepo).
+		On("Profile", usr.Username).
+		Return(user.Profile{}, fmt.Errorf("test error"))
+	userHandler.Export(w, r.WithContext(ctx))
+	require.Equal(t, w.Result().StatusCode, http.StatusInternalServerError)
+}
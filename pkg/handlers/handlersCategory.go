@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"redditclone/pkg/errors"
+	"redditclone/pkg/flair"
+	"redditclone/pkg/frontendMessages"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/post"
+	"redditclone/pkg/token"
+	"redditclone/pkg/user"
+
+	"github.com/gorilla/mux"
+)
+
+// prepareFlair replaces the flair of a new post with its definition in the
+// category. It returns false if the response was already sent.
+func (h *PostHandler) prepareFlair(w http.ResponseWriter, pst *post.Post, from string) (bool, error) {
+	if pst.Flair == nil || pst.Flair.Text == "" {
+		pst.Flair = nil
+		return true, nil
+	}
+	flairs, err := h.CategoryRepo.Flairs(pst.Category)
+	if err != nil {
+		return false, fmt.Errorf("can`t get flairs: %w", err)
+	}
+	flr, found := flair.Find(flairs, pst.Flair.Text)
+	if !found {
+		frontendMessages.SendError(w, []frontendMessages.ErrorMessage{{
+			Location: "body",
+			Param:    "flair",
+			Value:    pst.Flair.Text,
+			Message:  "is not defined in this category",
+		}}, http.StatusUnprocessableEntity, h.Logger, from)
+		return false, nil
+	}
+	pst.Flair = &flr
+	return true, nil
+}
+
+// flairText resolves a flair asked for in a listing to the text stored on
+// posts, flairs are matched regardless of case like on creation.
+func (h *PostHandler) flairText(category, text string) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	flairs, err := h.CategoryRepo.Flairs(category)
+	if err != nil {
+		return "", fmt.Errorf("can`t get flairs: %w", err)
+	}
+	if flr, found := flair.Find(flairs, text); found {
+		return flr.Text, nil
+	}
+	return text, nil
+}
+
+func (h *CategoryHandler) sendJson(w http.ResponseWriter, data interface{}, from string) {
+	res, errMarshal := json.Marshal(data)
+	if errMarshal != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("%s: %w", from, errors.ErrMarshal{Err: errMarshal}),
+		)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+func (h *CategoryHandler) Flairs(w http.ResponseWriter, r *http.Request) {
+	category, errGet := token.GetMapItemString(mux.Vars(r), "category_name")
+	if errGet != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("Flairs: %w", errors.ErrRequest{Err: errGet}),
+		)
+		return
+	}
+	flairs, err := h.CategoryRepo.Flairs(category)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("Flairs: %w", err),
+		)
+		return
+	}
+	h.sendJson(w, flairs, "Flairs")
+}
+
+// flairChange checks that usr may change flairs of the category from the
+// request vars and returns the category, on false the response was already
+// sent.
+func (h *CategoryHandler) flairChange(w http.ResponseWriter, r *http.Request, from string) (string, bool, error) {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return "", false, nil
+	}
+	category, errGet := token.GetMapItemString(mux.Vars(r), "category_name")
+	if errGet != nil {
+		return "", false, errors.ErrRequest{Err: errGet}
+	}
+	allowed, err := canManageCategory(h.CategoryRepo, h.UserRepo, category, usr)
+	if err != nil {
+		return "", false, err
+	}
+	if !allowed {
+		frontendMessages.SendMessage(w,
+			"only owners of the category can change its flairs",
+			http.StatusForbidden,
+			h.Logger, from,
+		)
+		return "", false, nil
+	}
+	return category, true, nil
+}
+
+func (h *CategoryHandler) flairSet(w http.ResponseWriter, r *http.Request) error {
+	body, _ := ioutil.ReadAll(r.Body)
+	category, ok, err := h.flairChange(w, r, "flairSet")
+	if !ok {
+		return err
+	}
+	flr := flair.Flair{}
+	if errUnmarshal := json.Unmarshal(body, &flr); errUnmarshal != nil {
+		return errors.ErrUnmarshalRequest{Err: errUnmarshal}
+	}
+	if param, problem := flr.Check(); problem != "" {
+		frontendMessages.SendError(w, []frontendMessages.ErrorMessage{{
+			Location: "body",
+			Param:    param,
+			Message:  problem,
+		}}, http.StatusUnprocessableEntity, h.Logger, "flairSet")
+		return nil
+	}
+	if errSet := h.CategoryRepo.SetFlair(category, flr); errSet != nil {
+		return errSet
+	}
+	flairs, errFlairs := h.CategoryRepo.Flairs(category)
+	if errFlairs != nil {
+		return errFlairs
+	}
+	h.sendJson(w, flairs, "flairSet")
+	return nil
+}
+
+func (h *CategoryHandler) FlairSet(w http.ResponseWriter, r *http.Request) {
+	err := h.flairSet(w, r)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("flairSet: %w", err),
+		)
+	}
+}
+
+// flairRemove removes the definition only, posts keep the flair they have.
+func (h *CategoryHandler) flairRemove(w http.ResponseWriter, r *http.Request) error {
+	category, ok, err := h.flairChange(w, r, "flairRemove")
+	if !ok {
+		return err
+	}
+	text, errGet := token.GetMapItemString(mux.Vars(r), "flair_text")
+	if errGet != nil {
+		return errors.ErrRequest{Err: errGet}
+	}
+	removed, errRemove := h.CategoryRepo.RemoveFlair(category, text)
+	if errRemove != nil {
+		return errRemove
+	}
+	if !removed {
+		frontendMessages.SendMessage(w,
+			"flair not found",
+			http.StatusNotFound,
+			h.Logger, "flairRemove",
+		)
+		return nil
+	}
+	frontendMessages.SendMessage(w,
+		"success",
+		http.StatusOK,
+		h.Logger, "flairRemove",
+	)
+	return nil
+}
+
+func (h *CategoryHandler) FlairRemove(w http.ResponseWriter, r *http.Request) {
+	err := h.flairRemove(w, r)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("flairRemove: %w", err),
+		)
+	}
+}
+
+func (h *CategoryHandler) Owners(w http.ResponseWriter, r *http.Request) {
+	category, errGet := token.GetMapItemString(mux.Vars(r), "category_name")
+	if errGet != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("Owners: %w", errors.ErrRequest{Err: errGet}),
+		)
+		return
+	}
+	owners, err := h.CategoryRepo.Owners(category)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("Owners: %w", err),
+		)
+		return
+	}
+	h.sendJson(w, owners, "Owners")
+}
+
+// setOwner gives or takes the category from the user in the request vars,
+// only admins may do it.
+func (h *CategoryHandler) setOwner(w http.ResponseWriter, r *http.Request, add bool, from string) error {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+	vars := mux.Vars(r)
+	category, errGet := token.GetMapItemString(vars, "category_name")
+	if errGet != nil {
+		return errors.ErrRequest{Err: errGet}
+	}
+	username, errGet := token.GetMapItemString(vars, "user_login")
+	if errGet != nil {
+		return errors.ErrRequest{Err: errGet}
+	}
+	role, errRole := h.UserRepo.Role(usr.UserID)
+	if errRole != nil {
+		return fmt.Errorf("can`t get role: %w", errRole)
+	}
+	if role != user.RoleAdmin {
+		frontendMessages.SendMessage(w,
+			"only admins can change owners of categories",
+			http.StatusForbidden,
+			h.Logger, from,
+		)
+		return nil
+	}
+	owner, found := h.UserRepo.Find(username)
+	if !found {
+		frontendMessages.SendMessage(w,
+			"user not found",
+			http.StatusNotFound,
+			h.Logger, from,
+		)
+		return nil
+	}
+	var err error
+	if add {
+		err = h.CategoryRepo.AddOwner(category, owner)
+	} else {
+		err = h.CategoryRepo.RemoveOwner(category, owner)
+	}
+	if err != nil {
+		return err
+	}
+	frontendMessages.SendMessage(w,
+		"success",
+		http.StatusOK,
+		h.Logger, from,
+	)
+	return nil
+}
+
+func (h *CategoryHandler) OwnerAdd(w http.ResponseWriter, r *http.Request) {
+	err := h.setOwner(w, r, true, "ownerAdd")
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("ownerAdd: %w", err),
+		)
+	}
+}
+
+func (h *CategoryHandler) OwnerRemove(w http.ResponseWriter, r *http.Request) {
+	err := h.setOwner(w, r, false, "ownerRemove")
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("ownerRemove: %w", err),
+		)
+	}
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"redditclone/pkg/database/mocks"
+	"redditclone/pkg/flair"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/post"
+	"redditclone/pkg/user"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlairSet(t *testing.T) {
+	type testCase struct {
+		body string
+
+		owner bool
+		role  string
+
+		setError error
+
+		statusCode int
+		response   string
+	}
+
+	usr := user.User{Username: "test", UserID: 1}
+	news := flair.Flair{Text: "News", Color: "#ff4500"}
+	testCases := []testCase{
+		{
+			body:       `{"text":" News ","color":"#FF4500"}`,
+			owner:      true,
+			statusCode: http.StatusOK,
+			response:   `[{"text":"News","color":"#ff4500"}]`,
+		},
+		{
+			body:       `{"text":"News","color":"#ff4500"}`,
+			role:       user.RoleModerator,
+			statusCode: http.StatusOK,
+			response:   `[{"text":"News","color":"#ff4500"}]`,
+		},
+		{
+			body:       `{"text":"News","color":"#ff4500"}`,
+			role:       user.RoleUser,
+			statusCode: http.StatusForbidden,
+			response:   "{\"message\":\"only owners of the category can change its flairs\"}\n",
+		},
+		{
+			body:       `{"text":"News","color":"red"}`,
+			owner:      true,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"color\",\"msg\":\"must be a hex color like #ff4500\"}]}\n",
+		},
+		{
+			body:       `{"text":"News","color":"#ff4500"}`,
+			owner:      true,
+			setError:   fmt.Errorf("test error"),
+			statusCode: http.StatusInternalServerError,
+			response:   "",
+		},
+	}
+
+	for caseNum, testCase := range testCases {
+		categoryHandler := setupCategory()
+		defer categoryHandler.Logger.Sync()
+
+		r := httptest.NewRequest("POST", "/api/category/music/flairs", strings.NewReader(testCase.body))
+		r = mux.SetURLVars(r, map[string]string{"category_name": "music"})
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, usr)
+		w := httptest.NewRecorder()
+
+		categoryHandler.CategoryRepo.(*mocks.CategoryRepo).
+			On("IsOwner", "music", usr).
+			Return(testCase.owner, nil)
+		categoryHandler.UserRepo.(*mocks.UserRepo).
+			On("Role", usr.UserID).
+			Return(testCase.role, nil)
+		categoryHandler.CategoryRepo.(*mocks.CategoryRepo).
+			On("SetFlair", "music", news).
+			Return(testCase.setError)
+		categoryHandler.CategoryRepo.(*mocks.CategoryRepo).
+			On("Flairs", "music").
+			Return([]flair.Flair{news}, nil)
+
+		categoryHandler.FlairSet(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode, "case %d", caseNum)
+		require.Equal(t, string(body), testCase.response, "case %d", caseNum)
+	}
+}
+
+func TestFlairRemove(t *testing.T) {
+	type testCase struct {
+		removed bool
+
+		statusCode int
+		response   string
+	}
+
+	usr := user.User{Username: "test", UserID: 1}
+	testCases := []testCase{
+		{
+			removed:    true,
+			statusCode: http.StatusOK,
+			response:   "{\"message\":\"success\"}\n",
+		},
+		{
+			removed:    false,
+			statusCode: http.StatusNotFound,
+			response:   "{\"message\":\"flair not found\"}\n",
+		},
+	}
+
+	for caseNum, testCase := range testCases {
+		categoryHandler := setupCategory()
+		defer categoryHandler.Logger.Sync()
+
+		r := httptest.NewRequest("DELETE", "/api/category/music/flairs/News", nil)
+		r = mux.SetURLVars(r, map[string]string{"category_name": "music", "flair_text": "News"})
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, usr)
+		w := httptest.NewRecorder()
+
+		categoryHandler.CategoryRepo.(*mocks.CategoryRepo).
+			On("IsOwner", "music", usr).
+			Return(true, nil)
+		categoryHandler.CategoryRepo.(*mocks.CategoryRepo).
+			On("RemoveFlair", "music", "News").
+			Return(testCase.removed, nil)
+
+		categoryHandler.FlairRemove(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode, "case %d", caseNum)
+		require.Equal(t, string(body), testCase.response, "case %d", caseNum)
+	}
+}
+
+func TestOwnerAdd(t *testing.T) {
+	type testCase struct {
+		role string
+
+		findStatus bool
+
+		statusCode int
+		response   string
+	}
+
+	usr := user.User{Username: "admin", UserID: 1}
+	owner := user.User{Username: "owner", UserID: 2}
+	testCases := []testCase{
+		{
+			role:       user.RoleAdmin,
+			findStatus: true,
+			statusCode: http.StatusOK,
+			response:   "{\"message\":\"success\"}\n",
+		},
+		{
+			role:       user.RoleModerator,
+			findStatus: true,
+			statusCode: http.StatusForbidden,
+			response:   "{\"message\":\"only admins can change owners of categories\"}\n",
+		},
+		{
+			role:       user.RoleAdmin,
+			findStatus: false,
+			statusCode: http.StatusNotFound,
+			response:   "{\"message\":\"user not found\"}\n",
+		},
+	}
+
+	for caseNum, testCase := range testCases {
+		categoryHandler := setupCategory()
+		defer categoryHandler.Logger.Sync()
+
+		r := httptest.NewRequest("POST", "/api/category/music/owners/owner", nil)
+		r = mux.SetURLVars(r, map[string]string{"category_name": "music", "user_login": "owner"})
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, usr)
+		w := httptest.NewRecorder()
+
+		categoryHandler.UserRepo.(*mocks.UserRepo).
+			On("Role", usr.UserID).
+			Return(testCase.role, nil)
+		categoryHandler.UserRepo.(*mocks.UserRepo).
+			On("Find", "owner").
+			Return(owner, testCase.findStatus)
+		categoryHandler.CategoryRepo.(*mocks.CategoryRepo).
+			On("AddOwner", "music", owner).
+			Return(nil)
+
+		categoryHandler.OwnerAdd(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode, "case %d", caseNum)
+		require.Equal(t, string(body), testCase.response, "case %d", caseNum)
+		if testCase.statusCode != http.StatusOK {
+			categoryHandler.CategoryRepo.(*mocks.CategoryRepo).AssertNotCalled(t, "AddOwner", mock.Anything, mock.Anything)
+		}
+	}
+}
+
+func TestPostAddFlair(t *testing.T) {
+	news := flair.Flair{Text: "News", Color: "#ff4500"}
+
+	postHandler := setupPost()
+	defer postHandler.Logger.Sync()
+	postHandler.CategoryRepo.(*mocks.CategoryRepo).
+		On("Flairs", "music").
+		Return([]flair.Flair{news}, nil)
+	postHandler.PostRepo.(*mocks.PostRepo).
+		On("Add", mock.AnythingOfType("*post.Post")).
+		Return(nil)
+
+	r := httptest.NewRequest("POST", "/api/posts", strings.NewReader(
+		`{"type":"text","title":"title","text":"text","category":"music","flair":{"text":"Other"}}`,
+	))
+	ctx := context.WithValue(r.Context(), middleware.UserContextKey, user.User{Username: "test", UserID: 0})
+	w := httptest.NewRecorder()
+
+	postHandler.PostAdd(w, r.WithContext(ctx))
+
+	resp := w.Result()
+	body, errRead := ioutil.ReadAll(resp.Body)
+	require.NoError(t, errRead)
+	require.Equal(t, resp.StatusCode, http.StatusUnprocessableEntity)
+	require.Equal(t, string(body), "{\"errors\":[{\"location\":\"body\",\"param\":\"flair\",\"value\":\"Other\",\"msg\":\"is not defined in this category\"}]}\n")
+	postHandler.PostRepo.(*mocks.PostRepo).AssertNotCalled(t, "Add", mock.Anything)
+
+	r = httptest.NewRequest("POST", "/api/posts", strings.NewReader(
+		`{"type":"text","title":"title","text":"text","category":"music","flair":{"text":"news","color":"#000000"}}`,
+	))
+	w = httptest.NewRecorder()
+
+	postHandler.PostAdd(w, r.WithContext(ctx))
+
+	require.Equal(t, w.Result().StatusCode, http.StatusOK)
+	added := postHandler.PostRepo.(*mocks.PostRepo).Calls[0].Arguments.Get(0).(*post.Post)
+	require.Equal(t, added.Flair, &news)
+}
+
+func TestCategoriesFlair(t *testing.T) {
+	postHandler := setupPost()
+	defer postHandler.Logger.Sync()
+
+	r := httptest.NewRequest("GET", "/api/posts/music?flair=%20news", nil)
+	r = mux.SetURLVars(r, map[string]string{"category_name": "music"})
+	w := httptest.NewRecorder()
+
+	postHandler.CategoryRepo.(*mocks.CategoryRepo).
+		On("Flairs", "music").
+		Return([]flair.Flair{{Text: "News", Color: "#0000ff"}}, nil)
+	postHandler.PostRepo.(*mocks.PostRepo).
+		On("ToJson", post.Filter{Category: "music", Flair: "News", Preferences: user.DefaultPreferences()}).
+		Return([]byte("[]"), nil)
+
+	postHandler.Categories(w, r)
+
+	resp := w.Result()
+	body, errRead := ioutil.ReadAll(resp.Body)
+	require.NoError(t, errRead)
+	require.Equal(t, resp.StatusCode, http.StatusOK)
+	require.Equal(t, string(body), "[]")
+}
@@ -7,15 +7,56 @@ import (
 	"net/http"
 	"redditclone/pkg/comment"
 	"redditclone/pkg/errors"
+	"redditclone/pkg/events"
 	"redditclone/pkg/frontendMessages"
+	"redditclone/pkg/markdown"
 	"redditclone/pkg/middleware"
+	"redditclone/pkg/notification"
 	"redditclone/pkg/token"
 	"redditclone/pkg/user"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
 )
 
+const (
+	defaultPageLimit = 25
+	maxPageLimit     = 100
+	maxMentions      = 5
+	maxCommentLen    = 2000
+)
+
+func getPagination(r *http.Request) (page, limit int64, errs []frontendMessages.ErrorMessage) {
+	limit = defaultPageLimit
+	query := r.URL.Query()
+	if pageStr := query.Get("page"); pageStr != "" {
+		pageParse, errParse := strconv.ParseInt(pageStr, 10, 64)
+		if errParse != nil || pageParse < 0 {
+			errs = append(errs, frontendMessages.ErrorMessage{
+				Location: "query",
+				Param:    "page",
+				Value:    pageStr,
+				Message:  "must be a non-negative integer",
+			})
+		}
+		page = pageParse
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limitParse, errParse := strconv.ParseInt(limitStr, 10, 64)
+		if errParse != nil || limitParse <= 0 || limitParse > maxPageLimit {
+			errs = append(errs, frontendMessages.ErrorMessage{
+				Location: "query",
+				Param:    "limit",
+				Value:    limitStr,
+				Message:  fmt.Sprintf("must be an integer from 1 to %d", maxPageLimit),
+			})
+		}
+		limit = limitParse
+	}
+	return
+}
+
 func (h *PostHandler) CommentAdd(w http.ResponseWriter, r *http.Request) {
 	body, _ := ioutil.ReadAll(r.Body)
 	vars := mux.Vars(r)
@@ -34,7 +75,8 @@ func (h *PostHandler) CommentAdd(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	type readComment struct {
-		Body string `json:"comment"`
+		Body   string  `json:"comment"`
+		Parent *uint64 `json:"parent,string"`
 	}
 	readCmt := readComment{}
 	errUnmarchal := json.Unmarshal(body, &readCmt)
@@ -45,9 +87,15 @@ func (h *PostHandler) CommentAdd(w http.ResponseWriter, r *http.Request) {
 		)
 		return
 	}
-	if readCmt.Body == "" || len(readCmt.Body) >= 2000 {
+	h.addComment(w, usr, id, readCmt.Body, readCmt.Parent)
+}
+
+// addComment checks and adds a comment of usr to the post, it reports
+// whether the comment was added. The response is always sent.
+func (h *PostHandler) addComment(w http.ResponseWriter, usr user.User, id uint64, text string, parent *uint64) bool {
+	if text == "" || len(text) >= maxCommentLen {
 		var err frontendMessages.ErrorMessage
-		if readCmt.Body == "" {
+		if text == "" {
 			err = frontendMessages.ErrorMessage{
 				Location: "body",
 				Param:    "comment",
@@ -57,7 +105,7 @@ func (h *PostHandler) CommentAdd(w http.ResponseWriter, r *http.Request) {
 			err = frontendMessages.ErrorMessage{
 				Location: "body",
 				Param:    "comment",
-				Message:  "must be at most 2000 characters long",
+				Message:  fmt.Sprintf("must be at most %d characters long", maxCommentLen),
 			}
 		}
 		res, errMarshal := json.Marshal(frontendMessages.Error{Errors: []frontendMessages.ErrorMessage{err}})
@@ -66,18 +114,19 @@ func (h *PostHandler) CommentAdd(w http.ResponseWriter, r *http.Request) {
 				h.Logger, w,
 				fmt.Errorf("postAddComment: %w", errors.ErrMarshal{Err: errMarshal}),
 			)
-			return
+			return false
 		}
 		http.Error(w, string(res), http.StatusUnprocessableEntity)
-		return
+		return false
 	}
+	mentions := h.mentions(text, usr)
 	if !h.PostRepo.Lock(id) {
 		frontendMessages.SendMessage(w,
 			"post not found",
 			http.StatusNotFound,
 			h.Logger, "postAddComment",
 		)
-		return
+		return false
 	}
 	pst, errGet := h.PostRepo.Get(id)
 	if errGet != nil {
@@ -86,14 +135,57 @@ func (h *PostHandler) CommentAdd(w http.ResponseWriter, r *http.Request) {
 			h.Logger, w,
 			fmt.Errorf("postAddComment: %w", errGet),
 		)
-		return
+		return false
+	}
+	if h.closedPost(w, &pst, usr, "postAddComment") {
+		h.PostRepo.Unlock(id)
+		return false
+	}
+	recipient := pst.Author
+	kind := notification.KindPostReply
+	if parent != nil {
+		parentIndex := commentIndex(pst, *parent)
+		if parentIndex == -1 {
+			h.PostRepo.Unlock(id)
+			frontendMessages.SendMessage(w,
+				"parent comment not found",
+				http.StatusNotFound,
+				h.Logger, "postAddComment",
+			)
+			return false
+		}
+		recipient = pst.Comments[parentIndex].Author
+		kind = notification.KindCommentReply
+	}
+	if recipient.UserID != usr.UserID {
+		blocked, errBlocked := h.Blocks.IsBlocked(recipient, usr)
+		if errBlocked != nil {
+			h.PostRepo.Unlock(id)
+			errors.SendHttpError(
+				h.Logger, w,
+				fmt.Errorf("postAddComment: %w", errBlocked),
+			)
+			return false
+		}
+		if blocked {
+			h.PostRepo.Unlock(id)
+			frontendMessages.SendMessage(w,
+				"this user doesn't accept your replies",
+				http.StatusForbidden,
+				h.Logger, "postAddComment",
+			)
+			return false
+		}
 	}
 	cmt := comment.Comment{
-		Author: usr,
-		Body:   readCmt.Body,
-		ID:     pst.GetID(),
-		Time:   time.Now().Format(time.RFC3339),
+		Author:   usr,
+		Body:     text,
+		ID:       pst.GetID(),
+		Parent:   parent,
+		Time:     time.Now().Format(time.RFC3339),
+		Mentions: mentions,
 	}
+	cmt.Render()
 	pst.Comments = append(pst.Comments, cmt)
 	errUpdate := h.PostRepo.Update(pst)
 	h.PostRepo.Unlock(id)
@@ -102,18 +194,73 @@ func (h *PostHandler) CommentAdd(w http.ResponseWriter, r *http.Request) {
 			h.Logger, w,
 			fmt.Errorf("postAddComment: %w", errGet),
 		)
-		return
+		return false
+	}
+	h.notify(recipient, usr, kind, pst.ID, cmt.ID, cmt.Body)
+	for _, mentioned := range mentions {
+		if mentioned != recipient {
+			h.notifyMention(mentioned, usr, notification.KindCommentMention, pst.ID, cmt.ID, cmt.Body)
+		}
 	}
+	// subscribers get the comment as anonymous visitors see it
+	published := cmt
+	published.ForViewer(nil)
+	h.Events.Publish(events.Event{Type: events.CommentAdded, PostID: pst.ID, Category: pst.Category, Data: published})
+	pst.ForViewer(&usr)
 	pstJson, err := json.Marshal(pst)
 	if err != nil {
 		errors.SendHttpError(
 			h.Logger, w,
 			fmt.Errorf("postAddComment: %w", errors.ErrMarshal{Err: err}),
 		)
-		return
+		return true
 	}
 	w.WriteHeader(http.StatusOK)
 	w.Write(pstJson)
+	return true
+}
+
+func (h *PostHandler) notify(recipient, from user.User, kind string, postID, commentID uint64, text string) {
+	if recipient.Username == from.Username && recipient.UserID == from.UserID {
+		return
+	}
+	errNotify := h.Notifications.Add(&notification.Notification{
+		UserID:    recipient.UserID,
+		Kind:      kind,
+		From:      from,
+		PostID:    postID,
+		CommentID: commentID,
+		Text:      notification.Snippet(text),
+	})
+	if errNotify != nil {
+		h.Logger.Errorf("can`t notify user %d: %s", recipient.UserID, errNotify)
+	}
+}
+
+// notifyMention notifies a mentioned user unless they blocked the author.
+func (h *PostHandler) notifyMention(mentioned, from user.User, kind string, postID, commentID uint64, text string) {
+	blocked, errBlocked := h.Blocks.IsBlocked(mentioned, from)
+	if errBlocked != nil {
+		h.Logger.Errorf("can`t check blocks of user %d: %s", mentioned.UserID, errBlocked)
+		return
+	}
+	if !blocked {
+		h.notify(mentioned, from, kind, postID, commentID, text)
+	}
+}
+
+// mentions resolves users mentioned in text, at most maxMentions of them, so
+// a single item can't be used to spam notifications.
+func (h *PostHandler) mentions(text string, author user.User) []user.User {
+	var res []user.User
+	for _, username := range markdown.Mentions(text, maxMentions) {
+		usr, ok := h.UserRepo.Find(username)
+		if !ok || usr.UserID == author.UserID {
+			continue
+		}
+		res = append(res, user.User{Username: usr.Username, UserID: usr.UserID})
+	}
+	return res
 }
 
 func (h *PostHandler) CommentRemove(w http.ResponseWriter, r *http.Request) {
@@ -151,7 +298,7 @@ func (h *PostHandler) CommentRemove(w http.ResponseWriter, r *http.Request) {
 	pst, _ := h.PostRepo.Get(idPost)
 	flag := true
 	for i, cmt := range pst.Comments {
-		if cmt.ID == idComment {
+		if cmt.ID == idComment && cmt.DeletedAt == nil {
 			if cmt.Author.UserID != usr.UserID || cmt.Author.Username != usr.Username {
 				h.Logger.Errorf("PostRemoveComment: can`t remove comment: Author: {Username: %s, UserID: %d}, User: {Username: %s, UserID: %d}, commeniID: %d",
 					cmt.Author.Username, cmt.Author.UserID, usr.Username, usr.UserID, idComment,
@@ -164,7 +311,7 @@ func (h *PostHandler) CommentRemove(w http.ResponseWriter, r *http.Request) {
 				h.PostRepo.Unlock(idPost)
 				return
 			}
-			pst.Comments = token.RemoveInArr(pst.Comments, uint(i))
+			pst.Comments[i].MarkDeleted(usr, r.URL.Query().Get("reason"))
 			flag = false
 			errUpdate := h.PostRepo.Update(pst)
 			if errUpdate != nil {
@@ -187,6 +334,13 @@ func (h *PostHandler) CommentRemove(w http.ResponseWriter, r *http.Request) {
 		)
 		return
 	}
+	h.Events.Publish(events.Event{
+		Type:     events.CommentRemoved,
+		PostID:   pst.ID,
+		Category: pst.Category,
+		Data:     events.CommentRef{CommentID: idComment},
+	})
+	pst.ForViewer(&usr)
 	res, errMarshal := json.Marshal(pst)
 	if errMarshal != nil {
 		errors.SendHttpError(
@@ -198,3 +352,84 @@ func (h *PostHandler) CommentRemove(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write(res)
 }
+
+// dropBlockedComments removes comments written by the blocked users.
+func dropBlockedComments(comments []comment.UserComment, blocked []int64) []comment.UserComment {
+	if len(blocked) == 0 {
+		return comments
+	}
+	isBlocked := map[int64]bool{}
+	for _, userID := range blocked {
+		isBlocked[userID] = true
+	}
+	res := make([]comment.UserComment, 0, len(comments))
+	for _, cmt := range comments {
+		if !isBlocked[cmt.Author.UserID] {
+			res = append(res, cmt)
+		}
+	}
+	return res
+}
+
+func (h *PostHandler) UserComments(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	username, errGet := token.GetMapItemString(vars, "user_login")
+	if errGet != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("UserComments: %w", errors.ErrRequest{Err: errGet}),
+		)
+		return
+	}
+	page, limit, errs := getPagination(r)
+	sortBy := r.URL.Query().Get("sort")
+	switch sortBy {
+	case "":
+		sortBy = comment.SortNew
+	case comment.SortNew, comment.SortTop:
+	default:
+		errs = append(errs, frontendMessages.ErrorMessage{
+			Location: "query",
+			Param:    "sort",
+			Value:    sortBy,
+			Message:  fmt.Sprintf(`must be "%s" or "%s"`, comment.SortNew, comment.SortTop),
+		})
+	}
+	if len(errs) != 0 {
+		frontendMessages.SendError(w, errs, http.StatusUnprocessableEntity, h.Logger, "UserComments")
+		return
+	}
+	comments, errComments := h.PostRepo.UserComments(username, sortBy, page, limit)
+	if errComments != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("UserComments: %w", errComments),
+		)
+		return
+	}
+	viewer := viewerFromContext(r)
+	if viewer != nil {
+		blocked, errBlocked := h.blockedIDs(*viewer)
+		if errBlocked != nil {
+			errors.SendHttpError(
+				h.Logger, w,
+				fmt.Errorf("UserComments: %w", errBlocked),
+			)
+			return
+		}
+		comments = dropBlockedComments(comments, blocked)
+	}
+	for i := range comments {
+		comments[i].ForViewer(viewer)
+	}
+	res, errMarshal := json.Marshal(comments)
+	if errMarshal != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("UserComments: %w", errors.ErrMarshal{Err: errMarshal}),
+		)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
@@ -9,7 +9,10 @@ import (
 	"net/http/httptest"
 	"redditclone/pkg/comment"
 	"redditclone/pkg/database/mocks"
+	"redditclone/pkg/frontendMessages"
+	"redditclone/pkg/markdown"
 	"redditclone/pkg/middleware"
+	"redditclone/pkg/notification"
 	"redditclone/pkg/post"
 	"redditclone/pkg/user"
 	"strings"
@@ -32,13 +35,20 @@ func TestCommentAdd(t *testing.T) {
 		postRepoGetError    error
 		postRepoUpdateError error
 
+		blocked bool
+
 		request         string
 		statusCode      int
 		responseIsPost  bool
 		responsePost    post.Post
 		responseMessage string
+
+		notifyKind   string
+		notifyUserID int64
 	}
 
+	parentID := uint64(0)
+	parentAuthor := user.User{Username: "parent", UserID: 2}
 	testCases := []testCase{
 		{
 			valueVars:           map[string]string{"post_id": "0"},
@@ -53,13 +63,90 @@ func TestCommentAdd(t *testing.T) {
 			statusCode:          http.StatusOK,
 			responseIsPost:      true,
 			responsePost: post.Post{
+				Votes: []frontendMessages.Vote{},
 				Comments: []comment.Comment{{
-					Author: user.User{Username: "test", UserID: 0},
-					Body:   "test comment",
-					Time:   "",
-					ID:     0,
+					Author:   user.User{Username: "test", UserID: 0},
+					Body:     "test comment",
+					BodyHTML: "<p>test comment</p>\n",
+					Time:     "",
+					ID:       0,
 				}},
 			},
+			notifyKind:   notification.KindPostReply,
+			notifyUserID: 0,
+		},
+		{
+			valueVars:          map[string]string{"post_id": "0"},
+			contextKey:         middleware.UserContextKey,
+			contextValue:       user.User{Username: "test", UserID: 1},
+			postID:             0,
+			postRepoLockStatus: true,
+			postRepoGetPost: post.Post{
+				Author:    user.User{Username: "test", UserID: 1},
+				Comments:  []comment.Comment{{Author: parentAuthor, Body: "parent", ID: 0}},
+				CommentID: 1,
+			},
+			request:        `{"comment":"reply","parent":"0"}`,
+			statusCode:     http.StatusOK,
+			responseIsPost: true,
+			responsePost: post.Post{
+				Author: user.User{Username: "test", UserID: 1},
+				Votes:  []frontendMessages.Vote{},
+				Comments: []comment.Comment{
+					{Author: parentAuthor, Body: "parent", BodyHTML: "<p>parent</p>\n", ID: 0},
+					{Author: user.User{Username: "test", UserID: 1}, Body: "reply", BodyHTML: "<p>reply</p>\n", ID: 1, Parent: &parentID},
+				},
+			},
+			notifyKind:   notification.KindCommentReply,
+			notifyUserID: parentAuthor.UserID,
+		},
+		{
+			valueVars:          map[string]string{"post_id": "0"},
+			contextKey:         middleware.UserContextKey,
+			contextValue:       user.User{Username: "test", UserID: 1},
+			postID:             0,
+			postRepoLockStatus: true,
+			postRepoGetPost: post.Post{
+				Author: user.User{Username: "test", UserID: 1},
+			},
+			request:        `{"comment":"own post"}`,
+			statusCode:     http.StatusOK,
+			responseIsPost: true,
+			responsePost: post.Post{
+				Author: user.User{Username: "test", UserID: 1},
+				Votes:  []frontendMessages.Vote{},
+				Comments: []comment.Comment{
+					{Author: user.User{Username: "test", UserID: 1}, Body: "own post", BodyHTML: "<p>own post</p>\n", ID: 0},
+				},
+			},
+		},
+		{
+			valueVars:          map[string]string{"post_id": "0"},
+			contextKey:         middleware.UserContextKey,
+			contextValue:       user.User{Username: "test", UserID: 1},
+			postID:             0,
+			postRepoLockStatus: true,
+			postRepoGetPost:    post.Post{},
+			request:            `{"comment":"reply","parent":"5"}`,
+			statusCode:         http.StatusNotFound,
+			responseIsPost:     false,
+			responseMessage:    "{\"message\":\"parent comment not found\"}\n",
+		},
+		{
+			valueVars:          map[string]string{"post_id": "0"},
+			contextKey:         middleware.UserContextKey,
+			contextValue:       user.User{Username: "test", UserID: 1},
+			postID:             0,
+			postRepoLockStatus: true,
+			postRepoGetPost: post.Post{
+				Comments:  []comment.Comment{{Author: parentAuthor, Body: "parent", ID: 0}},
+				CommentID: 1,
+			},
+			blocked:         true,
+			request:         `{"comment":"reply","parent":"0"}`,
+			statusCode:      http.StatusForbidden,
+			responseIsPost:  false,
+			responseMessage: "{\"message\":\"this user doesn't accept your replies\"}\n",
 		},
 		{
 			valueVars:           map[string]string{"wrong vars": "0"},
@@ -208,7 +295,17 @@ func TestCommentAdd(t *testing.T) {
 			On("Unlock", testCase.postID).
 			Return(true)
 
+		postHandler.Notifications.(*mocks.NotificationRepo).
+			On("Add", mock.AnythingOfType("*notification.Notification")).
+			Return(nil)
+
+		postHandler.Blocks.(*mocks.BlockRepo).
+			On("IsBlocked", mock.AnythingOfType("user.User"), mock.AnythingOfType("user.User")).
+			Return(testCase.blocked, nil)
+
+		sub := postHandler.Events.SubscribeCategory("")
 		postHandler.CommentAdd(w, r.WithContext(ctx))
+		postHandler.Events.Unsubscribe(sub)
 
 		resp := w.Result()
 
@@ -216,15 +313,35 @@ func TestCommentAdd(t *testing.T) {
 		require.NoError(t, errRead)
 		require.Equal(t, resp.StatusCode, testCase.statusCode)
 		if testCase.responseIsPost {
+			select {
+			case evt := <-sub.Events:
+				published := evt.Data.(comment.Comment)
+				require.NotEmpty(t, published.BodyHTML)
+				require.Equal(t, published.BodyHTML, markdown.Render(published.Body))
+			default:
+				t.Fatal("comment_added is not published")
+			}
+
 			var post post.Post
 			errUnmarshal := json.Unmarshal(body, &post)
 			require.NoError(t, errUnmarshal)
 			post.Time = ""
-			post.Comments[0].Time = ""
+			for i := range post.Comments {
+				post.Comments[i].Time = ""
+			}
 			require.Equal(t, post, testCase.responsePost)
 		} else {
 			require.Equal(t, string(body), testCase.responseMessage)
 		}
+		if testCase.notifyKind != "" {
+			postHandler.Notifications.(*mocks.NotificationRepo).AssertCalled(t, "Add",
+				mock.MatchedBy(func(ntf *notification.Notification) bool {
+					return ntf.Kind == testCase.notifyKind && ntf.UserID == testCase.notifyUserID
+				}),
+			)
+		} else {
+			postHandler.Notifications.(*mocks.NotificationRepo).AssertNotCalled(t, "Add", mock.Anything)
+		}
 	}
 }
 
@@ -268,7 +385,7 @@ func TestCommentRemove(t *testing.T) {
 			postRepoUpdateError: nil,
 			statusCode:          http.StatusOK,
 			responseIsPost:      true,
-			responsePost:        post.Post{Comments: []comment.Comment{}},
+			responsePost:        post.Post{Votes: []frontendMessages.Vote{}, Comments: []comment.Comment{}},
 		},
 		{
 			valueVars: map[string]string{
@@ -438,3 +555,117 @@ func TestCommentRemove(t *testing.T) {
 		}
 	}
 }
+
+func TestUserComments(t *testing.T) {
+	type testCase struct {
+		valueVars map[string]string
+		query     string
+		viewer    *user.User
+
+		username string
+		sortBy   string
+		page     int64
+		limit    int64
+
+		postRepoComments []comment.UserComment
+		postRepoError    error
+
+		statusCode int
+		response   string
+	}
+
+	testCases := []testCase{
+		{
+			valueVars: map[string]string{"user_login": "test"},
+			query:     "",
+			username:  "test",
+			sortBy:    comment.SortNew,
+			page:      0,
+			limit:     defaultPageLimit,
+			postRepoComments: []comment.UserComment{{
+				Comment:   comment.Comment{Author: user.User{Username: "test", UserID: 1}, Body: "test comment", ID: 2},
+				PostID:    3,
+				PostTitle: "test post",
+			}},
+			statusCode: http.StatusOK,
+			response:   `[{"author":{"username":"test","id":"1"},"body":"test comment","bodyHtml":"\u003cp\u003etest comment\u003c/p\u003e\n","created":"","id":"2","score":0,"myVote":0,"postId":"3","postTitle":"test post"}]`,
+		},
+		{
+			valueVars:        map[string]string{"user_login": "test"},
+			query:            "?sort=top&page=2&limit=10",
+			username:         "test",
+			sortBy:           comment.SortTop,
+			page:             2,
+			limit:            10,
+			postRepoComments: []comment.UserComment{},
+			statusCode:       http.StatusOK,
+			response:         `[]`,
+		},
+		{
+			valueVars: map[string]string{"user_login": "test"},
+			query:     "",
+			viewer:    &user.User{Username: "viewer", UserID: 5},
+			username:  "test",
+			sortBy:    comment.SortNew,
+			page:      0,
+			limit:     defaultPageLimit,
+			postRepoComments: []comment.UserComment{{
+				Comment:   comment.Comment{Author: user.User{Username: "test", UserID: 1}, Body: "test comment", ID: 2},
+				PostID:    3,
+				PostTitle: "test post",
+			}},
+			statusCode: http.StatusOK,
+			response:   `[]`,
+		},
+		{
+			valueVars:  map[string]string{"user_login": "test"},
+			query:      "?sort=old&limit=1000",
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"query\",\"param\":\"limit\",\"value\":\"1000\",\"msg\":\"must be an integer from 1 to 100\"},{\"location\":\"query\",\"param\":\"sort\",\"value\":\"old\",\"msg\":\"must be \\\"new\\\" or \\\"top\\\"\"}]}\n",
+		},
+		{
+			valueVars:  map[string]string{"wrong vars": "test"},
+			statusCode: http.StatusInternalServerError,
+			response:   "",
+		},
+		{
+			valueVars:     map[string]string{"user_login": "test"},
+			query:         "?page=1",
+			username:      "test",
+			sortBy:        comment.SortNew,
+			page:          1,
+			limit:         defaultPageLimit,
+			postRepoError: fmt.Errorf("test error"),
+			statusCode:    http.StatusInternalServerError,
+			response:      "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		postHandler := setupPost()
+		defer postHandler.Logger.Sync()
+
+		r := httptest.NewRequest("GET", "/api/user/test/comments"+testCase.query, nil)
+		r = mux.SetURLVars(r, testCase.valueVars)
+		if testCase.viewer != nil {
+			r = r.WithContext(context.WithValue(r.Context(), middleware.UserContextKey, *testCase.viewer))
+			postHandler.Blocks.(*mocks.BlockRepo).
+				On("Blocks", *testCase.viewer).
+				Return([]user.User{{Username: "test", UserID: 1}}, nil)
+		}
+		w := httptest.NewRecorder()
+
+		postHandler.PostRepo.(*mocks.PostRepo).
+			On("UserComments", testCase.username, testCase.sortBy, testCase.page, testCase.limit).
+			Return(testCase.postRepoComments, testCase.postRepoError)
+
+		postHandler.UserComments(w, r)
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		require.Equal(t, string(body), testCase.response)
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"redditclone/pkg/errors"
+	"redditclone/pkg/frontendMessages"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/post"
+	"redditclone/pkg/token"
+	"redditclone/pkg/user"
+
+	"github.com/gorilla/mux"
+)
+
+// markCrossposts updates crossposts of a removed or restored post, failures
+// only get logged as the post itself has already changed.
+func (h *PostHandler) markCrossposts(originID uint64, removed bool) {
+	if errMark := h.PostRepo.MarkCrossposts(originID, removed); errMark != nil {
+		h.Logger.Errorf("can`t mark crossposts of post %d: %s", originID, errMark)
+	}
+}
+
+func (h *PostHandler) crosspost(w http.ResponseWriter, r *http.Request) error {
+	body, _ := ioutil.ReadAll(r.Body)
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+	postID, errGet := token.GetMapItemUint64(mux.Vars(r), "post_id")
+	if errGet != nil {
+		return errors.ErrRequest{Err: errGet}
+	}
+	readCrosspost := struct {
+		Category string `json:"category"`
+	}{}
+	if errUnmarshal := json.Unmarshal(body, &readCrosspost); errUnmarshal != nil {
+		return errors.ErrUnmarshalRequest{Err: errUnmarshal}
+	}
+	if readCrosspost.Category == "" {
+		frontendMessages.SendError(w, []frontendMessages.ErrorMessage{{
+			Location: "body",
+			Param:    "category",
+			Message:  "is required",
+		}}, http.StatusUnprocessableEntity, h.Logger, "crosspost")
+		return nil
+	}
+	if !h.PostRepo.Find(postID) {
+		frontendMessages.SendMessage(w,
+			"post not found",
+			http.StatusNotFound,
+			h.Logger, "crosspost",
+		)
+		return nil
+	}
+	original, errGet := h.PostRepo.Get(postID)
+	if errGet != nil {
+		return errGet
+	}
+	if original.Hidden || original.PublishAt != nil {
+		frontendMessages.SendMessage(w,
+			"post not found",
+			http.StatusNotFound,
+			h.Logger, "crosspost",
+		)
+		return nil
+	}
+	origin := original.Crosspost
+	if origin == nil {
+		origin = &post.Origin{
+			PostID:   original.ID,
+			Title:    original.Title,
+			URL:      original.URL,
+			Category: original.Category,
+			Author:   original.Author,
+		}
+	}
+	if readCrosspost.Category == origin.Category || readCrosspost.Category == original.Category {
+		frontendMessages.SendError(w, []frontendMessages.ErrorMessage{{
+			Location: "body",
+			Param:    "category",
+			Value:    readCrosspost.Category,
+			Message:  "must differ from the category of the original post",
+		}}, http.StatusUnprocessableEntity, h.Logger, "crosspost")
+		return nil
+	}
+	h.createPost(w, usr, post.Post{
+		Type:      post.TypeCrosspost,
+		Title:     origin.Title,
+		URL:       origin.URL,
+		Domain:    original.Domain,
+		Category:  readCrosspost.Category,
+		NSFW:      original.NSFW,
+		Spoiler:   original.Spoiler,
+		Crosspost: origin,
+	})
+	return nil
+}
+
+func (h *PostHandler) Crosspost(w http.ResponseWriter, r *http.Request) {
+	err := h.crosspost(w, r)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("crosspost: %w", err),
+		)
+	}
+}
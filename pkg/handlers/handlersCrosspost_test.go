@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"redditclone/pkg/database/mocks"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/post"
+	"redditclone/pkg/user"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrosspost(t *testing.T) {
+	type testCase struct {
+		body string
+
+		postRepoFind bool
+		postRepoGet  post.Post
+
+		statusCode int
+		response   string
+		created    *post.Post
+	}
+
+	usr := user.User{Username: "test", UserID: 1}
+	author := user.User{Username: "author", UserID: 2}
+	origin := &post.Origin{
+		PostID:   3,
+		Title:    "title",
+		URL:      "https://example.com/",
+		Category: "music",
+		Author:   author,
+	}
+	original := post.Post{
+		ID:       3,
+		Type:     post.TypeLink,
+		Title:    "title",
+		URL:      "https://example.com/",
+		Domain:   "example.com",
+		Category: "music",
+		Author:   author,
+	}
+
+	testCases := []testCase{
+		{
+			body:         `{"category":"news"}`,
+			postRepoFind: true,
+			postRepoGet:  original,
+			statusCode:   http.StatusOK,
+			created: &post.Post{
+				Type:      post.TypeCrosspost,
+				Title:     "title",
+				URL:       "https://example.com/",
+				Domain:    "example.com",
+				Category:  "news",
+				Crosspost: origin,
+			},
+		},
+		{
+			body:         `{"category":"funny"}`,
+			postRepoFind: true,
+			postRepoGet: post.Post{
+				ID:        3,
+				Type:      post.TypeCrosspost,
+				Title:     "title",
+				URL:       "https://example.com/",
+				Domain:    "example.com",
+				Category:  "news",
+				Author:    usr,
+				Crosspost: origin,
+			},
+			statusCode: http.StatusOK,
+			created: &post.Post{
+				Type:      post.TypeCrosspost,
+				Title:     "title",
+				URL:       "https://example.com/",
+				Domain:    "example.com",
+				Category:  "funny",
+				Crosspost: origin,
+			},
+		},
+		{
+			body:         `{"category":"music"}`,
+			postRepoFind: true,
+			postRepoGet:  original,
+			statusCode:   http.StatusUnprocessableEntity,
+			response:     "{\"errors\":[{\"location\":\"body\",\"param\":\"category\",\"value\":\"music\",\"msg\":\"must differ from the category of the original post\"}]}\n",
+		},
+		{
+			body:       `{}`,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"category\",\"msg\":\"is required\"}]}\n",
+		},
+		{
+			body:         `{"category":"news"}`,
+			postRepoFind: false,
+			statusCode:   http.StatusNotFound,
+			response:     "{\"message\":\"post not found\"}\n",
+		},
+		{
+			body:         `{"category":"news"}`,
+			postRepoFind: true,
+			postRepoGet:  post.Post{ID: 3, Category: "music", Hidden: true},
+			statusCode:   http.StatusNotFound,
+			response:     "{\"message\":\"post not found\"}\n",
+		},
+	}
+
+	for _, testCase := range testCases {
+		postHandler := setupPost()
+		defer postHandler.Logger.Sync()
+		setupMentionUsers(postHandler)
+
+		r := httptest.NewRequest("POST", "/api/post/3/crosspost", strings.NewReader(testCase.body))
+		r = mux.SetURLVars(r, map[string]string{"post_id": "3"})
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, usr)
+		w := httptest.NewRecorder()
+
+		postHandler.PostRepo.(*mocks.PostRepo).On("Find", uint64(3)).Return(testCase.postRepoFind)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Get", uint64(3)).Return(testCase.postRepoGet, nil)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Add", mock.AnythingOfType("*post.Post")).Return(nil)
+
+		postHandler.Crosspost(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		if testCase.created == nil {
+			require.Equal(t, string(body), testCase.response)
+			postHandler.PostRepo.(*mocks.PostRepo).AssertNotCalled(t, "Add", mock.Anything)
+			continue
+		}
+		var pst post.Post
+		require.NoError(t, json.Unmarshal(body, &pst))
+		require.Equal(t, pst.Crosspost, testCase.created.Crosspost)
+		added := *postHandler.PostRepo.(*mocks.PostRepo).Calls[2].Arguments.Get(0).(*post.Post)
+		require.Equal(t, added.Author, usr)
+		added.Author, added.Time, added.Votes, added.MyVote = user.User{}, "", nil, 0
+		added.Score, added.UpvotePercentage = 0, 0
+		require.Equal(t, added, *testCase.created)
+	}
+}
+
+func TestPostAddCrosspostType(t *testing.T) {
+	postHandler := setupPost()
+	defer postHandler.Logger.Sync()
+
+	r := httptest.NewRequest("POST", "/api/posts", strings.NewReader(`{"type":"crosspost","title":"title","category":"news"}`))
+	ctx := context.WithValue(r.Context(), middleware.UserContextKey, user.User{Username: "test", UserID: 1})
+	w := httptest.NewRecorder()
+
+	postHandler.PostAdd(w, r.WithContext(ctx))
+
+	body, errRead := ioutil.ReadAll(w.Result().Body)
+	require.NoError(t, errRead)
+	require.Equal(t, w.Result().StatusCode, http.StatusUnprocessableEntity)
+	require.Equal(t, string(body), "{\"errors\":[{\"location\":\"body\",\"param\":\"type\",\"value\":\"crosspost\",\"msg\":\"crossposts are made from the original post\"}]}\n")
+}
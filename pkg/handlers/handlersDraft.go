@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"redditclone/pkg/draft"
+	"redditclone/pkg/errors"
+	"redditclone/pkg/frontendMessages"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/token"
+	"redditclone/pkg/user"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxDrafts = 100
+	// limits of drafted posts keep the drafts collection bounded, drafted
+	// comments are limited like published ones
+	maxDraftTitleLen = 300
+	maxDraftTextLen  = 40000
+)
+
+// lengthErrors reports the title and the text of a drafted post over their
+// limits.
+func lengthErrors(title, text string) []frontendMessages.ErrorMessage {
+	errs := []frontendMessages.ErrorMessage{}
+	if utf8.RuneCountInString(title) > maxDraftTitleLen {
+		errs = append(errs, frontendMessages.ErrorMessage{
+			Location: "body",
+			Param:    "title",
+			Message:  fmt.Sprintf("must be at most %d characters", maxDraftTitleLen),
+		})
+	}
+	if utf8.RuneCountInString(text) > maxDraftTextLen {
+		errs = append(errs, frontendMessages.ErrorMessage{
+			Location: "body",
+			Param:    "text",
+			Message:  fmt.Sprintf("must be at most %d characters", maxDraftTextLen),
+		})
+	}
+	return errs
+}
+
+// readDraft reads a draft of usr from the request body, on false the
+// response was already sent.
+func (h *PostHandler) readDraft(w http.ResponseWriter, r *http.Request, usr user.User, from string) (draft.Draft, bool, error) {
+	body, _ := ioutil.ReadAll(r.Body)
+	drf := draft.Draft{}
+	if errUnmarshal := json.Unmarshal(body, &drf); errUnmarshal != nil {
+		return drf, false, errors.ErrUnmarshalRequest{Err: errUnmarshal}
+	}
+	if !drf.Valid() {
+		frontendMessages.SendError(w, []frontendMessages.ErrorMessage{{
+			Location: "body",
+			Param:    "draft",
+			Message:  "must contain either a post or a comment",
+		}}, http.StatusUnprocessableEntity, h.Logger, from)
+		return drf, false, nil
+	}
+	var errs []frontendMessages.ErrorMessage
+	if drf.Post != nil {
+		errs = lengthErrors(drf.Post.Title, drf.Post.Text)
+	} else if len(drf.Comment.Body) >= maxCommentLen {
+		errs = []frontendMessages.ErrorMessage{{
+			Location: "body",
+			Param:    "comment",
+			Message:  fmt.Sprintf("must be at most %d characters long", maxCommentLen),
+		}}
+	}
+	if len(errs) != 0 {
+		frontendMessages.SendError(w, errs, http.StatusUnprocessableEntity, h.Logger, from)
+		return drf, false, nil
+	}
+	drf.UserID = usr.UserID
+	return drf, true, nil
+}
+
+func (h *PostHandler) sendDraft(w http.ResponseWriter, drf draft.Draft) error {
+	res, err := json.Marshal(drf)
+	if err != nil {
+		return errors.ErrMarshal{Err: err}
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+	return nil
+}
+
+func (h *PostHandler) DraftList(w http.ResponseWriter, r *http.Request) {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	drafts, err := h.Drafts.List(usr.UserID)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("DraftList: %w", err),
+		)
+		return
+	}
+	res, errMarshal := json.Marshal(drafts)
+	if errMarshal != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("DraftList: %w", errors.ErrMarshal{Err: errMarshal}),
+		)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+func (h *PostHandler) draftAdd(w http.ResponseWriter, r *http.Request) error {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+	drf, ok, err := h.readDraft(w, r, usr, "draftAdd")
+	if !ok {
+		return err
+	}
+	count, errCount := h.Drafts.Count(usr.UserID)
+	if errCount != nil {
+		return errCount
+	}
+	if count >= maxDrafts {
+		frontendMessages.SendMessage(w,
+			fmt.Sprintf("you can keep at most %d drafts", maxDrafts),
+			http.StatusConflict,
+			h.Logger, "draftAdd",
+		)
+		return nil
+	}
+	errAdd := h.Drafts.Add(&drf)
+	if errAdd != nil {
+		return errAdd
+	}
+	return h.sendDraft(w, drf)
+}
+
+func (h *PostHandler) DraftAdd(w http.ResponseWriter, r *http.Request) {
+	err := h.draftAdd(w, r)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("draftAdd: %w", err),
+		)
+	}
+}
+
+func (h *PostHandler) draftUpdate(w http.ResponseWriter, r *http.Request) error {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+	id, errGet := token.GetMapItemString(mux.Vars(r), "draft_id")
+	if errGet != nil {
+		return errors.ErrRequest{Err: errGet}
+	}
+	drf, ok, err := h.readDraft(w, r, usr, "draftUpdate")
+	if !ok {
+		return err
+	}
+	objectID, errID := primitive.ObjectIDFromHex(id)
+	found := errID == nil
+	if found {
+		drf.ID = objectID
+		found, err = h.Drafts.Update(&drf)
+		if err != nil {
+			return err
+		}
+	}
+	if !found {
+		frontendMessages.SendMessage(w,
+			"draft not found",
+			http.StatusNotFound,
+			h.Logger, "draftUpdate",
+		)
+		return nil
+	}
+	return h.sendDraft(w, drf)
+}
+
+func (h *PostHandler) DraftUpdate(w http.ResponseWriter, r *http.Request) {
+	err := h.draftUpdate(w, r)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("draftUpdate: %w", err),
+		)
+	}
+}
+
+func (h *PostHandler) draftRemove(w http.ResponseWriter, r *http.Request) error {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+	id, errGet := token.GetMapItemString(mux.Vars(r), "draft_id")
+	if errGet != nil {
+		return errors.ErrRequest{Err: errGet}
+	}
+	removed, err := h.Drafts.Remove(usr.UserID, id)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		frontendMessages.SendMessage(w,
+			"draft not found",
+			http.StatusNotFound,
+			h.Logger, "draftRemove",
+		)
+		return nil
+	}
+	frontendMessages.SendMessage(w,
+		"success",
+		http.StatusOK,
+		h.Logger, "draftRemove",
+	)
+	return nil
+}
+
+func (h *PostHandler) DraftRemove(w http.ResponseWriter, r *http.Request) {
+	err := h.draftRemove(w, r)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("draftRemove: %w", err),
+		)
+	}
+}
+
+// draftPublish turns the draft into a post or a comment with the same checks
+// as for ones sent directly, the draft is removed once it is published.
+func (h *PostHandler) draftPublish(w http.ResponseWriter, r *http.Request) error {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+	id, errGet := token.GetMapItemString(mux.Vars(r), "draft_id")
+	if errGet != nil {
+		return errors.ErrRequest{Err: errGet}
+	}
+	drf, found, err := h.Drafts.Get(usr.UserID, id)
+	if err != nil {
+		return err
+	}
+	if !found || !drf.Valid() {
+		frontendMessages.SendMessage(w,
+			"draft not found",
+			http.StatusNotFound,
+			h.Logger, "draftPublish",
+		)
+		return nil
+	}
+	var published bool
+	if drf.Post != nil {
+		published = h.addPost(w, usr, drf.Post.ToPost())
+	} else {
+		published = h.addComment(w, usr, drf.Comment.PostID, drf.Comment.Body, drf.Comment.Parent)
+	}
+	if !published {
+		return nil
+	}
+	if _, errRemove := h.Drafts.Remove(usr.UserID, id); errRemove != nil {
+		h.Logger.Errorf("draftPublish: can`t remove draft %s: %s", id, errRemove)
+	}
+	return nil
+}
+
+func (h *PostHandler) DraftPublish(w http.ResponseWriter, r *http.Request) {
+	err := h.draftPublish(w, r)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("draftPublish: %w", err),
+		)
+	}
+}
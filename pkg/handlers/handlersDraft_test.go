@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"redditclone/pkg/database/mocks"
+	"redditclone/pkg/draft"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/post"
+	"redditclone/pkg/user"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDrafts(t *testing.T) {
+	type testCase struct {
+		handler func(h *PostHandler) http.HandlerFunc
+		draftID string
+		body    string
+
+		draftRepoCount       int64
+		draftRepoAddError    error
+		draftRepoUpdate      bool
+		draftRepoRemove      bool
+		draftRepoRemoveError error
+
+		statusCode int
+		response   string
+	}
+
+	usr := user.User{Username: "test", UserID: 1}
+	objectID, _ := primitive.ObjectIDFromHex("0123456789abcdef01234567")
+	add := func(h *PostHandler) http.HandlerFunc { return h.DraftAdd }
+	update := func(h *PostHandler) http.HandlerFunc { return h.DraftUpdate }
+	remove := func(h *PostHandler) http.HandlerFunc { return h.DraftRemove }
+
+	testCases := []testCase{
+		{
+			handler:    add,
+			body:       `{"post":{"type":"text","title":"title","category":"music"}}`,
+			statusCode: http.StatusOK,
+			response:   `{"id":"0123456789abcdef01234567","post":{"type":"text","title":"title","category":"music"},"updated":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			handler:    add,
+			body:       `{"post":{"title":"title"},"comment":{"postId":"3","comment":"text"}}`,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"draft\",\"msg\":\"must contain either a post or a comment\"}]}\n",
+		},
+		{
+			handler:    add,
+			body:       `{"post":{"title":"` + strings.Repeat("t", maxDraftTitleLen+1) + `"}}`,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"title\",\"msg\":\"must be at most 300 characters\"}]}\n",
+		},
+		{
+			handler:    add,
+			body:       `{"comment":{"postId":"3","comment":"` + strings.Repeat("t", maxCommentLen) + `"}}`,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"comment\",\"msg\":\"must be at most 2000 characters long\"}]}\n",
+		},
+		{
+			handler:        add,
+			body:           `{"comment":{"postId":"3","comment":"text"}}`,
+			draftRepoCount: maxDrafts,
+			statusCode:     http.StatusConflict,
+			response:       "{\"message\":\"you can keep at most 100 drafts\"}\n",
+		},
+		{
+			handler:    update,
+			draftID:    objectID.Hex(),
+			body:       `{"post":{"title":"title","text":"` + strings.Repeat("t", maxDraftTextLen+1) + `"}}`,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"text\",\"msg\":\"must be at most 40000 characters\"}]}\n",
+		},
+		{
+			handler:    add,
+			body:       `{}`,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"draft\",\"msg\":\"must contain either a post or a comment\"}]}\n",
+		},
+		{
+			handler:           add,
+			body:              `{"comment":{"postId":"3","comment":"text"}}`,
+			draftRepoAddError: fmt.Errorf("test error"),
+			statusCode:        http.StatusInternalServerError,
+			response:          "",
+		},
+		{
+			handler:         update,
+			draftID:         objectID.Hex(),
+			body:            `{"comment":{"postId":"3","parent":"2","comment":"text"}}`,
+			draftRepoUpdate: true,
+			statusCode:      http.StatusOK,
+			response:        `{"id":"0123456789abcdef01234567","comment":{"postId":"3","parent":"2","comment":"text"},"updated":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			handler:         update,
+			draftID:         objectID.Hex(),
+			body:            `{"comment":{"postId":"3","comment":"text"}}`,
+			draftRepoUpdate: false,
+			statusCode:      http.StatusNotFound,
+			response:        "{\"message\":\"draft not found\"}\n",
+		},
+		{
+			handler:         remove,
+			draftID:         objectID.Hex(),
+			draftRepoRemove: true,
+			statusCode:      http.StatusOK,
+			response:        "{\"message\":\"success\"}\n",
+		},
+		{
+			handler:         remove,
+			draftID:         objectID.Hex(),
+			draftRepoRemove: false,
+			statusCode:      http.StatusNotFound,
+			response:        "{\"message\":\"draft not found\"}\n",
+		},
+		{
+			handler:              remove,
+			draftID:              objectID.Hex(),
+			draftRepoRemoveError: fmt.Errorf("test error"),
+			statusCode:           http.StatusInternalServerError,
+			response:             "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		postHandler := setupPost()
+		defer postHandler.Logger.Sync()
+
+		r := httptest.NewRequest("POST", "/api/drafts", strings.NewReader(testCase.body))
+		r = mux.SetURLVars(r, map[string]string{"draft_id": testCase.draftID})
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, usr)
+		w := httptest.NewRecorder()
+
+		postHandler.Drafts.(*mocks.DraftRepo).
+			On("Count", usr.UserID).
+			Return(testCase.draftRepoCount, nil)
+		postHandler.Drafts.(*mocks.DraftRepo).
+			On("Add", mock.AnythingOfType("*draft.Draft")).
+			Run(func(args mock.Arguments) {
+				args.Get(0).(*draft.Draft).ID = objectID
+			}).
+			Return(testCase.draftRepoAddError)
+		postHandler.Drafts.(*mocks.DraftRepo).
+			On("Update", mock.MatchedBy(func(drf *draft.Draft) bool {
+				return drf.ID == objectID && drf.UserID == usr.UserID
+			})).
+			Return(testCase.draftRepoUpdate, nil)
+		postHandler.Drafts.(*mocks.DraftRepo).
+			On("Remove", usr.UserID, objectID.Hex()).
+			Return(testCase.draftRepoRemove, testCase.draftRepoRemoveError)
+
+		testCase.handler(postHandler)(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		require.Equal(t, string(body), testCase.response)
+	}
+}
+
+func TestDraftPublish(t *testing.T) {
+	type testCase struct {
+		draft      draft.Draft
+		found      bool
+		statusCode int
+		removed    bool
+	}
+
+	usr := user.User{Username: "test", UserID: 1}
+	id := "0123456789abcdef01234567"
+
+	testCases := []testCase{
+		{
+			draft:      draft.Draft{Post: &draft.Post{Type: post.TypeText, Title: "title", Category: "music"}},
+			found:      true,
+			statusCode: http.StatusOK,
+			removed:    true,
+		},
+		{
+			draft:      draft.Draft{Post: &draft.Post{Type: post.TypePoll, Title: "title", Category: "music"}},
+			found:      true,
+			statusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			draft:      draft.Draft{Comment: &draft.Comment{PostID: 3, Body: "text"}},
+			found:      true,
+			statusCode: http.StatusOK,
+			removed:    true,
+		},
+		{
+			draft:      draft.Draft{Comment: &draft.Comment{PostID: 3}},
+			found:      true,
+			statusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			found:      false,
+			statusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		postHandler := setupPost()
+		defer postHandler.Logger.Sync()
+		setupMentionUsers(postHandler)
+
+		r := httptest.NewRequest("POST", "/api/drafts/"+id+"/publish", nil)
+		r = mux.SetURLVars(r, map[string]string{"draft_id": id})
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, usr)
+		w := httptest.NewRecorder()
+
+		postHandler.Drafts.(*mocks.DraftRepo).On("Get", usr.UserID, id).Return(testCase.draft, testCase.found, nil)
+		postHandler.Drafts.(*mocks.DraftRepo).On("Remove", usr.UserID, id).Return(true, nil)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Add", mock.AnythingOfType("*post.Post")).Return(nil)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Lock", uint64(3)).Return(true)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Get", uint64(3)).Return(post.Post{ID: 3}, nil)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Update", mock.AnythingOfType("post.Post")).Return(nil)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Unlock", uint64(3)).Return(true)
+		postHandler.Blocks.(*mocks.BlockRepo).On("IsBlocked", user.User{}, usr).Return(false, nil)
+
+		postHandler.DraftPublish(w, r.WithContext(ctx))
+
+		require.Equal(t, w.Result().StatusCode, testCase.statusCode)
+		if testCase.removed {
+			postHandler.Drafts.(*mocks.DraftRepo).AssertCalled(t, "Remove", usr.UserID, id)
+		} else {
+			postHandler.Drafts.(*mocks.DraftRepo).AssertNotCalled(t, "Remove", usr.UserID, id)
+		}
+	}
+}
@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/mail"
+	"redditclone/pkg/database"
+	"redditclone/pkg/errors"
+	"redditclone/pkg/frontendMessages"
+	"redditclone/pkg/mailer"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/session"
+	"redditclone/pkg/user"
+	"time"
+)
+
+const (
+	maxEmailLen = 255
+	verifyTTL   = 48 * time.Hour
+	resetTTL    = time.Hour
+)
+
+type emailJson struct {
+	Email    string `json:"email"`
+	Verified bool   `json:"verified"`
+}
+
+// emailProblem checks the email a user with userID wants to have, "" if it's fine.
+func (h *UserHandler) emailProblem(email string, userID int64) string {
+	addr, errParse := mail.ParseAddress(email)
+	if errParse != nil || addr.Address != email || addr.Name != "" || len(email) > maxEmailLen {
+		return "is invalid"
+	}
+	if owner, exists := h.UserRepo.FindByEmail(email); exists && owner.UserID != userID {
+		return "already exists"
+	}
+	return ""
+}
+
+func (h *UserHandler) sendVerification(usr user.User, email string) error {
+	verifyToken, err := h.Tokens.Issue(usr.UserID, database.TokenVerify, email, verifyTTL)
+	if err != nil {
+		return fmt.Errorf("can`t issue token: %w", err)
+	}
+	return h.Mailer.Send(mailer.Mail{
+		To:      email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"confirm this email for your account by opening the link:\n%s/verify?token=%s\n\n"+
+			"The link works for %d hours. If you didn't add this email, ignore this letter.\n",
+			usr.Username, h.SiteURL, verifyToken, int(verifyTTL.Hours())),
+	})
+}
+
+func (h *UserHandler) sendEmail(w http.ResponseWriter, usr user.User, from string) {
+	res, errMarshal := json.Marshal(emailJson{Email: usr.Email, Verified: usr.EmailVerified})
+	if errMarshal != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("%s: %w", from, errors.ErrMarshal{Err: errMarshal}),
+		)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+func (h *UserHandler) Email(w http.ResponseWriter, r *http.Request) {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	stored, found := h.UserRepo.Find(usr.Username)
+	if !found {
+		frontendMessages.SendMessage(w,
+			"user not found",
+			http.StatusNotFound,
+			h.Logger, "email",
+		)
+		return
+	}
+	h.sendEmail(w, stored, "email")
+}
+
+// EmailChange sets a new email and sends a verification letter to it, an
+// empty email removes the current one.
+func (h *UserHandler) EmailChange(w http.ResponseWriter, r *http.Request) {
+	changeJson := struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}{}
+	usr, ok := h.authorizeAccount(w, r, &changeJson, &changeJson.Password, "emailChange")
+	if !ok {
+		return
+	}
+	if changeJson.Email != "" {
+		if problem := h.emailProblem(changeJson.Email, usr.UserID); problem != "" {
+			frontendMessages.SendError(w, []frontendMessages.ErrorMessage{{
+				Location: "body",
+				Param:    "email",
+				Value:    changeJson.Email,
+				Message:  problem,
+			}}, http.StatusUnprocessableEntity, h.Logger, "emailChange")
+			return
+		}
+	}
+	errSet := h.UserRepo.SetEmail(usr, changeJson.Email)
+	if errSet != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("emailChange: can`t set email: %w", errSet),
+		)
+		return
+	}
+	// links sent to the old address must not work anymore
+	for _, kind := range []string{database.TokenReset, database.TokenVerify} {
+		if errRevoke := h.Tokens.Revoke(usr.UserID, kind); errRevoke != nil {
+			errors.SendHttpError(
+				h.Logger, w,
+				fmt.Errorf("emailChange: can`t revoke %s tokens: %w", kind, errRevoke),
+			)
+			return
+		}
+	}
+	usr.Email = changeJson.Email
+	usr.EmailVerified = false
+	if usr.Email != "" {
+		if errSend := h.sendVerification(usr, usr.Email); errSend != nil {
+			errors.SendHttpError(
+				h.Logger, w,
+				fmt.Errorf("emailChange: can`t send verification: %w", errSend),
+			)
+			return
+		}
+	}
+	h.Logger.Infof(`changed email of user: "%s", userID: "%d"`, usr.Username, usr.UserID)
+	h.sendEmail(w, usr, "emailChange")
+}
+
+// EmailVerifyResend sends one more verification letter to the current email.
+func (h *UserHandler) EmailVerifyResend(w http.ResponseWriter, r *http.Request) {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	stored, found := h.UserRepo.Find(usr.Username)
+	if !found {
+		frontendMessages.SendMessage(w,
+			"user not found",
+			http.StatusNotFound,
+			h.Logger, "emailVerifyResend",
+		)
+		return
+	}
+	var mesg string
+	if stored.Email == "" {
+		mesg = "email is not set"
+	} else if stored.EmailVerified {
+		mesg = "email is already verified"
+	}
+	if mesg != "" {
+		frontendMessages.SendMessage(w, mesg, http.StatusUnprocessableEntity, h.Logger, "emailVerifyResend")
+		return
+	}
+	if errSend := h.sendVerification(stored, stored.Email); errSend != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("emailVerifyResend: can`t send verification: %w", errSend),
+		)
+		return
+	}
+	frontendMessages.SendMessage(w, "success", http.StatusOK, h.Logger, "emailVerifyResend")
+}
+
+func (h *UserHandler) sendInvalidToken(w http.ResponseWriter, from string) {
+	frontendMessages.SendError(w, []frontendMessages.ErrorMessage{{
+		Location: "body",
+		Param:    "token",
+		Message:  "is invalid or expired",
+	}}, http.StatusUnprocessableEntity, h.Logger, from)
+}
+
+func (h *UserHandler) EmailVerify(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	verifyJson := struct {
+		Token string `json:"token"`
+	}{}
+	errUnmarshal := json.Unmarshal(body, &verifyJson)
+	if errUnmarshal != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("emailVerify: %w", errors.ErrUnmarshalRequest{Err: errUnmarshal}),
+		)
+		return
+	}
+	userID, email, errTake := h.Tokens.Take(verifyJson.Token, database.TokenVerify)
+	if errTake == database.ErrTokenInvalid {
+		h.sendInvalidToken(w, "emailVerify")
+		return
+	}
+	if errTake != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("emailVerify: can`t take token: %w", errTake),
+		)
+		return
+	}
+	verified, errVerify := h.UserRepo.VerifyEmail(userID, email)
+	if errVerify != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("emailVerify: can`t verify email: %w", errVerify),
+		)
+		return
+	}
+	// the email was changed after the letter had been sent
+	if !verified {
+		h.sendInvalidToken(w, "emailVerify")
+		return
+	}
+	if errRevoke := h.Tokens.Revoke(userID, database.TokenVerify); errRevoke != nil {
+		h.Logger.Errorf("emailVerify: can`t revoke tokens: %s", errRevoke)
+	}
+	h.Logger.Infof(`verified email of userID: "%d"`, userID)
+	frontendMessages.SendMessage(w, "success", http.StatusOK, h.Logger, "emailVerify")
+}
+
+// PasswordForgot sends a password reset letter to a verified email. The
+// answer is the same whether the email is known or not.
+func (h *UserHandler) PasswordForgot(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	forgotJson := struct {
+		Email string `json:"email"`
+	}{}
+	errUnmarshal := json.Unmarshal(body, &forgotJson)
+	if errUnmarshal != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("passwordForgot: %w", errors.ErrUnmarshalRequest{Err: errUnmarshal}),
+		)
+		return
+	}
+	if _, errParse := mail.ParseAddress(forgotJson.Email); errParse != nil {
+		frontendMessages.SendError(w, []frontendMessages.ErrorMessage{{
+			Location: "body",
+			Param:    "email",
+			Value:    forgotJson.Email,
+			Message:  "is invalid",
+		}}, http.StatusUnprocessableEntity, h.Logger, "passwordForgot")
+		return
+	}
+	usr, found := h.UserRepo.FindByEmail(forgotJson.Email)
+	if found && usr.EmailVerified {
+		errSend := h.sendReset(usr)
+		if errSend != nil {
+			h.Logger.Errorf("passwordForgot: can`t send reset letter to userID %d: %s", usr.UserID, errSend)
+		}
+	}
+	frontendMessages.SendMessage(w,
+		"if the email belongs to an account, a reset link has been sent to it",
+		http.StatusOK,
+		h.Logger, "passwordForgot",
+	)
+}
+
+func (h *UserHandler) sendReset(usr user.User) error {
+	resetToken, err := h.Tokens.Issue(usr.UserID, database.TokenReset, usr.Email, resetTTL)
+	if err != nil {
+		return fmt.Errorf("can`t issue token: %w", err)
+	}
+	return h.Mailer.Send(mailer.Mail{
+		To:      usr.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"set a new password for your account by opening the link:\n%s/reset?token=%s\n\n"+
+			"The link works for %d minutes. If you didn't ask for it, ignore this letter.\n",
+			usr.Username, h.SiteURL, resetToken, int(resetTTL.Minutes())),
+	})
+}
+
+// PasswordReset sets a new password by a reset token and closes all
+// sessions of the user.
+func (h *UserHandler) PasswordReset(w http.ResponseWriter, r *http.Request) {
+	auth, ok := r.Context().Value(middleware.AuthtorizationContextKey).(session.SessionManager)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.AuthtorizationContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	resetJson := struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}{}
+	errUnmarshal := json.Unmarshal(body, &resetJson)
+	if errUnmarshal != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("passwordReset: %w", errors.ErrUnmarshalRequest{Err: errUnmarshal}),
+		)
+		return
+	}
+	if problem := passwordProblem(resetJson.Password); problem != "" {
+		frontendMessages.SendError(w, []frontendMessages.ErrorMessage{{
+			Location: "body",
+			Param:    "password",
+			Message:  problem,
+		}}, http.StatusUnprocessableEntity, h.Logger, "passwordReset")
+		return
+	}
+	userID, email, errTake := h.Tokens.Take(resetJson.Token, database.TokenReset)
+	if errTake == database.ErrTokenInvalid {
+		h.sendInvalidToken(w, "passwordReset")
+		return
+	}
+	if errTake != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("passwordReset: can`t take token: %w", errTake),
+		)
+		return
+	}
+	// the email was changed after the letter had been sent
+	if owner, found := h.UserRepo.FindByEmail(email); !found || owner.UserID != userID || !owner.EmailVerified {
+		h.sendInvalidToken(w, "passwordReset")
+		return
+	}
+	usr := user.User{UserID: userID}
+	errUpdate := h.UserRepo.UpdatePassword(usr, user.GetPasswordHash(resetJson.Password))
+	if errUpdate != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("passwordReset: can`t update password: %w", errUpdate),
+		)
+		return
+	}
+	if errRevoke := h.Tokens.Revoke(userID, database.TokenReset); errRevoke != nil {
+		h.Logger.Errorf("passwordReset: can`t revoke tokens: %s", errRevoke)
+	}
+	errSessions := auth.RevokeAll(userID)
+	if errSessions != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("passwordReset: can`t revoke sessions: %w", errSessions),
+		)
+		return
+	}
+	h.Logger.Infof(`reset password of userID: "%d"`, userID)
+	frontendMessages.SendMessage(w, "success", http.StatusOK, h.Logger, "passwordReset")
+}
@@ -0,0 +1,407 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"redditclone/pkg/database"
+	"redditclone/pkg/database/mocks"
+	"redditclone/pkg/mailer"
+	"redditclone/pkg/middleware"
+	sessionMocks "redditclone/pkg/session/mocks"
+	"redditclone/pkg/user"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailChange(t *testing.T) {
+	type testCase struct {
+		request string
+		owner   user.User
+		taken   bool
+
+		statusCode int
+		response   string
+		sent       []mailer.Mail
+	}
+
+	usr := user.User{Username: "test", UserID: 1}
+	stored := user.User{Username: "test", UserID: 1, PasswordHash: user.GetPasswordHash("password1"), Email: "old@example.com", EmailVerified: true}
+	verifyMail := mailer.Mail{
+		To:      "new@example.com",
+		Subject: "Verify your email",
+		Body: "Hi test,\n\n" +
+			"confirm this email for your account by opening the link:\nhttp://localhost:8080/verify?token=token\n\n" +
+			"The link works for 48 hours. If you didn't add this email, ignore this letter.\n",
+	}
+	testCases := []testCase{
+		{
+			request:    `{"email":"new@example.com","password":"password1"}`,
+			statusCode: http.StatusOK,
+			response:   `{"email":"new@example.com","verified":false}`,
+			sent:       []mailer.Mail{verifyMail},
+		},
+		{
+			request:    `{"email":"new@example.com","password":"password1"}`,
+			owner:      usr,
+			taken:      true,
+			statusCode: http.StatusOK,
+			response:   `{"email":"new@example.com","verified":false}`,
+			sent:       []mailer.Mail{verifyMail},
+		},
+		{
+			request:    `{"email":"","password":"password1"}`,
+			statusCode: http.StatusOK,
+			response:   `{"email":"","verified":false}`,
+		},
+		{
+			request:    `{"email":"new@example.com","password":"password1"}`,
+			owner:      user.User{Username: "other", UserID: 2},
+			taken:      true,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"email\",\"value\":\"new@example.com\",\"msg\":\"already exists\"}]}\n",
+		},
+		{
+			request:    `{"email":"new","password":"password1"}`,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"email\",\"value\":\"new\",\"msg\":\"is invalid\"}]}\n",
+		},
+		{
+			request:    `{"email":"new@example.com","password":"password2"}`,
+			statusCode: http.StatusForbidden,
+			response:   "{\"message\":\"invalid password\"}\n",
+		},
+	}
+
+	for caseNum, testCase := range testCases {
+		userHandler := setupUser()
+		defer userHandler.Logger.Sync()
+
+		r := httptest.NewRequest("PUT", "/api/user/me/email", strings.NewReader(testCase.request))
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, usr)
+		w := httptest.NewRecorder()
+
+		userHandler.UserRepo.(*mocks.UserRepo).
+			On("Find", usr.Username).
+			Return(stored, true)
+		userHandler.UserRepo.(*mocks.UserRepo).
+			On("FindByEmail", "new@example.com").
+			Return(testCase.owner, testCase.taken)
+		userHandler.UserRepo.(*mocks.UserRepo).
+			On("SetEmail", stored, mock.AnythingOfType("string")).
+			Return(nil)
+		userHandler.Tokens.(*mocks.TokenRepo).
+			On("Revoke", usr.UserID, mock.AnythingOfType("string")).
+			Return(nil)
+		userHandler.Tokens.(*mocks.TokenRepo).
+			On("Issue", usr.UserID, database.TokenVerify, "new@example.com", verifyTTL).
+			Return("token", nil)
+
+		userHandler.EmailChange(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode, "case %d", caseNum)
+		require.Equal(t, string(body), testCase.response, "case %d", caseNum)
+		require.Equal(t, userHandler.Mailer.(*mailer.MemoryMailer).Sent(), append([]mailer.Mail{}, testCase.sent...), "case %d", caseNum)
+		if testCase.statusCode != http.StatusOK {
+			userHandler.UserRepo.(*mocks.UserRepo).AssertNotCalled(t, "SetEmail", mock.Anything, mock.Anything)
+			continue
+		}
+		userHandler.Tokens.(*mocks.TokenRepo).AssertCalled(t, "Revoke", usr.UserID, database.TokenReset)
+		userHandler.Tokens.(*mocks.TokenRepo).AssertCalled(t, "Revoke", usr.UserID, database.TokenVerify)
+	}
+}
+
+func TestEmailVerifyResend(t *testing.T) {
+	type testCase struct {
+		stored     user.User
+		statusCode int
+		response   string
+		sent       int
+	}
+
+	usr := user.User{Username: "test", UserID: 1}
+	testCases := []testCase{
+		{
+			stored:     user.User{Username: "test", UserID: 1, Email: "test@example.com"},
+			statusCode: http.StatusOK,
+			response:   "{\"message\":\"success\"}\n",
+			sent:       1,
+		},
+		{
+			stored:     user.User{Username: "test", UserID: 1, Email: "test@example.com", EmailVerified: true},
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"message\":\"email is already verified\"}\n",
+		},
+		{
+			stored:     user.User{Username: "test", UserID: 1},
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"message\":\"email is not set\"}\n",
+		},
+	}
+
+	for caseNum, testCase := range testCases {
+		userHandler := setupUser()
+		defer userHandler.Logger.Sync()
+
+		r := httptest.NewRequest("POST", "/api/user/me/email/verify", nil)
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, usr)
+		w := httptest.NewRecorder()
+
+		userHandler.UserRepo.(*mocks.UserRepo).
+			On("Find", usr.Username).
+			Return(testCase.stored, true)
+		userHandler.Tokens.(*mocks.TokenRepo).
+			On("Issue", usr.UserID, database.TokenVerify, "test@example.com", verifyTTL).
+			Return("token", nil)
+
+		userHandler.EmailVerifyResend(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode, "case %d", caseNum)
+		require.Equal(t, string(body), testCase.response, "case %d", caseNum)
+		require.Equal(t, len(userHandler.Mailer.(*mailer.MemoryMailer).Sent()), testCase.sent, "case %d", caseNum)
+	}
+}
+
+func TestEmailVerify(t *testing.T) {
+	type testCase struct {
+		request   string
+		takeError error
+		verified  bool
+
+		statusCode int
+		response   string
+	}
+
+	testCases := []testCase{
+		{
+			request:    `{"token":"token"}`,
+			verified:   true,
+			statusCode: http.StatusOK,
+			response:   "{\"message\":\"success\"}\n",
+		},
+		{
+			request:    `{"token":"token"}`,
+			verified:   false,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"token\",\"msg\":\"is invalid or expired\"}]}\n",
+		},
+		{
+			request:    `{"token":"token"}`,
+			takeError:  database.ErrTokenInvalid,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"token\",\"msg\":\"is invalid or expired\"}]}\n",
+		},
+		{
+			request:    `{"token":"token"}`,
+			takeError:  fmt.Errorf("test error"),
+			statusCode: http.StatusInternalServerError,
+			response:   "",
+		},
+	}
+
+	for caseNum, testCase := range testCases {
+		userHandler := setupUser()
+		defer userHandler.Logger.Sync()
+
+		r := httptest.NewRequest("POST", "/api/verify", strings.NewReader(testCase.request))
+		w := httptest.NewRecorder()
+
+		userHandler.Tokens.(*mocks.TokenRepo).
+			On("Take", "token", database.TokenVerify).
+			Return(int64(1), "test@example.com", testCase.takeError)
+		userHandler.UserRepo.(*mocks.UserRepo).
+			On("VerifyEmail", int64(1), "test@example.com").
+			Return(testCase.verified, nil)
+		userHandler.Tokens.(*mocks.TokenRepo).
+			On("Revoke", int64(1), database.TokenVerify).
+			Return(nil)
+
+		userHandler.EmailVerify(w, r)
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode, "case %d", caseNum)
+		require.Equal(t, string(body), testCase.response, "case %d", caseNum)
+	}
+}
+
+func TestPasswordForgot(t *testing.T) {
+	type testCase struct {
+		request string
+		found   user.User
+		ok      bool
+
+		statusCode int
+		response   string
+		sent       []mailer.Mail
+	}
+
+	verified := user.User{Username: "test", UserID: 1, Email: "test@example.com", EmailVerified: true}
+	sentResponse := "{\"message\":\"if the email belongs to an account, a reset link has been sent to it\"}\n"
+	testCases := []testCase{
+		{
+			request:    `{"email":"test@example.com"}`,
+			found:      verified,
+			ok:         true,
+			statusCode: http.StatusOK,
+			response:   sentResponse,
+			sent: []mailer.Mail{{
+				To:      "test@example.com",
+				Subject: "Reset your password",
+				Body: "Hi test,\n\n" +
+					"set a new password for your account by opening the link:\nhttp://localhost:8080/reset?token=token\n\n" +
+					"The link works for 60 minutes. If you didn't ask for it, ignore this letter.\n",
+			}},
+		},
+		{
+			request:    `{"email":"test@example.com"}`,
+			found:      user.User{Username: "test", UserID: 1, Email: "test@example.com"},
+			ok:         true,
+			statusCode: http.StatusOK,
+			response:   sentResponse,
+		},
+		{
+			request:    `{"email":"test@example.com"}`,
+			statusCode: http.StatusOK,
+			response:   sentResponse,
+		},
+		{
+			request:    `{"email":"test"}`,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"email\",\"value\":\"test\",\"msg\":\"is invalid\"}]}\n",
+		},
+	}
+
+	for caseNum, testCase := range testCases {
+		userHandler := setupUser()
+		defer userHandler.Logger.Sync()
+
+		r := httptest.NewRequest("POST", "/api/password/forgot", strings.NewReader(testCase.request))
+		w := httptest.NewRecorder()
+
+		userHandler.UserRepo.(*mocks.UserRepo).
+			On("FindByEmail", "test@example.com").
+			Return(testCase.found, testCase.ok)
+		userHandler.Tokens.(*mocks.TokenRepo).
+			On("Issue", int64(1), database.TokenReset, "test@example.com", resetTTL).
+			Return("token", nil)
+
+		userHandler.PasswordForgot(w, r)
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode, "case %d", caseNum)
+		require.Equal(t, string(body), testCase.response, "case %d", caseNum)
+		require.Equal(t, userHandler.Mailer.(*mailer.MemoryMailer).Sent(), append([]mailer.Mail{}, testCase.sent...), "case %d", caseNum)
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	type testCase struct {
+		request   string
+		takeError error
+		owner     user.User
+		found     bool
+
+		statusCode int
+		response   string
+		updated    bool
+	}
+
+	owner := user.User{Username: "test", UserID: 1, Email: "test@example.com", EmailVerified: true}
+	testCases := []testCase{
+		{
+			request:    `{"token":"token","password":"password2"}`,
+			owner:      owner,
+			found:      true,
+			statusCode: http.StatusOK,
+			response:   "{\"message\":\"success\"}\n",
+			updated:    true,
+		},
+		{
+			request:    `{"token":"token","password":"password2"}`,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"token\",\"msg\":\"is invalid or expired\"}]}\n",
+		},
+		{
+			request:    `{"token":"token","password":"password2"}`,
+			owner:      user.User{Username: "other", UserID: 2, Email: "test@example.com", EmailVerified: true},
+			found:      true,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"token\",\"msg\":\"is invalid or expired\"}]}\n",
+		},
+		{
+			request:    `{"token":"token","password":"short"}`,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"password\",\"msg\":\"must be from 8 to 32 characters long\"}]}\n",
+		},
+		{
+			request:    `{"token":"token","password":"password2"}`,
+			takeError:  database.ErrTokenInvalid,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"token\",\"msg\":\"is invalid or expired\"}]}\n",
+		},
+		{
+			request:    `wrong json`,
+			statusCode: http.StatusInternalServerError,
+			response:   "",
+		},
+	}
+
+	for caseNum, testCase := range testCases {
+		userHandler := setupUser()
+		defer userHandler.Logger.Sync()
+		authorization := &sessionMocks.SessionManager{}
+
+		r := httptest.NewRequest("POST", "/api/password/reset", strings.NewReader(testCase.request))
+		ctx := context.WithValue(r.Context(), middleware.AuthtorizationContextKey, authorization)
+		w := httptest.NewRecorder()
+
+		userHandler.Tokens.(*mocks.TokenRepo).
+			On("Take", "token", database.TokenReset).
+			Return(int64(1), "test@example.com", testCase.takeError)
+		userHandler.UserRepo.(*mocks.UserRepo).
+			On("FindByEmail", "test@example.com").
+			Return(testCase.owner, testCase.found)
+		userHandler.UserRepo.(*mocks.UserRepo).
+			On("UpdatePassword", user.User{UserID: 1}, user.GetPasswordHash("password2")).
+			Return(nil)
+		userHandler.Tokens.(*mocks.TokenRepo).
+			On("Revoke", int64(1), database.TokenReset).
+			Return(nil)
+		authorization.
+			On("RevokeAll", int64(1)).
+			Return(nil)
+
+		userHandler.PasswordReset(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode, "case %d", caseNum)
+		require.Equal(t, string(body), testCase.response, "case %d", caseNum)
+		if testCase.updated {
+			authorization.AssertCalled(t, "RevokeAll", int64(1))
+		} else {
+			userHandler.UserRepo.(*mocks.UserRepo).AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything)
+		}
+	}
+}
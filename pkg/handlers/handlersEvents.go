@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"redditclone/pkg/comment"
+	"redditclone/pkg/errors"
+	"redditclone/pkg/events"
+	"redditclone/pkg/frontendMessages"
+	"redditclone/pkg/token"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const eventsKeepAlive = 15 * time.Second
+
+// serveEvents streams events of sub until the client leaves, events for
+// which skip reports true are not sent.
+func (h *PostHandler) serveEvents(w http.ResponseWriter, r *http.Request, sub *events.Subscription, skip func(events.Event) bool, from string) {
+	defer h.Events.Unsubscribe(sub)
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.Logger.Errorf("%s: streaming is not supported", from)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	ticker := time.NewTicker(eventsKeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case evt := <-sub.Events:
+			if skip != nil && skip(evt) {
+				continue
+			}
+			data, errMarshal := json.Marshal(evt)
+			if errMarshal != nil {
+				h.Logger.Errorf("%s: can`t marshal event: %s", from, errMarshal)
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", evt.Type, data)
+		}
+		flusher.Flush()
+	}
+}
+
+func (h *PostHandler) PostEvents(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	postID, errGet := token.GetMapItemUint64(vars, "post_id")
+	if errGet != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("PostEvents: %w", errors.ErrRequest{Err: errGet}),
+		)
+		return
+	}
+	pst, errPost := h.PostRepo.Get(postID)
+	if errPost != nil || pst.DeletedAt != nil {
+		frontendMessages.SendMessage(w,
+			"post not found",
+			http.StatusNotFound,
+			h.Logger, "PostEvents",
+		)
+		return
+	}
+	blocked, visible := h.visiblePost(w, &pst, viewerFromContext(r), "PostEvents")
+	if !visible {
+		return
+	}
+	isBlocked := map[int64]bool{}
+	for _, userID := range blocked {
+		isBlocked[userID] = true
+	}
+	// comments of blocked users are dropped like in PostGet
+	skip := func(evt events.Event) bool {
+		cmt, ok := evt.Data.(comment.Comment)
+		return ok && isBlocked[cmt.Author.UserID]
+	}
+	h.serveEvents(w, r, h.Events.SubscribePost(postID), skip, "PostEvents")
+}
+
+func (h *PostHandler) CategoryEvents(w http.ResponseWriter, r *http.Request) {
+	category := mux.Vars(r)["category_name"]
+	h.serveEvents(w, r, h.Events.SubscribeCategory(category), nil, "CategoryEvents")
+}
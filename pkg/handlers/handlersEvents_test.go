@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"redditclone/pkg/comment"
+	"redditclone/pkg/database/mocks"
+	"redditclone/pkg/events"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/post"
+	"redditclone/pkg/user"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func readEvent(t *testing.T, reader *bufio.Reader) (res string) {
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		if line == "\n" {
+			return
+		}
+		res += line
+	}
+}
+
+func TestPostEvents(t *testing.T) {
+	postHandler := setupPost()
+	defer postHandler.Logger.Sync()
+
+	viewer := user.User{Username: "viewer", UserID: 5}
+	author := user.User{Username: "author", UserID: 6}
+	blocked := user.User{Username: "blocked", UserID: 7}
+	publishAt := time.Now().Add(time.Hour)
+	postHandler.PostRepo.(*mocks.PostRepo).On("Get", uint64(1)).Return(post.Post{ID: 1, Author: author}, nil)
+	postHandler.PostRepo.(*mocks.PostRepo).On("Get", uint64(2)).Return(post.Post{}, fmt.Errorf("not found"))
+	postHandler.PostRepo.(*mocks.PostRepo).On("Get", uint64(3)).Return(post.Post{ID: 3, Author: author, PublishAt: &publishAt}, nil)
+	postHandler.PostRepo.(*mocks.PostRepo).On("Get", uint64(4)).Return(post.Post{ID: 4, Author: author, Hidden: true}, nil)
+	postHandler.PostRepo.(*mocks.PostRepo).On("Get", uint64(5)).Return(post.Post{ID: 5, Author: blocked}, nil)
+	postHandler.UserRepo.(*mocks.UserRepo).On("Role", viewer.UserID).Return(user.RoleUser, nil)
+	postHandler.Blocks.(*mocks.BlockRepo).On("Blocks", viewer).Return([]user.User{blocked}, nil)
+
+	r := mux.NewRouter()
+	r.HandleFunc("/api/post/{post_id:[0-9]+}/events", func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, viewer)
+		postHandler.PostEvents(w, r.WithContext(ctx))
+	})
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	for _, id := range []string{"2", "3", "4", "5"} {
+		resp, err := http.Get(server.URL + "/api/post/" + id + "/events")
+		require.NoError(t, err)
+		body, errRead := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, http.StatusNotFound, id)
+		require.Equal(t, string(body), "{\"message\":\"post not found\"}\n", id)
+	}
+
+	resp, err := http.Get(server.URL + "/api/post/1/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, resp.StatusCode, http.StatusOK)
+	require.Equal(t, resp.Header.Get("Content-Type"), "text/event-stream")
+
+	reader := bufio.NewReader(resp.Body)
+	require.Equal(t, readEvent(t, reader), ": connected\n")
+
+	postHandler.Events.Publish(events.Event{Type: events.PostRemoved, PostID: 3, Category: "music"})
+	postHandler.Events.Publish(events.Event{
+		Type:     events.CommentAdded,
+		PostID:   1,
+		Category: "music",
+		Data:     comment.Comment{ID: 3, Author: blocked, Body: "hidden"},
+	})
+	postHandler.Events.Publish(events.Event{
+		Type:     events.CommentRemoved,
+		PostID:   1,
+		Category: "music",
+		Data:     events.CommentRef{CommentID: 4},
+	})
+	require.Equal(t, readEvent(t, reader),
+		"event: comment_removed\ndata: {\"type\":\"comment_removed\",\"postId\":\"1\",\"category\":\"music\",\"data\":{\"commentId\":\"4\"}}\n",
+	)
+}
+
+func TestCategoryEvents(t *testing.T) {
+	postHandler := setupPost()
+	defer postHandler.Logger.Sync()
+
+	r := mux.NewRouter()
+	r.HandleFunc("/api/events", postHandler.CategoryEvents)
+	r.HandleFunc("/api/posts/{category_name}/events", postHandler.CategoryEvents)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	category, err := http.Get(server.URL + "/api/posts/music/events")
+	require.NoError(t, err)
+	defer category.Body.Close()
+	categoryReader := bufio.NewReader(category.Body)
+	require.Equal(t, readEvent(t, categoryReader), ": connected\n")
+
+	all, err := http.Get(server.URL + "/api/events")
+	require.NoError(t, err)
+	defer all.Body.Close()
+	allReader := bufio.NewReader(all.Body)
+	require.Equal(t, readEvent(t, allReader), ": connected\n")
+
+	postHandler.Events.Publish(events.Event{Type: events.PostRemoved, PostID: 1, Category: "funny"})
+	postHandler.Events.Publish(events.Event{Type: events.PostRemoved, PostID: 2, Category: "music"})
+
+	require.Equal(t, readEvent(t, allReader),
+		"event: post_removed\ndata: {\"type\":\"post_removed\",\"postId\":\"1\",\"category\":\"funny\"}\n",
+	)
+	require.Equal(t, readEvent(t, allReader),
+		"event: post_removed\ndata: {\"type\":\"post_removed\",\"postId\":\"2\",\"category\":\"music\"}\n",
+	)
+	require.Equal(t, readEvent(t, categoryReader),
+		"event: post_removed\ndata: {\"type\":\"post_removed\",\"postId\":\"2\",\"category\":\"music\"}\n",
+	)
+}
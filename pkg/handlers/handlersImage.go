@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"redditclone/pkg/errors"
+	"redditclone/pkg/flair"
+	"redditclone/pkg/frontendMessages"
+	"redditclone/pkg/media"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/post"
+	"redditclone/pkg/user"
+	"strconv"
+)
+
+const (
+	// formOverhead is room for multipart headers and text fields
+	formOverhead  = 64 << 10
+	thumbnailSide = 320
+)
+
+// ImageAdd creates an image post from a multipart form with "title",
+// "category" and "image" fields and optional "flair", "nsfw" and "spoiler".
+func (h *PostHandler) ImageAdd(w http.ResponseWriter, r *http.Request) {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	tooLarge := func() {
+		frontendMessages.SendMessage(w,
+			fmt.Sprintf("image is larger than %d bytes", h.MaxImageSize),
+			http.StatusRequestEntityTooLarge,
+			h.Logger, "imageAdd",
+		)
+	}
+	if r.ContentLength > h.MaxImageSize+formOverhead {
+		tooLarge()
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, h.MaxImageSize+formOverhead)
+	if errParse := r.ParseMultipartForm(formOverhead); errParse != nil {
+		frontendMessages.SendMessage(w,
+			"bad multipart form",
+			http.StatusBadRequest,
+			h.Logger, "imageAdd",
+		)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+	pst := post.Post{
+		Type:     post.TypeImage,
+		Title:    r.FormValue("title"),
+		Category: r.FormValue("category"),
+		Flair:    &flair.Flair{Text: r.FormValue("flair")},
+	}
+	pst.NSFW, _ = strconv.ParseBool(r.FormValue("nsfw"))
+	pst.Spoiler, _ = strconv.ParseBool(r.FormValue("spoiler"))
+	ok, errPrepare := h.preparePost(w, &pst, "imageAdd")
+	if errPrepare != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("imageAdd: %w", errPrepare),
+		)
+		return
+	}
+	if !ok {
+		return
+	}
+	file, _, errFile := r.FormFile("image")
+	if errFile != nil {
+		frontendMessages.SendError(w, []frontendMessages.ErrorMessage{{
+			Location: "body",
+			Param:    "image",
+			Message:  "is required",
+		}}, http.StatusUnprocessableEntity, h.Logger, "imageAdd")
+		return
+	}
+	defer file.Close()
+	data, errRead := ioutil.ReadAll(io.LimitReader(file, h.MaxImageSize+1))
+	if errRead != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("imageAdd: %w", errRead),
+		)
+		return
+	}
+	if int64(len(data)) > h.MaxImageSize {
+		tooLarge()
+		return
+	}
+	upload, errProcess := media.Process(data, thumbnailSide)
+	if errProcess != nil {
+		frontendMessages.SendMessage(w,
+			"only jpeg, png and gif images are supported",
+			http.StatusUnsupportedMediaType,
+			h.Logger, "imageAdd",
+		)
+		return
+	}
+	// names are content hashes and may be shared by several posts, so
+	// the files are kept even if the post isn't created
+	if errPut := h.Images.Put(upload.Name, upload.Data); errPut != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("imageAdd: %w", errPut),
+		)
+		return
+	}
+	if errPut := h.Images.Put(upload.ThumbnailName, upload.Thumbnail); errPut != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("imageAdd: %w", errPut),
+		)
+		return
+	}
+	img := upload.Image
+	img.URL = h.Images.URL(upload.Name)
+	img.ThumbnailURL = h.Images.URL(upload.ThumbnailName)
+	pst.Image = &img
+	h.createPost(w, usr, pst)
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	blobMocks "redditclone/pkg/blob/mocks"
+	"redditclone/pkg/database/mocks"
+	"redditclone/pkg/flair"
+	"redditclone/pkg/media"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/post"
+	"redditclone/pkg/user"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func multipartBody(t *testing.T, fields map[string]string, image []byte) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for key, value := range fields {
+		require.NoError(t, writer.WriteField(key, value))
+	}
+	if image != nil {
+		part, err := writer.CreateFormFile("image", "image.png")
+		require.NoError(t, err)
+		_, err = part.Write(image)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	return body, writer.FormDataContentType()
+}
+
+func TestImageAdd(t *testing.T) {
+	type testCase struct {
+		contextKey  middleware.Key
+		fields      map[string]string
+		image       []byte
+		contentType string
+		putError    error
+
+		statusCode int
+		response   string
+	}
+
+	var pngBuf bytes.Buffer
+	require.NoError(t, png.Encode(&pngBuf, image.NewGray(image.Rect(0, 0, 40, 20))))
+	pngData := pngBuf.Bytes()
+	upload, err := media.Process(pngData, thumbnailSide)
+	require.NoError(t, err)
+
+	usr := user.User{Username: "test", UserID: 1}
+	news := flair.Flair{Text: "News", Color: "#0000ff"}
+	testCases := []testCase{
+		{
+			contextKey: middleware.UserContextKey,
+			image:      pngData,
+			statusCode: http.StatusOK,
+		},
+		{
+			contextKey: middleware.UserContextKey,
+			fields:     map[string]string{"flair": "news", "nsfw": "true", "spoiler": "1"},
+			image:      pngData,
+			statusCode: http.StatusOK,
+		},
+		{
+			contextKey: middleware.UserContextKey,
+			fields:     map[string]string{"flair": "memes"},
+			image:      pngData,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"flair\",\"value\":\"memes\",\"msg\":\"is not defined in this category\"}]}\n",
+		},
+		{
+			contextKey: middleware.AuthtorizationContextKey,
+			image:      pngData,
+			statusCode: http.StatusInternalServerError,
+			response:   "Internal server error\n",
+		},
+		{
+			contextKey: middleware.UserContextKey,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"image\",\"msg\":\"is required\"}]}\n",
+		},
+		{
+			contextKey: middleware.UserContextKey,
+			image:      []byte("<svg onload=\"alert(1)\"></svg>"),
+			statusCode: http.StatusUnsupportedMediaType,
+			response:   "{\"message\":\"only jpeg, png and gif images are supported\"}\n",
+		},
+		{
+			contextKey: middleware.UserContextKey,
+			image:      append(pngData, make([]byte, 1000)...),
+			statusCode: http.StatusRequestEntityTooLarge,
+			response:   "{\"message\":\"image is larger than 1000 bytes\"}\n",
+		},
+		{
+			contextKey:  middleware.UserContextKey,
+			image:       pngData,
+			contentType: "multipart/form-data",
+			statusCode:  http.StatusBadRequest,
+			response:    "{\"message\":\"bad multipart form\"}\n",
+		},
+		{
+			contextKey: middleware.UserContextKey,
+			image:      pngData,
+			putError:   fmt.Errorf("test error"),
+			statusCode: http.StatusInternalServerError,
+			response:   "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		postHandler := setupPost()
+		postHandler.Images = &blobMocks.Store{}
+		postHandler.MaxImageSize = 1000
+		defer postHandler.Logger.Sync()
+
+		fields := map[string]string{"title": "title", "category": "pics"}
+		for key, value := range testCase.fields {
+			fields[key] = value
+		}
+		body, contentType := multipartBody(t, fields, testCase.image)
+		if testCase.contentType != "" {
+			contentType = testCase.contentType
+		}
+		r := httptest.NewRequest("POST", "/api/posts/image", body)
+		r.Header.Set("Content-Type", contentType)
+		ctx := context.WithValue(r.Context(), testCase.contextKey, usr)
+		w := httptest.NewRecorder()
+
+		postHandler.Images.(*blobMocks.Store).On("Put", mock.AnythingOfType("string"), mock.Anything).Return(testCase.putError)
+		postHandler.Images.(*blobMocks.Store).On("URL", mock.AnythingOfType("string")).Return(
+			func(name string) string { return "/uploads/" + name },
+		)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Add", mock.AnythingOfType("*post.Post")).Return(nil)
+		postHandler.CategoryRepo.(*mocks.CategoryRepo).On("Flairs", "pics").Return([]flair.Flair{news}, nil)
+
+		postHandler.ImageAdd(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		respBody, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		if testCase.statusCode != http.StatusOK {
+			require.Equal(t, string(respBody), testCase.response)
+			continue
+		}
+		var pst post.Post
+		require.NoError(t, json.Unmarshal(respBody, &pst))
+		require.Equal(t, pst.Type, post.TypeImage)
+		require.Equal(t, pst.Title, "title")
+		require.Equal(t, pst.Category, "pics")
+		if testCase.fields["flair"] != "" {
+			require.Equal(t, pst.Flair, &news)
+		} else {
+			require.Nil(t, pst.Flair)
+		}
+		require.Equal(t, pst.NSFW, testCase.fields["nsfw"] != "")
+		require.Equal(t, pst.Spoiler, testCase.fields["spoiler"] != "")
+		require.Equal(t, pst.Image, &media.Image{
+			URL:          "/uploads/" + upload.Name,
+			ThumbnailURL: "/uploads/" + upload.ThumbnailName,
+			ContentType:  "image/png",
+			Width:        40,
+			Height:       20,
+			Size:         int64(len(pngData)),
+		})
+		postHandler.Images.(*blobMocks.Store).AssertCalled(t, "Put", upload.Name, pngData)
+		postHandler.Images.(*blobMocks.Store).AssertCalled(t, "Put", upload.ThumbnailName, upload.Thumbnail)
+	}
+}
+
+func TestPostAddImageType(t *testing.T) {
+	postHandler := setupPost()
+	defer postHandler.Logger.Sync()
+
+	r := httptest.NewRequest("POST", "/api/posts", strings.NewReader(`{"type":"image","image":{"url":"/uploads/a.png"}}`))
+	ctx := context.WithValue(r.Context(), middleware.UserContextKey, user.User{Username: "test", UserID: 1})
+	w := httptest.NewRecorder()
+
+	postHandler.PostAdd(w, r.WithContext(ctx))
+
+	resp := w.Result()
+	body, errRead := ioutil.ReadAll(resp.Body)
+	require.NoError(t, errRead)
+	require.Equal(t, resp.StatusCode, http.StatusUnprocessableEntity)
+	require.Equal(t, string(body), "{\"errors\":[{\"location\":\"body\",\"param\":\"type\",\"value\":\"image\",\"msg\":\"images are uploaded with multipart form\"}]}\n")
+}
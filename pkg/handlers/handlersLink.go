@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"redditclone/pkg/errors"
+	"redditclone/pkg/frontendMessages"
+	"redditclone/pkg/post"
+	"redditclone/pkg/token"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// prepareLink canonicalizes the link of pst and looks for the same link posted
+// to the category within DuplicateWindow. It returns false if the response was
+// already sent.
+func (h *PostHandler) prepareLink(w http.ResponseWriter, pst *post.Post, from string) (bool, error) {
+	pst.Domain = ""
+	pst.Preview = nil
+	pst.DuplicateOf = 0
+	if pst.Type != post.TypeLink {
+		return true, nil
+	}
+	canonical, domain, errURL := post.CanonicalURL(pst.URL)
+	if errURL != nil {
+		frontendMessages.SendError(w, []frontendMessages.ErrorMessage{{
+			Location: "body",
+			Param:    "url",
+			Value:    pst.URL,
+			Message:  "is invalid",
+		}}, http.StatusUnprocessableEntity, h.Logger, from)
+		return false, nil
+	}
+	pst.URL, pst.Domain = canonical, domain
+	if h.DuplicateWindow == 0 {
+		return true, nil
+	}
+	duplicate, err := h.PostRepo.Duplicate(pst.Category, pst.URL, time.Now().Add(-h.DuplicateWindow))
+	if err != nil {
+		return false, err
+	}
+	// an edited scheduled post finds itself
+	if duplicate == 0 || duplicate == pst.ID {
+		return true, nil
+	}
+	if h.RejectDuplicates {
+		frontendMessages.SendMessage(w,
+			fmt.Sprintf("this link was already posted to this category: %d", duplicate),
+			http.StatusConflict,
+			h.Logger, from,
+		)
+		return false, nil
+	}
+	pst.DuplicateOf = duplicate
+	return true, nil
+}
+
+// fetchPreview stores the preview of the linked page on the post. It runs in
+// background after PostAdd, so errors are only logged.
+func (h *PostHandler) fetchPreview(postID uint64, link string) {
+	prv, err := h.Previews.Fetch(context.Background(), link)
+	if err != nil {
+		h.Logger.Infof("fetchPreview: post %d: %s", postID, err)
+		return
+	}
+	if prv.Empty() {
+		return
+	}
+	if !h.PostRepo.Lock(postID) {
+		return
+	}
+	defer h.PostRepo.Unlock(postID)
+	pst, err := h.PostRepo.Get(postID)
+	if err != nil {
+		h.Logger.Errorf("fetchPreview: can`t get post %d: %s", postID, err)
+		return
+	}
+	pst.Preview = &prv
+	if err = h.PostRepo.Update(pst); err != nil {
+		h.Logger.Errorf("fetchPreview: can`t update post %d: %s", postID, err)
+	}
+}
+
+func (h *PostHandler) DomainPosts(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domain, errGet := token.GetMapItemString(vars, "domain")
+	if errGet != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("DomainPosts: %w", errors.ErrRequest{Err: errGet}),
+		)
+		return
+	}
+	filter, errFilter := h.listingFilter(r, post.Filter{Domain: strings.ToLower(domain)})
+	if errFilter != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("DomainPosts: %w", errFilter),
+		)
+		return
+	}
+	postsStr, err := h.PostRepo.ToJson(filter)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("DomainPosts: %w", errors.ErrMarshal{Err: err}),
+		)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(postsStr)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"redditclone/pkg/database/mocks"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/notification"
+	"redditclone/pkg/post"
+	"redditclone/pkg/user"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupMentionUsers(postHandler *PostHandler, users ...user.User) {
+	for _, usr := range users {
+		postHandler.UserRepo.(*mocks.UserRepo).On("Find", usr.Username).Return(usr, true)
+	}
+	postHandler.UserRepo.(*mocks.UserRepo).On("Find", mock.AnythingOfType("string")).Return(user.User{}, false)
+	postHandler.Notifications.(*mocks.NotificationRepo).On("Add", mock.AnythingOfType("*notification.Notification")).Return(nil)
+	postHandler.Blocks.(*mocks.BlockRepo).On("IsBlocked", mock.AnythingOfType("user.User"), mock.AnythingOfType("user.User")).Return(false, nil)
+}
+
+func requireNotified(t *testing.T, postHandler *PostHandler, expected []notification.Notification) {
+	sent := []notification.Notification{}
+	for _, call := range postHandler.Notifications.(*mocks.NotificationRepo).Calls {
+		ntf := *call.Arguments.Get(0).(*notification.Notification)
+		sent = append(sent, notification.Notification{UserID: ntf.UserID, Kind: ntf.Kind, PostID: ntf.PostID, CommentID: ntf.CommentID})
+	}
+	require.Equal(t, sent, expected)
+}
+
+func TestCommentAddMentions(t *testing.T) {
+	author := user.User{Username: "test", UserID: 1}
+	alice := user.User{Username: "alice", UserID: 2}
+	bob := user.User{Username: "bob", UserID: 3}
+
+	postHandler := setupPost()
+	defer postHandler.Logger.Sync()
+	setupMentionUsers(postHandler, author, alice, bob)
+
+	r := httptest.NewRequest("POST", "/api/post/4", strings.NewReader(`{"comment":"hi @alice u/bob @ghost @test @alice"}`))
+	r = mux.SetURLVars(r, map[string]string{"post_id": "4"})
+	ctx := context.WithValue(r.Context(), middleware.UserContextKey, author)
+	w := httptest.NewRecorder()
+
+	postHandler.PostRepo.(*mocks.PostRepo).On("Lock", uint64(4)).Return(true)
+	postHandler.PostRepo.(*mocks.PostRepo).On("Unlock", uint64(4)).Return(true)
+	postHandler.PostRepo.(*mocks.PostRepo).On("Get", uint64(4)).Return(post.Post{ID: 4, Author: bob, CommentID: 7}, nil)
+	postHandler.PostRepo.(*mocks.PostRepo).On("Update", mock.AnythingOfType("post.Post")).Return(nil)
+	postHandler.Blocks.(*mocks.BlockRepo).On("IsBlocked", bob, author).Return(false, nil)
+
+	postHandler.CommentAdd(w, r.WithContext(ctx))
+
+	resp := w.Result()
+	body, errRead := ioutil.ReadAll(resp.Body)
+	require.NoError(t, errRead)
+	require.Equal(t, resp.StatusCode, http.StatusOK)
+
+	var pst post.Post
+	require.NoError(t, json.Unmarshal(body, &pst))
+	require.Equal(t, pst.Comments[0].Mentions, []user.User{alice, bob})
+	postHandler.UserRepo.(*mocks.UserRepo).AssertNumberOfCalls(t, "Find", 4)
+	requireNotified(t, postHandler, []notification.Notification{
+		{UserID: bob.UserID, Kind: notification.KindPostReply, PostID: 4, CommentID: 7},
+		{UserID: alice.UserID, Kind: notification.KindCommentMention, PostID: 4, CommentID: 7},
+	})
+}
+
+func TestCommentAddMentionsBlocked(t *testing.T) {
+	author := user.User{Username: "test", UserID: 1}
+	alice := user.User{Username: "alice", UserID: 2}
+	bob := user.User{Username: "bob", UserID: 3}
+
+	postHandler := setupPost()
+	defer postHandler.Logger.Sync()
+	postHandler.Blocks.(*mocks.BlockRepo).On("IsBlocked", alice, author).Return(true, nil)
+	setupMentionUsers(postHandler, alice, bob)
+
+	r := httptest.NewRequest("POST", "/api/post/4", strings.NewReader(`{"comment":"hi @alice"}`))
+	r = mux.SetURLVars(r, map[string]string{"post_id": "4"})
+	ctx := context.WithValue(r.Context(), middleware.UserContextKey, author)
+	w := httptest.NewRecorder()
+
+	postHandler.PostRepo.(*mocks.PostRepo).On("Lock", uint64(4)).Return(true)
+	postHandler.PostRepo.(*mocks.PostRepo).On("Unlock", uint64(4)).Return(true)
+	postHandler.PostRepo.(*mocks.PostRepo).On("Get", uint64(4)).Return(post.Post{ID: 4, Author: bob, CommentID: 7}, nil)
+	postHandler.PostRepo.(*mocks.PostRepo).On("Update", mock.AnythingOfType("post.Post")).Return(nil)
+
+	postHandler.CommentAdd(w, r.WithContext(ctx))
+
+	require.Equal(t, w.Result().StatusCode, http.StatusOK)
+	requireNotified(t, postHandler, []notification.Notification{
+		{UserID: bob.UserID, Kind: notification.KindPostReply, PostID: 4, CommentID: 7},
+	})
+}
+
+func TestPostAddMentions(t *testing.T) {
+	author := user.User{Username: "test", UserID: 1}
+	alice := user.User{Username: "alice", UserID: 2}
+
+	postHandler := setupPost()
+	defer postHandler.Logger.Sync()
+	setupMentionUsers(postHandler, alice)
+
+	r := httptest.NewRequest("POST", "/api/posts", strings.NewReader(
+		`{"type":"text","title":"title","text":"@alice @a @b @c @d @e @f","mentions":[{"username":"fake","id":"9"}]}`,
+	))
+	ctx := context.WithValue(r.Context(), middleware.UserContextKey, author)
+	w := httptest.NewRecorder()
+
+	postHandler.PostRepo.(*mocks.PostRepo).
+		On("Add", mock.AnythingOfType("*post.Post")).
+		Run(func(args mock.Arguments) {
+			args.Get(0).(*post.Post).ID = 5
+		}).
+		Return(nil)
+
+	postHandler.PostAdd(w, r.WithContext(ctx))
+
+	resp := w.Result()
+	body, errRead := ioutil.ReadAll(resp.Body)
+	require.NoError(t, errRead)
+	require.Equal(t, resp.StatusCode, http.StatusOK)
+
+	var pst post.Post
+	require.NoError(t, json.Unmarshal(body, &pst))
+	require.Equal(t, pst.Mentions, []user.User{alice})
+	postHandler.UserRepo.(*mocks.UserRepo).AssertNumberOfCalls(t, "Find", maxMentions)
+	requireNotified(t, postHandler, []notification.Notification{
+		{UserID: alice.UserID, Kind: notification.KindPostMention, PostID: 5},
+	})
+}
@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"redditclone/pkg/errors"
+	"redditclone/pkg/frontendMessages"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/token"
+	"redditclone/pkg/user"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+)
+
+const maxMessageLen = 2000
+
+func (h *MessageHandler) userFromPath(w http.ResponseWriter, r *http.Request, from string) (user.User, bool) {
+	vars := mux.Vars(r)
+	username, errGet := token.GetMapItemString(vars, "user_login")
+	if errGet != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("%s: %w", from, errors.ErrRequest{Err: errGet}),
+		)
+		return user.User{}, false
+	}
+	usr, ok := h.UserRepo.Find(username)
+	if !ok {
+		frontendMessages.SendMessage(w,
+			"user not found",
+			http.StatusNotFound,
+			h.Logger, from,
+		)
+		return user.User{}, false
+	}
+	return usr, true
+}
+
+// peerFromPath finds the other side of a conversation by user_login or, for
+// conversations with deleted accounts which have no login anymore, by user_id.
+func (h *MessageHandler) peerFromPath(w http.ResponseWriter, r *http.Request, usr user.User, from string) (user.User, bool) {
+	vars := mux.Vars(r)
+	if _, byID := vars["user_id"]; !byID {
+		return h.userFromPath(w, r, from)
+	}
+	id, errGet := token.GetMapItemUint64(vars, "user_id")
+	if errGet != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("%s: %w", from, errors.ErrRequest{Err: errGet}),
+		)
+		return user.User{}, false
+	}
+	conversations, err := h.Messages.Conversations(usr)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("%s: %w", from, err),
+		)
+		return user.User{}, false
+	}
+	for _, conv := range conversations {
+		if conv.With.UserID == int64(id) {
+			return conv.With, true
+		}
+	}
+	frontendMessages.SendMessage(w,
+		"user not found",
+		http.StatusNotFound,
+		h.Logger, from,
+	)
+	return user.User{}, false
+}
+
+func (h *MessageHandler) sendJson(w http.ResponseWriter, data interface{}, from string) {
+	res, errMarshal := json.Marshal(data)
+	if errMarshal != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("%s: %w", from, errors.ErrMarshal{Err: errMarshal}),
+		)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+func (h *MessageHandler) MessageSend(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	type readMessage struct {
+		To   string `json:"to"`
+		Body string `json:"body"`
+	}
+	readMsg := readMessage{}
+	errUnmarshal := json.Unmarshal(body, &readMsg)
+	if errUnmarshal != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("messageSend: %w", errors.ErrUnmarshalRequest{Err: errUnmarshal}),
+		)
+		return
+	}
+	errs := []frontendMessages.ErrorMessage{}
+	if readMsg.To == "" {
+		errs = append(errs, frontendMessages.ErrorMessage{
+			Location: "body",
+			Param:    "to",
+			Message:  "is required",
+		})
+	} else if readMsg.To == usr.Username {
+		errs = append(errs, frontendMessages.ErrorMessage{
+			Location: "body",
+			Param:    "to",
+			Value:    readMsg.To,
+			Message:  "can`t be yourself",
+		})
+	}
+	if readMsg.Body == "" {
+		errs = append(errs, frontendMessages.ErrorMessage{
+			Location: "body",
+			Param:    "body",
+			Message:  "is required",
+		})
+	} else if utf8.RuneCountInString(readMsg.Body) > maxMessageLen {
+		errs = append(errs, frontendMessages.ErrorMessage{
+			Location: "body",
+			Param:    "body",
+			Message:  fmt.Sprintf("must be at most %d characters long", maxMessageLen),
+		})
+	}
+	if len(errs) != 0 {
+		frontendMessages.SendError(w, errs, http.StatusUnprocessableEntity, h.Logger, "messageSend")
+		return
+	}
+	recipient, found := h.UserRepo.Find(readMsg.To)
+	if !found {
+		frontendMessages.SendMessage(w,
+			"user not found",
+			http.StatusNotFound,
+			h.Logger, "messageSend",
+		)
+		return
+	}
+	blocked, errBlocked := h.Messages.IsBlocked(recipient, usr)
+	if errBlocked != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("messageSend: %w", errBlocked),
+		)
+		return
+	}
+	if blocked {
+		frontendMessages.SendMessage(w,
+			"this user doesn't accept your messages",
+			http.StatusForbidden,
+			h.Logger, "messageSend",
+		)
+		return
+	}
+	msg, errSend := h.Messages.Send(usr, recipient, readMsg.Body)
+	if errSend != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("messageSend: %w", errSend),
+		)
+		return
+	}
+	h.Logger.Debugf("message %d from %d to %d", msg.ID, usr.UserID, recipient.UserID)
+	h.sendJson(w, msg, "messageSend")
+}
+
+func (h *MessageHandler) Conversations(w http.ResponseWriter, r *http.Request) {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	conversations, err := h.Messages.Conversations(usr)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("conversations: %w", err),
+		)
+		return
+	}
+	h.sendJson(w, conversations, "conversations")
+}
+
+func (h *MessageHandler) Thread(w http.ResponseWriter, r *http.Request) {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	page, limit, errs := getPagination(r)
+	if len(errs) != 0 {
+		frontendMessages.SendError(w, errs, http.StatusUnprocessableEntity, h.Logger, "thread")
+		return
+	}
+	with, found := h.peerFromPath(w, r, usr, "thread")
+	if !found {
+		return
+	}
+	messages, err := h.Messages.Thread(usr, with, page, limit)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("thread: %w", err),
+		)
+		return
+	}
+	h.sendJson(w, messages, "thread")
+}
+
+func (h *MessageHandler) ThreadRead(w http.ResponseWriter, r *http.Request) {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	with, found := h.peerFromPath(w, r, usr, "threadRead")
+	if !found {
+		return
+	}
+	errRead := h.Messages.MarkRead(usr, with)
+	if errRead != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("threadRead: %w", errRead),
+		)
+		return
+	}
+	frontendMessages.SendMessage(w,
+		"success",
+		http.StatusOK,
+		h.Logger, "threadRead",
+	)
+}
+
+func (h *MessageHandler) setBlock(w http.ResponseWriter, r *http.Request, block bool) error {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+	target, found := h.userFromPath(w, r, "setBlock")
+	if !found {
+		return nil
+	}
+	if target.UserID == usr.UserID {
+		frontendMessages.SendMessage(w,
+			"can`t block yourself",
+			http.StatusBadRequest,
+			h.Logger, "setBlock",
+		)
+		return nil
+	}
+	var err error
+	if block {
+		err = h.Messages.Block(usr, target)
+	} else {
+		err = h.Messages.Unblock(usr, target)
+	}
+	if err != nil {
+		return err
+	}
+	frontendMessages.SendMessage(w,
+		"success",
+		http.StatusOK,
+		h.Logger, "setBlock",
+	)
+	return nil
+}
+
+func (h *MessageHandler) UserBlock(w http.ResponseWriter, r *http.Request) {
+	err := h.setBlock(w, r, true)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("userBlock: %w", err),
+		)
+	}
+}
+
+func (h *MessageHandler) UserUnblock(w http.ResponseWriter, r *http.Request) {
+	err := h.setBlock(w, r, false)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("userUnblock: %w", err),
+		)
+	}
+}
+
+func (h *MessageHandler) BlockedUsers(w http.ResponseWriter, r *http.Request) {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	blocked, err := h.Messages.Blocks(usr)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("blockedUsers: %w", err),
+		)
+		return
+	}
+	h.sendJson(w, blocked, "blockedUsers")
+}
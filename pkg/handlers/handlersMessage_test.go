@@ -0,0 +1,383 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"redditclone/pkg/database/mocks"
+	"redditclone/pkg/message"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/user"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageSend(t *testing.T) {
+	type testCase struct {
+		contextKey middleware.Key
+		request    string
+
+		findUser   user.User
+		findStatus bool
+
+		blocked      bool
+		blockedError error
+
+		sendRes   message.Message
+		sendError error
+
+		statusCode int
+		response   string
+	}
+
+	usr := user.User{Username: "test", UserID: 1}
+	recipient := user.User{Username: "friend", UserID: 2}
+	testCases := []testCase{
+		{
+			contextKey: middleware.UserContextKey,
+			request:    `{"to":"friend","body":"hello"}`,
+			findUser:   recipient,
+			findStatus: true,
+			sendRes: message.Message{
+				ID:      5,
+				From:    usr,
+				To:      recipient,
+				Body:    "hello",
+				Created: "2022-05-02T18:32:00Z",
+			},
+			statusCode: http.StatusOK,
+			response:   `{"id":"5","from":{"username":"test","id":"1"},"to":{"username":"friend","id":"2"},"body":"hello","created":"2022-05-02T18:32:00Z","read":false}`,
+		},
+		{
+			contextKey: middleware.UserContextKey,
+			request:    `{"to":"test","body":""}`,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"to\",\"value\":\"test\",\"msg\":\"can`t be yourself\"},{\"location\":\"body\",\"param\":\"body\",\"msg\":\"is required\"}]}\n",
+		},
+		{
+			contextKey: middleware.UserContextKey,
+			request:    `{"to":"friend","body":"hello"}`,
+			findStatus: false,
+			statusCode: http.StatusNotFound,
+			response:   "{\"message\":\"user not found\"}\n",
+		},
+		{
+			contextKey: middleware.UserContextKey,
+			request:    `{"to":"friend","body":"hello"}`,
+			findUser:   recipient,
+			findStatus: true,
+			blocked:    true,
+			statusCode: http.StatusForbidden,
+			response:   "{\"message\":\"this user doesn't accept your messages\"}\n",
+		},
+		{
+			contextKey:   middleware.UserContextKey,
+			request:      `{"to":"friend","body":"hello"}`,
+			findUser:     recipient,
+			findStatus:   true,
+			blockedError: fmt.Errorf("test error"),
+			statusCode:   http.StatusInternalServerError,
+			response:     "",
+		},
+		{
+			contextKey: middleware.UserContextKey,
+			request:    `{"to":"friend","body":"hello"}`,
+			findUser:   recipient,
+			findStatus: true,
+			sendError:  fmt.Errorf("test error"),
+			statusCode: http.StatusInternalServerError,
+			response:   "",
+		},
+		{
+			contextKey: middleware.UserContextKey,
+			request:    `wrong request`,
+			statusCode: http.StatusInternalServerError,
+			response:   "",
+		},
+		{
+			contextKey: middleware.AuthtorizationContextKey,
+			request:    `{"to":"friend","body":"hello"}`,
+			statusCode: http.StatusInternalServerError,
+			response:   "Internal server error\n",
+		},
+	}
+
+	for _, testCase := range testCases {
+		messageHandler := setupMessage()
+		defer messageHandler.Logger.Sync()
+
+		r := httptest.NewRequest("POST", "/api/messages", strings.NewReader(testCase.request))
+		ctx := context.WithValue(r.Context(), testCase.contextKey, usr)
+		w := httptest.NewRecorder()
+
+		messageHandler.UserRepo.(*mocks.UserRepo).
+			On("Find", "friend").
+			Return(testCase.findUser, testCase.findStatus)
+
+		messageHandler.Messages.(*mocks.MessageRepo).
+			On("IsBlocked", recipient, usr).
+			Return(testCase.blocked, testCase.blockedError)
+
+		messageHandler.Messages.(*mocks.MessageRepo).
+			On("Send", usr, recipient, "hello").
+			Return(testCase.sendRes, testCase.sendError)
+
+		messageHandler.MessageSend(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		require.Equal(t, string(body), testCase.response)
+	}
+}
+
+func TestConversations(t *testing.T) {
+	usr := user.User{Username: "test", UserID: 1}
+	friend := user.User{Username: "friend", UserID: 2}
+
+	messageHandler := setupMessage()
+	defer messageHandler.Logger.Sync()
+
+	messageHandler.Messages.(*mocks.MessageRepo).
+		On("Conversations", usr).
+		Return([]message.Conversation{{
+			With: friend,
+			LastMessage: message.Message{
+				ID: 3, From: friend, To: usr, Body: "hi", Created: "2022-05-02T18:32:00Z",
+			},
+			Unread: 1,
+		}}, nil)
+
+	r := httptest.NewRequest("GET", "/api/messages", nil)
+	ctx := context.WithValue(r.Context(), middleware.UserContextKey, usr)
+	w := httptest.NewRecorder()
+
+	messageHandler.Conversations(w, r.WithContext(ctx))
+
+	resp := w.Result()
+	body, errRead := ioutil.ReadAll(resp.Body)
+	require.NoError(t, errRead)
+	require.Equal(t, resp.StatusCode, http.StatusOK)
+	require.Equal(t, string(body), `[{"with":{"username":"friend","id":"2"},"lastMessage":{"id":"3","from":{"username":"friend","id":"2"},"to":{"username":"test","id":"1"},"body":"hi","created":"2022-05-02T18:32:00Z","read":false},"unread":1}]`)
+}
+
+func TestThread(t *testing.T) {
+	type testCase struct {
+		valueVars map[string]string
+		query     string
+		page      int64
+		limit     int64
+
+		findStatus bool
+
+		threadRes   []message.Message
+		threadError error
+
+		statusCode int
+		response   string
+	}
+
+	usr := user.User{Username: "test", UserID: 1}
+	friend := user.User{Username: "friend", UserID: 2}
+	testCases := []testCase{
+		{
+			valueVars:  map[string]string{"user_login": "friend"},
+			query:      "?page=2&limit=1",
+			page:       2,
+			limit:      1,
+			findStatus: true,
+			threadRes:  []message.Message{{ID: 3, From: friend, To: usr, Body: "hi", Created: "2022-05-02T18:32:00Z", Read: true}},
+			statusCode: http.StatusOK,
+			response:   `[{"id":"3","from":{"username":"friend","id":"2"},"to":{"username":"test","id":"1"},"body":"hi","created":"2022-05-02T18:32:00Z","read":true}]`,
+		},
+		{
+			valueVars:  map[string]string{"user_login": "friend"},
+			limit:      defaultPageLimit,
+			findStatus: false,
+			statusCode: http.StatusNotFound,
+			response:   "{\"message\":\"user not found\"}\n",
+		},
+		{
+			valueVars:  map[string]string{"user_login": "friend"},
+			query:      "?limit=0",
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"query\",\"param\":\"limit\",\"value\":\"0\",\"msg\":\"must be an integer from 1 to 100\"}]}\n",
+		},
+		{
+			valueVars:   map[string]string{"user_login": "friend"},
+			limit:       defaultPageLimit,
+			findStatus:  true,
+			threadError: fmt.Errorf("test error"),
+			statusCode:  http.StatusInternalServerError,
+			response:    "",
+		},
+		{
+			valueVars:  map[string]string{"wrong vars": "friend"},
+			limit:      defaultPageLimit,
+			statusCode: http.StatusInternalServerError,
+			response:   "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		messageHandler := setupMessage()
+		defer messageHandler.Logger.Sync()
+
+		r := httptest.NewRequest("GET", "/api/messages/friend"+testCase.query, nil)
+		r = mux.SetURLVars(r, testCase.valueVars)
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, usr)
+		w := httptest.NewRecorder()
+
+		messageHandler.UserRepo.(*mocks.UserRepo).
+			On("Find", "friend").
+			Return(friend, testCase.findStatus)
+
+		messageHandler.Messages.(*mocks.MessageRepo).
+			On("Thread", usr, friend, testCase.page, testCase.limit).
+			Return(testCase.threadRes, testCase.threadError)
+
+		messageHandler.Thread(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		require.Equal(t, string(body), testCase.response)
+	}
+}
+
+func TestThreadByID(t *testing.T) {
+	usr := user.User{Username: "test", UserID: 1}
+	deleted := user.User{Username: user.DeletedUsername, UserID: 3}
+	for _, id := range []string{"3", "4"} {
+		messageHandler := setupMessage()
+		defer messageHandler.Logger.Sync()
+
+		r := httptest.NewRequest("GET", "/api/messages/id/"+id, nil)
+		r = mux.SetURLVars(r, map[string]string{"user_id": id})
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, usr)
+		w := httptest.NewRecorder()
+
+		messageHandler.Messages.(*mocks.MessageRepo).
+			On("Conversations", usr).
+			Return([]message.Conversation{{With: deleted}}, nil)
+		messageHandler.Messages.(*mocks.MessageRepo).
+			On("Thread", usr, deleted, int64(0), int64(defaultPageLimit)).
+			Return([]message.Message{{ID: 5, From: usr, To: deleted, Body: "bye", Created: "2022-05-01T10:00:00Z"}}, nil)
+
+		messageHandler.Thread(w, r.WithContext(ctx))
+
+		resp := w.Result()
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		if id == "4" {
+			require.Equal(t, resp.StatusCode, http.StatusNotFound)
+			require.Equal(t, string(body), "{\"message\":\"user not found\"}\n")
+			continue
+		}
+		require.Equal(t, resp.StatusCode, http.StatusOK)
+		require.Equal(t, string(body), `[{"id":"5","from":{"username":"test","id":"1"},"to":{"username":"[deleted]","id":"3"},"body":"bye","created":"2022-05-01T10:00:00Z","read":false}]`)
+	}
+}
+
+func TestUserBlock(t *testing.T) {
+	type testCase struct {
+		block     bool
+		valueVars map[string]string
+
+		findUser   user.User
+		findStatus bool
+		blockError error
+
+		statusCode int
+		response   string
+	}
+
+	usr := user.User{Username: "test", UserID: 1}
+	spammer := user.User{Username: "spammer", UserID: 2}
+	testCases := []testCase{
+		{
+			block:      true,
+			valueVars:  map[string]string{"user_login": "spammer"},
+			findUser:   spammer,
+			findStatus: true,
+			statusCode: http.StatusOK,
+			response:   "{\"message\":\"success\"}\n",
+		},
+		{
+			block:      false,
+			valueVars:  map[string]string{"user_login": "spammer"},
+			findUser:   spammer,
+			findStatus: true,
+			statusCode: http.StatusOK,
+			response:   "{\"message\":\"success\"}\n",
+		},
+		{
+			block:      true,
+			valueVars:  map[string]string{"user_login": "spammer"},
+			findUser:   usr,
+			findStatus: true,
+			statusCode: http.StatusBadRequest,
+			response:   "{\"message\":\"can`t block yourself\"}\n",
+		},
+		{
+			block:      true,
+			valueVars:  map[string]string{"user_login": "spammer"},
+			findStatus: false,
+			statusCode: http.StatusNotFound,
+			response:   "{\"message\":\"user not found\"}\n",
+		},
+		{
+			block:      false,
+			valueVars:  map[string]string{"user_login": "spammer"},
+			findUser:   spammer,
+			findStatus: true,
+			blockError: fmt.Errorf("test error"),
+			statusCode: http.StatusInternalServerError,
+			response:   "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		messageHandler := setupMessage()
+		defer messageHandler.Logger.Sync()
+
+		r := httptest.NewRequest("POST", "/api/messages/spammer/block", nil)
+		r = mux.SetURLVars(r, testCase.valueVars)
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, usr)
+		w := httptest.NewRecorder()
+
+		messageHandler.UserRepo.(*mocks.UserRepo).
+			On("Find", "spammer").
+			Return(testCase.findUser, testCase.findStatus)
+
+		messageHandler.Messages.(*mocks.MessageRepo).
+			On("Block", usr, spammer).
+			Return(testCase.blockError)
+
+		messageHandler.Messages.(*mocks.MessageRepo).
+			On("Unblock", usr, spammer).
+			Return(testCase.blockError)
+
+		if testCase.block {
+			messageHandler.UserBlock(w, r.WithContext(ctx))
+		} else {
+			messageHandler.UserUnblock(w, r.WithContext(ctx))
+		}
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		require.Equal(t, string(body), testCase.response)
+	}
+}
@@ -0,0 +1,383 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"redditclone/pkg/database"
+	"redditclone/pkg/errors"
+	"redditclone/pkg/frontendMessages"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/post"
+	"redditclone/pkg/report"
+	"redditclone/pkg/token"
+	"redditclone/pkg/user"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	maxReasonLen = 500
+	removeReason = "moderation"
+)
+
+func canModerate(users database.UserRepo, usr user.User) (bool, error) {
+	role, err := users.Role(usr.UserID)
+	if err != nil {
+		return false, err
+	}
+	return user.CanModerate(role), nil
+}
+
+// canManageCategory reports whether usr owns the category or moderates the
+// whole site.
+func canManageCategory(categories database.CategoryRepo, users database.UserRepo, category string, usr user.User) (bool, error) {
+	owner, err := categories.IsOwner(category, usr)
+	if err != nil || owner {
+		return owner, err
+	}
+	return canModerate(users, usr)
+}
+
+// canSeeHidden reports whether viewer (nil for anonymous visitors) may see
+// content of author hidden by moderation.
+func canSeeHidden(users database.UserRepo, author user.User, viewer *user.User) (bool, error) {
+	if viewer == nil {
+		return false, nil
+	}
+	if viewer.Username == author.Username && viewer.UserID == author.UserID {
+		return true, nil
+	}
+	return canModerate(users, *viewer)
+}
+
+func targetFromVars(vars map[string]string) (report.Target, error) {
+	postID, errGet := token.GetMapItemUint64(vars, "post_id")
+	if errGet != nil {
+		return report.Target{}, errGet
+	}
+	if _, ok := vars["comment_id"]; !ok {
+		return report.Target{Kind: report.KindPost, PostID: postID}, nil
+	}
+	commentID, errGet := token.GetMapItemUint64(vars, "comment_id")
+	if errGet != nil {
+		return report.Target{}, errGet
+	}
+	return report.Target{Kind: report.KindComment, PostID: postID, CommentID: commentID}, nil
+}
+
+// setHidden hides or shows the reported item, returns false if it doesn't exist.
+func (h *ModerationHandler) setHidden(target report.Target, hidden bool) (bool, error) {
+	if !h.PostRepo.Lock(target.PostID) {
+		return false, nil
+	}
+	defer h.PostRepo.Unlock(target.PostID)
+	pst, err := h.PostRepo.Get(target.PostID)
+	if err != nil {
+		return false, err
+	}
+	if target.Kind == report.KindPost {
+		pst.Hidden = hidden
+	} else {
+		index := commentIndex(pst, target.CommentID)
+		if index == -1 {
+			return false, nil
+		}
+		pst.Comments[index].Hidden = hidden
+	}
+	return true, h.PostRepo.Update(pst)
+}
+
+// commentIndex returns the position of the live comment with commentID, -1 if
+// there is none or it was deleted.
+func commentIndex(pst post.Post, commentID uint64) int {
+	for i, cmt := range pst.Comments {
+		if cmt.ID == commentID && cmt.DeletedAt == nil {
+			return i
+		}
+	}
+	return -1
+}
+
+func (h *ModerationHandler) itemExists(target report.Target) (bool, error) {
+	if target.Kind == report.KindPost {
+		return h.PostRepo.Find(target.PostID), nil
+	}
+	if !h.PostRepo.Find(target.PostID) {
+		return false, nil
+	}
+	pst, err := h.PostRepo.Get(target.PostID)
+	if err != nil {
+		return false, err
+	}
+	return commentIndex(pst, target.CommentID) != -1, nil
+}
+
+func (h *ModerationHandler) report(w http.ResponseWriter, r *http.Request) error {
+	body, _ := ioutil.ReadAll(r.Body)
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+	target, errTarget := targetFromVars(mux.Vars(r))
+	if errTarget != nil {
+		return errors.ErrRequest{Err: errTarget}
+	}
+	type readReport struct {
+		Reason string `json:"reason"`
+	}
+	readRpt := readReport{}
+	errUnmarshal := json.Unmarshal(body, &readRpt)
+	if errUnmarshal != nil {
+		return errors.ErrUnmarshalRequest{Err: errUnmarshal}
+	}
+	if readRpt.Reason == "" || utf8.RuneCountInString(readRpt.Reason) > maxReasonLen {
+		message := "is required"
+		if readRpt.Reason != "" {
+			message = fmt.Sprintf("must be at most %d characters long", maxReasonLen)
+		}
+		frontendMessages.SendError(w, []frontendMessages.ErrorMessage{{
+			Location: "body",
+			Param:    "reason",
+			Message:  message,
+		}}, http.StatusUnprocessableEntity, h.Logger, "report")
+		return nil
+	}
+	exists, errExists := h.itemExists(target)
+	if errExists != nil {
+		return errExists
+	}
+	if !exists {
+		frontendMessages.SendMessage(w,
+			fmt.Sprintf("%s not found", target.Kind),
+			http.StatusNotFound,
+			h.Logger, "report",
+		)
+		return nil
+	}
+	count, errAdd := h.Reports.Add(report.Report{Target: target, ReporterID: usr.UserID, Reason: readRpt.Reason})
+	if errAdd == database.ErrAlreadyReported {
+		frontendMessages.SendMessage(w,
+			fmt.Sprintf("you have already reported this %s", target.Kind),
+			http.StatusConflict,
+			h.Logger, "report",
+		)
+		return nil
+	}
+	if errAdd != nil {
+		return errAdd
+	}
+	if h.HideThreshold > 0 && count >= h.HideThreshold {
+		if _, errHide := h.setHidden(target, true); errHide != nil {
+			h.Logger.Errorf("report: can`t hide %s %d/%d: %s", target.Kind, target.PostID, target.CommentID, errHide)
+		}
+	}
+	frontendMessages.SendMessage(w,
+		"success",
+		http.StatusOK,
+		h.Logger, "report",
+	)
+	return nil
+}
+
+func (h *ModerationHandler) PostReport(w http.ResponseWriter, r *http.Request) {
+	err := h.report(w, r)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("postReport: %w", err),
+		)
+	}
+}
+
+func (h *ModerationHandler) CommentReport(w http.ResponseWriter, r *http.Request) {
+	err := h.report(w, r)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("commentReport: %w", err),
+		)
+	}
+}
+
+// moderator returns the current user if the user can moderate, otherwise it sends the response itself.
+func (h *ModerationHandler) moderator(w http.ResponseWriter, r *http.Request, from string) (user.User, bool) {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return user.User{}, false
+	}
+	allowed, err := canModerate(h.UserRepo, usr)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("%s: can`t get role: %w", from, err),
+		)
+		return user.User{}, false
+	}
+	if !allowed {
+		frontendMessages.SendMessage(w,
+			"moderator rights required",
+			http.StatusForbidden,
+			h.Logger, from,
+		)
+		return user.User{}, false
+	}
+	return usr, true
+}
+
+func (h *ModerationHandler) Queue(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.moderator(w, r, "moderationQueue"); !ok {
+		return
+	}
+	page, limit, errs := getPagination(r)
+	if len(errs) != 0 {
+		frontendMessages.SendError(w, errs, http.StatusUnprocessableEntity, h.Logger, "moderationQueue")
+		return
+	}
+	items, err := h.Reports.Queue(page, limit)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("moderationQueue: %w", err),
+		)
+		return
+	}
+	for i := range items {
+		item := &items[i]
+		if !h.PostRepo.Find(item.PostID) {
+			item.Missing = true
+			continue
+		}
+		pst, errGet := h.PostRepo.Get(item.PostID)
+		if errGet != nil {
+			errors.SendHttpError(
+				h.Logger, w,
+				fmt.Errorf("moderationQueue: %w", errGet),
+			)
+			return
+		}
+		if item.Kind == report.KindPost {
+			item.Author = &pst.Author
+			item.Text = pst.Title
+			item.Hidden = pst.Hidden
+			continue
+		}
+		index := commentIndex(pst, item.CommentID)
+		if index == -1 {
+			item.Missing = true
+			continue
+		}
+		item.Author = &pst.Comments[index].Author
+		item.Text = pst.Comments[index].Body
+		item.Hidden = pst.Comments[index].Hidden
+	}
+	res, errMarshal := json.Marshal(items)
+	if errMarshal != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("moderationQueue: %w", errors.ErrMarshal{Err: errMarshal}),
+		)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+func (h *ModerationHandler) remove(target report.Target, moderator user.User) (bool, error) {
+	if !h.PostRepo.Lock(target.PostID) {
+		return false, nil
+	}
+	defer h.PostRepo.Unlock(target.PostID)
+	pst, err := h.PostRepo.Get(target.PostID)
+	if err != nil {
+		return false, err
+	}
+	if target.Kind == report.KindPost {
+		pst.MarkDeleted(moderator, removeReason)
+		if err = h.PostRepo.Update(pst); err != nil {
+			return false, err
+		}
+		if errMark := h.PostRepo.MarkCrossposts(target.PostID, true); errMark != nil {
+			h.Logger.Errorf("can`t mark crossposts of post %d: %s", target.PostID, errMark)
+		}
+		return true, h.Reports.ResolvePost(target.PostID)
+	}
+	index := commentIndex(pst, target.CommentID)
+	if index == -1 {
+		return false, nil
+	}
+	pst.Comments[index].MarkDeleted(moderator, removeReason)
+	if err = h.PostRepo.Update(pst); err != nil {
+		return false, err
+	}
+	return true, h.Reports.Resolve(target)
+}
+
+func (h *ModerationHandler) resolve(w http.ResponseWriter, r *http.Request, remove bool) error {
+	usr, ok := h.moderator(w, r, "resolve")
+	if !ok {
+		return nil
+	}
+	target, errTarget := targetFromVars(mux.Vars(r))
+	if errTarget != nil {
+		return errors.ErrRequest{Err: errTarget}
+	}
+	var (
+		found bool
+		err   error
+	)
+	if remove {
+		found, err = h.remove(target, usr)
+	} else {
+		found, err = h.setHidden(target, false)
+		if err == nil {
+			err = h.Reports.Resolve(target)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if !found {
+		frontendMessages.SendMessage(w,
+			fmt.Sprintf("%s not found", target.Kind),
+			http.StatusNotFound,
+			h.Logger, "resolve",
+		)
+		return nil
+	}
+	h.Logger.Infof(`moderator "%s" resolved reports of %s %d/%d, removed: %t`,
+		usr.Username, target.Kind, target.PostID, target.CommentID, remove,
+	)
+	frontendMessages.SendMessage(w,
+		"success",
+		http.StatusOK,
+		h.Logger, "resolve",
+	)
+	return nil
+}
+
+func (h *ModerationHandler) ReportDismiss(w http.ResponseWriter, r *http.Request) {
+	err := h.resolve(w, r, false)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("reportDismiss: %w", err),
+		)
+	}
+}
+
+func (h *ModerationHandler) ReportRemove(w http.ResponseWriter, r *http.Request) {
+	err := h.resolve(w, r, true)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("reportRemove: %w", err),
+		)
+	}
+}
@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"redditclone/pkg/comment"
+	"redditclone/pkg/database"
+	"redditclone/pkg/database/mocks"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/post"
+	"redditclone/pkg/report"
+	"redditclone/pkg/user"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport(t *testing.T) {
+	type testCase struct {
+		valueVars map[string]string
+		request   string
+		reason    string
+		target    report.Target
+
+		postRepoFind bool
+		postRepoGet  post.Post
+
+		reportCount int64
+		reportError error
+
+		hidden bool
+
+		statusCode int
+		response   string
+	}
+
+	usr := user.User{Username: "test", UserID: 1}
+	withComment := post.Post{Comments: []comment.Comment{{ID: 3, Body: "rude"}}}
+	testCases := []testCase{
+		{
+			valueVars:    map[string]string{"post_id": "1"},
+			request:      `{"reason":"spam"}`,
+			reason:       "spam",
+			target:       report.Target{Kind: report.KindPost, PostID: 1},
+			postRepoFind: true,
+			reportCount:  1,
+			statusCode:   http.StatusOK,
+			response:     "{\"message\":\"success\"}\n",
+		},
+		{
+			valueVars:    map[string]string{"post_id": "1"},
+			request:      `{"reason":"spam"}`,
+			reason:       "spam",
+			target:       report.Target{Kind: report.KindPost, PostID: 1},
+			postRepoFind: true,
+			reportCount:  2,
+			hidden:       true,
+			statusCode:   http.StatusOK,
+			response:     "{\"message\":\"success\"}\n",
+		},
+		{
+			valueVars:    map[string]string{"post_id": "1", "comment_id": "3"},
+			request:      `{"reason":"rude"}`,
+			reason:       "rude",
+			target:       report.Target{Kind: report.KindComment, PostID: 1, CommentID: 3},
+			postRepoFind: true,
+			postRepoGet:  withComment,
+			reportCount:  5,
+			hidden:       true,
+			statusCode:   http.StatusOK,
+			response:     "{\"message\":\"success\"}\n",
+		},
+		{
+			valueVars:    map[string]string{"post_id": "1", "comment_id": "4"},
+			request:      `{"reason":"rude"}`,
+			reason:       "rude",
+			target:       report.Target{Kind: report.KindComment, PostID: 1, CommentID: 4},
+			postRepoFind: true,
+			postRepoGet:  withComment,
+			statusCode:   http.StatusNotFound,
+			response:     "{\"message\":\"comment not found\"}\n",
+		},
+		{
+			valueVars:    map[string]string{"post_id": "1"},
+			request:      `{"reason":"spam"}`,
+			reason:       "spam",
+			target:       report.Target{Kind: report.KindPost, PostID: 1},
+			postRepoFind: false,
+			statusCode:   http.StatusNotFound,
+			response:     "{\"message\":\"post not found\"}\n",
+		},
+		{
+			valueVars:  map[string]string{"post_id": "1"},
+			request:    `{"reason":""}`,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"reason\",\"msg\":\"is required\"}]}\n",
+		},
+		{
+			valueVars:    map[string]string{"post_id": "1"},
+			request:      `{"reason":"spam"}`,
+			reason:       "spam",
+			target:       report.Target{Kind: report.KindPost, PostID: 1},
+			postRepoFind: true,
+			reportError:  fmt.Errorf("test error"),
+			statusCode:   http.StatusInternalServerError,
+			response:     "",
+		},
+		{
+			valueVars:    map[string]string{"post_id": "1"},
+			request:      `{"reason":"spam"}`,
+			reason:       "spam",
+			target:       report.Target{Kind: report.KindPost, PostID: 1},
+			postRepoFind: true,
+			reportError:  database.ErrAlreadyReported,
+			statusCode:   http.StatusConflict,
+			response:     "{\"message\":\"you have already reported this post\"}\n",
+		},
+		{
+			valueVars:  map[string]string{"wrong vars": "1"},
+			request:    `{"reason":"spam"}`,
+			statusCode: http.StatusInternalServerError,
+			response:   "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		moderationHandler := setupModeration()
+		defer moderationHandler.Logger.Sync()
+
+		r := httptest.NewRequest("POST", "/api/post/1/report", strings.NewReader(testCase.request))
+		r = mux.SetURLVars(r, testCase.valueVars)
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, usr)
+		w := httptest.NewRecorder()
+
+		moderationHandler.PostRepo.(*mocks.PostRepo).On("Find", uint64(1)).Return(testCase.postRepoFind)
+		moderationHandler.PostRepo.(*mocks.PostRepo).On("Lock", uint64(1)).Return(true)
+		moderationHandler.PostRepo.(*mocks.PostRepo).On("Get", uint64(1)).Return(testCase.postRepoGet, nil)
+		moderationHandler.PostRepo.(*mocks.PostRepo).On("Update", mock.AnythingOfType("post.Post")).Return(nil)
+		moderationHandler.PostRepo.(*mocks.PostRepo).On("Unlock", uint64(1)).Return(true)
+
+		moderationHandler.Reports.(*mocks.ReportRepo).
+			On("Add", report.Report{Target: testCase.target, ReporterID: usr.UserID, Reason: testCase.reason}).
+			Return(testCase.reportCount, testCase.reportError)
+
+		if testCase.target.Kind == report.KindComment {
+			moderationHandler.CommentReport(w, r.WithContext(ctx))
+		} else {
+			moderationHandler.PostReport(w, r.WithContext(ctx))
+		}
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		require.Equal(t, string(body), testCase.response)
+		if testCase.hidden {
+			moderationHandler.PostRepo.(*mocks.PostRepo).AssertCalled(t, "Update",
+				mock.MatchedBy(func(pst post.Post) bool {
+					if testCase.target.Kind == report.KindPost {
+						return pst.Hidden
+					}
+					return !pst.Hidden && pst.Comments[0].Hidden
+				}),
+			)
+		} else {
+			moderationHandler.PostRepo.(*mocks.PostRepo).AssertNotCalled(t, "Update", mock.Anything)
+		}
+	}
+}
+
+func TestModerationQueue(t *testing.T) {
+	type testCase struct {
+		role      string
+		roleError error
+
+		queue      []report.Item
+		queueError error
+
+		statusCode int
+		response   string
+	}
+
+	usr := user.User{Username: "moder", UserID: 1}
+	author := user.User{Username: "author", UserID: 2}
+	queue := func() []report.Item {
+		return []report.Item{
+			{
+				Target:       report.Target{Kind: report.KindComment, PostID: 1, CommentID: 3},
+				Reports:      4,
+				Reasons:      []string{"rude"},
+				LastReported: "2022-05-02T18:32:00Z",
+			},
+			{
+				Target:       report.Target{Kind: report.KindPost, PostID: 2},
+				Reports:      1,
+				Reasons:      []string{"spam"},
+				LastReported: "2022-05-02T18:31:00Z",
+			},
+		}
+	}
+	testCases := []testCase{
+		{
+			role:       user.RoleModerator,
+			queue:      queue(),
+			statusCode: http.StatusOK,
+			response:   `[{"kind":"comment","postId":"1","commentId":"3","reports":4,"reasons":["rude"],"lastReported":"2022-05-02T18:32:00Z","author":{"username":"author","id":"2"},"text":"rude words","hidden":true},{"kind":"post","postId":"2","reports":1,"reasons":["spam"],"lastReported":"2022-05-02T18:31:00Z","hidden":false,"missing":true}]`,
+		},
+		{
+			role:       user.RoleUser,
+			statusCode: http.StatusForbidden,
+			response:   "{\"message\":\"moderator rights required\"}\n",
+		},
+		{
+			roleError:  fmt.Errorf("test error"),
+			statusCode: http.StatusInternalServerError,
+			response:   "",
+		},
+		{
+			role:       user.RoleAdmin,
+			queueError: fmt.Errorf("test error"),
+			statusCode: http.StatusInternalServerError,
+			response:   "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		moderationHandler := setupModeration()
+		defer moderationHandler.Logger.Sync()
+
+		r := httptest.NewRequest("GET", "/api/moderation/queue", nil)
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, usr)
+		w := httptest.NewRecorder()
+
+		moderationHandler.UserRepo.(*mocks.UserRepo).On("Role", usr.UserID).Return(testCase.role, testCase.roleError)
+		moderationHandler.Reports.(*mocks.ReportRepo).
+			On("Queue", int64(0), int64(defaultPageLimit)).
+			Return(testCase.queue, testCase.queueError)
+		moderationHandler.PostRepo.(*mocks.PostRepo).On("Find", uint64(1)).Return(true)
+		moderationHandler.PostRepo.(*mocks.PostRepo).On("Find", uint64(2)).Return(false)
+		moderationHandler.PostRepo.(*mocks.PostRepo).On("Get", uint64(1)).Return(post.Post{
+			Comments: []comment.Comment{{ID: 3, Author: author, Body: "rude words", Hidden: true}},
+		}, nil)
+
+		moderationHandler.Queue(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		require.Equal(t, string(body), testCase.response)
+	}
+}
+
+func TestReportResolve(t *testing.T) {
+	type testCase struct {
+		remove    bool
+		valueVars map[string]string
+
+		postRepoLock bool
+		resolveError error
+
+		statusCode int
+		response   string
+	}
+
+	usr := user.User{Username: "moder", UserID: 1}
+	testCases := []testCase{
+		{
+			remove:       false,
+			valueVars:    map[string]string{"post_id": "1"},
+			postRepoLock: true,
+			statusCode:   http.StatusOK,
+			response:     "{\"message\":\"success\"}\n",
+		},
+		{
+			remove:       false,
+			valueVars:    map[string]string{"post_id": "1", "comment_id": "3"},
+			postRepoLock: true,
+			statusCode:   http.StatusOK,
+			response:     "{\"message\":\"success\"}\n",
+		},
+		{
+			remove:       true,
+			valueVars:    map[string]string{"post_id": "1"},
+			postRepoLock: true,
+			statusCode:   http.StatusOK,
+			response:     "{\"message\":\"success\"}\n",
+		},
+		{
+			remove:       true,
+			valueVars:    map[string]string{"post_id": "1", "comment_id": "3"},
+			postRepoLock: true,
+			statusCode:   http.StatusOK,
+			response:     "{\"message\":\"success\"}\n",
+		},
+		{
+			remove:       true,
+			valueVars:    map[string]string{"post_id": "1", "comment_id": "4"},
+			postRepoLock: true,
+			statusCode:   http.StatusNotFound,
+			response:     "{\"message\":\"comment not found\"}\n",
+		},
+		{
+			remove:       false,
+			valueVars:    map[string]string{"post_id": "1"},
+			postRepoLock: false,
+			statusCode:   http.StatusNotFound,
+			response:     "{\"message\":\"post not found\"}\n",
+		},
+		{
+			remove:       false,
+			valueVars:    map[string]string{"post_id": "1"},
+			postRepoLock: true,
+			resolveError: fmt.Errorf("test error"),
+			statusCode:   http.StatusInternalServerError,
+			response:     "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		moderationHandler := setupModeration()
+		defer moderationHandler.Logger.Sync()
+
+		r := httptest.NewRequest("POST", "/api/moderation/post/1/dismiss", nil)
+		r = mux.SetURLVars(r, testCase.valueVars)
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, usr)
+		w := httptest.NewRecorder()
+
+		moderationHandler.UserRepo.(*mocks.UserRepo).On("Role", usr.UserID).Return(user.RoleModerator, nil)
+		moderationHandler.PostRepo.(*mocks.PostRepo).On("Lock", uint64(1)).Return(testCase.postRepoLock)
+		moderationHandler.PostRepo.(*mocks.PostRepo).On("Unlock", uint64(1)).Return(true)
+		moderationHandler.PostRepo.(*mocks.PostRepo).On("Get", uint64(1)).Return(post.Post{
+			Hidden:   true,
+			Comments: []comment.Comment{{ID: 3, Hidden: true}},
+		}, nil)
+		moderationHandler.PostRepo.(*mocks.PostRepo).On("Update", mock.AnythingOfType("post.Post")).Return(nil)
+		moderationHandler.PostRepo.(*mocks.PostRepo).On("MarkCrossposts", uint64(1), true).Return(nil)
+		moderationHandler.Reports.(*mocks.ReportRepo).On("Resolve", mock.AnythingOfType("report.Target")).Return(testCase.resolveError)
+		moderationHandler.Reports.(*mocks.ReportRepo).On("ResolvePost", uint64(1)).Return(testCase.resolveError)
+
+		if testCase.remove {
+			moderationHandler.ReportRemove(w, r.WithContext(ctx))
+		} else {
+			moderationHandler.ReportDismiss(w, r.WithContext(ctx))
+		}
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		require.Equal(t, string(body), testCase.response)
+		if testCase.remove && testCase.statusCode == http.StatusOK {
+			moderationHandler.PostRepo.(*mocks.PostRepo).AssertCalled(t, "Update",
+				mock.MatchedBy(func(pst post.Post) bool {
+					if _, ok := testCase.valueVars["comment_id"]; ok {
+						return pst.DeletedAt == nil && pst.Comments[0].DeletedAt != nil &&
+							pst.Comments[0].DeletedBy.Username == usr.Username
+					}
+					return pst.DeletedAt != nil && pst.DeletedBy.Username == usr.Username
+				}),
+			)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"redditclone/pkg/errors"
+	"redditclone/pkg/frontendMessages"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/token"
+	"redditclone/pkg/user"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *NotificationHandler) Inbox(w http.ResponseWriter, r *http.Request) {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	page, limit, errs := getPagination(r)
+	unreadOnly := false
+	switch unread := r.URL.Query().Get("unread"); unread {
+	case "", "false":
+	case "true":
+		unreadOnly = true
+	default:
+		errs = append(errs, frontendMessages.ErrorMessage{
+			Location: "query",
+			Param:    "unread",
+			Value:    unread,
+			Message:  `must be "true" or "false"`,
+		})
+	}
+	if len(errs) != 0 {
+		frontendMessages.SendError(w, errs, http.StatusUnprocessableEntity, h.Logger, "Inbox")
+		return
+	}
+	inbox, errList := h.Notifications.List(usr.UserID, unreadOnly, page, limit)
+	if errList != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("Inbox: %w", errList),
+		)
+		return
+	}
+	res, errMarshal := json.Marshal(inbox)
+	if errMarshal != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("Inbox: %w", errors.ErrMarshal{Err: errMarshal}),
+		)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+func (h *NotificationHandler) NotificationRead(w http.ResponseWriter, r *http.Request) {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	vars := mux.Vars(r)
+	id, errGet := token.GetMapItemString(vars, "notification_id")
+	if errGet != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("NotificationRead: %w", errors.ErrRequest{Err: errGet}),
+		)
+		return
+	}
+	found, errRead := h.Notifications.MarkRead(usr.UserID, id)
+	if errRead != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("NotificationRead: %w", errRead),
+		)
+		return
+	}
+	if !found {
+		frontendMessages.SendMessage(w,
+			"notification not found",
+			http.StatusNotFound,
+			h.Logger, "NotificationRead",
+		)
+		return
+	}
+	frontendMessages.SendMessage(w,
+		"success",
+		http.StatusOK,
+		h.Logger, "NotificationRead",
+	)
+}
+
+func (h *NotificationHandler) NotificationsReadAll(w http.ResponseWriter, r *http.Request) {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	errRead := h.Notifications.MarkAllRead(usr.UserID)
+	if errRead != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("NotificationsReadAll: %w", errRead),
+		)
+		return
+	}
+	frontendMessages.SendMessage(w,
+		"success",
+		http.StatusOK,
+		h.Logger, "NotificationsReadAll",
+	)
+}
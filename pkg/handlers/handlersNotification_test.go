@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"redditclone/pkg/database/mocks"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/notification"
+	"redditclone/pkg/user"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestInbox(t *testing.T) {
+	type testCase struct {
+		contextKey middleware.Key
+		query      string
+
+		unreadOnly bool
+		page       int64
+		limit      int64
+
+		listRes   notification.Inbox
+		listError error
+
+		statusCode int
+		response   string
+	}
+
+	usr := user.User{Username: "test", UserID: 1}
+	objectID, _ := primitive.ObjectIDFromHex("62700f0c5f1c3e2a9c0a0b01")
+	inbox := notification.Inbox{
+		Unread: 1,
+		Notifications: []notification.Notification{{
+			ID:        objectID,
+			UserID:    1,
+			Kind:      notification.KindPostReply,
+			From:      user.User{Username: "from", UserID: 2},
+			PostID:    3,
+			CommentID: 4,
+			Text:      "hi",
+			Created:   time.Date(2022, 5, 2, 18, 32, 0, 0, time.UTC),
+		}},
+	}
+	testCases := []testCase{
+		{
+			contextKey: middleware.UserContextKey,
+			limit:      defaultPageLimit,
+			listRes:    inbox,
+			statusCode: http.StatusOK,
+			response:   `{"unread":1,"notifications":[{"id":"62700f0c5f1c3e2a9c0a0b01","kind":"post_reply","from":{"username":"from","id":"2"},"postId":"3","commentId":"4","text":"hi","read":false,"created":"2022-05-02T18:32:00Z"}]}`,
+		},
+		{
+			contextKey: middleware.UserContextKey,
+			query:      "?unread=true&page=1&limit=10",
+			unreadOnly: true,
+			page:       1,
+			limit:      10,
+			listRes:    notification.Inbox{Notifications: []notification.Notification{}},
+			statusCode: http.StatusOK,
+			response:   `{"unread":0,"notifications":[]}`,
+		},
+		{
+			contextKey: middleware.UserContextKey,
+			query:      "?unread=yes",
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"query\",\"param\":\"unread\",\"value\":\"yes\",\"msg\":\"must be \\\"true\\\" or \\\"false\\\"\"}]}\n",
+		},
+		{
+			contextKey: middleware.UserContextKey,
+			limit:      defaultPageLimit,
+			listError:  fmt.Errorf("test error"),
+			statusCode: http.StatusInternalServerError,
+			response:   "",
+		},
+		{
+			contextKey: middleware.AuthtorizationContextKey,
+			statusCode: http.StatusInternalServerError,
+			response:   "Internal server error\n",
+		},
+	}
+
+	for _, testCase := range testCases {
+		notificationHandler := setupNotification()
+		defer notificationHandler.Logger.Sync()
+
+		r := httptest.NewRequest("GET", "/api/notifications"+testCase.query, nil)
+		ctx := context.WithValue(r.Context(), testCase.contextKey, usr)
+		w := httptest.NewRecorder()
+
+		notificationHandler.Notifications.(*mocks.NotificationRepo).
+			On("List", usr.UserID, testCase.unreadOnly, testCase.page, testCase.limit).
+			Return(testCase.listRes, testCase.listError)
+
+		notificationHandler.Inbox(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		require.Equal(t, string(body), testCase.response)
+	}
+}
+
+func TestNotificationRead(t *testing.T) {
+	type testCase struct {
+		contextKey middleware.Key
+		valueVars  map[string]string
+
+		markFound bool
+		markError error
+
+		statusCode int
+		response   string
+	}
+
+	usr := user.User{Username: "test", UserID: 1}
+	id := "62700f0c5f1c3e2a9c0a0b01"
+	testCases := []testCase{
+		{
+			contextKey: middleware.UserContextKey,
+			valueVars:  map[string]string{"notification_id": id},
+			markFound:  true,
+			statusCode: http.StatusOK,
+			response:   "{\"message\":\"success\"}\n",
+		},
+		{
+			contextKey: middleware.UserContextKey,
+			valueVars:  map[string]string{"notification_id": id},
+			markFound:  false,
+			statusCode: http.StatusNotFound,
+			response:   "{\"message\":\"notification not found\"}\n",
+		},
+		{
+			contextKey: middleware.UserContextKey,
+			valueVars:  map[string]string{"notification_id": id},
+			markError:  fmt.Errorf("test error"),
+			statusCode: http.StatusInternalServerError,
+			response:   "",
+		},
+		{
+			contextKey: middleware.UserContextKey,
+			valueVars:  map[string]string{"wrong vars": id},
+			statusCode: http.StatusInternalServerError,
+			response:   "",
+		},
+		{
+			contextKey: middleware.AuthtorizationContextKey,
+			valueVars:  map[string]string{"notification_id": id},
+			statusCode: http.StatusInternalServerError,
+			response:   "Internal server error\n",
+		},
+	}
+
+	for _, testCase := range testCases {
+		notificationHandler := setupNotification()
+		defer notificationHandler.Logger.Sync()
+
+		r := httptest.NewRequest("POST", "/api/notifications/"+id+"/read", nil)
+		r = mux.SetURLVars(r, testCase.valueVars)
+		ctx := context.WithValue(r.Context(), testCase.contextKey, usr)
+		w := httptest.NewRecorder()
+
+		notificationHandler.Notifications.(*mocks.NotificationRepo).
+			On("MarkRead", usr.UserID, id).
+			Return(testCase.markFound, testCase.markError)
+
+		notificationHandler.NotificationRead(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		require.Equal(t, string(body), testCase.response)
+	}
+}
+
+func TestNotificationsReadAll(t *testing.T) {
+	type testCase struct {
+		contextKey middleware.Key
+		markError  error
+
+		statusCode int
+		response   string
+	}
+
+	usr := user.User{Username: "test", UserID: 1}
+	testCases := []testCase{
+		{
+			contextKey: middleware.UserContextKey,
+			statusCode: http.StatusOK,
+			response:   "{\"message\":\"success\"}\n",
+		},
+		{
+			contextKey: middleware.UserContextKey,
+			markError:  fmt.Errorf("test error"),
+			statusCode: http.StatusInternalServerError,
+			response:   "",
+		},
+		{
+			contextKey: middleware.AuthtorizationContextKey,
+			statusCode: http.StatusInternalServerError,
+			response:   "Internal server error\n",
+		},
+	}
+
+	for _, testCase := range testCases {
+		notificationHandler := setupNotification()
+		defer notificationHandler.Logger.Sync()
+
+		r := httptest.NewRequest("POST", "/api/notifications/read", nil)
+		ctx := context.WithValue(r.Context(), testCase.contextKey, usr)
+		w := httptest.NewRecorder()
+
+		notificationHandler.Notifications.(*mocks.NotificationRepo).
+			On("MarkAllRead", usr.UserID).
+			Return(testCase.markError)
+
+		notificationHandler.NotificationsReadAll(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		require.Equal(t, string(body), testCase.response)
+	}
+}
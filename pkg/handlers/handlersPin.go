@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"redditclone/pkg/errors"
+	"redditclone/pkg/frontendMessages"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/post"
+	"redditclone/pkg/token"
+	"redditclone/pkg/user"
+
+	"github.com/gorilla/mux"
+)
+
+// setFlag changes a flag of the post chosen by set, only the author and
+// moderators may do it. Flags with staffOnly set are kept to moderators and
+// owners of the post category.
+func (h *PostHandler) setFlag(w http.ResponseWriter, r *http.Request, from string, staffOnly bool, set func(pst *post.Post)) error {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+	postID, errGet := token.GetMapItemUint64(mux.Vars(r), "post_id")
+	if errGet != nil {
+		return errors.ErrRequest{Err: errGet}
+	}
+	if !h.PostRepo.Lock(postID) {
+		frontendMessages.SendMessage(w,
+			"post not found",
+			http.StatusNotFound,
+			h.Logger, from,
+		)
+		return nil
+	}
+	pst, errGet := h.PostRepo.Get(postID)
+	if errGet != nil {
+		h.PostRepo.Unlock(postID)
+		return errGet
+	}
+	if staffOnly || !isAuthor(usr, pst.Author) {
+		allowed, errRole := canManageCategory(h.CategoryRepo, h.UserRepo, pst.Category, usr)
+		if errRole != nil {
+			h.PostRepo.Unlock(postID)
+			return fmt.Errorf("can`t get role: %w", errRole)
+		}
+		if !allowed {
+			h.PostRepo.Unlock(postID)
+			frontendMessages.SendMessage(w,
+				"you can't change this post",
+				http.StatusForbidden,
+				h.Logger, from,
+			)
+			return nil
+		}
+	}
+	set(&pst)
+	errUpdate := h.PostRepo.Update(pst)
+	if errUpdate != nil {
+		h.PostRepo.Unlock(postID)
+		return errUpdate
+	}
+	if !h.PostRepo.Unlock(postID) {
+		return fmt.Errorf("can`t unlock post")
+	}
+	pst.ForViewer(&usr)
+	res, err := json.Marshal(pst)
+	if err != nil {
+		return errors.ErrMarshal{Err: err}
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+	return nil
+}
+
+func (h *PostHandler) PostPin(w http.ResponseWriter, r *http.Request) {
+	err := h.setFlag(w, r, "postPin", true, func(pst *post.Post) { pst.Pinned = true })
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("postPin: %w", err),
+		)
+	}
+}
+
+func (h *PostHandler) PostUnpin(w http.ResponseWriter, r *http.Request) {
+	err := h.setFlag(w, r, "postUnpin", true, func(pst *post.Post) { pst.Pinned = false })
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("postUnpin: %w", err),
+		)
+	}
+}
+
+func (h *PostHandler) PostLock(w http.ResponseWriter, r *http.Request) {
+	err := h.setFlag(w, r, "postLock", false, func(pst *post.Post) { pst.Locked = true })
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("postLock: %w", err),
+		)
+	}
+}
+
+func (h *PostHandler) PostUnlock(w http.ResponseWriter, r *http.Request) {
+	err := h.setFlag(w, r, "postUnlock", false, func(pst *post.Post) { pst.Locked = false })
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("postUnlock: %w", err),
+		)
+	}
+}
+
+func (h *PostHandler) PostMarkNSFW(w http.ResponseWriter, r *http.Request) {
+	err := h.setFlag(w, r, "postMarkNSFW", false, func(pst *post.Post) { pst.NSFW = true })
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("postMarkNSFW: %w", err),
+		)
+	}
+}
+
+func (h *PostHandler) PostUnmarkNSFW(w http.ResponseWriter, r *http.Request) {
+	err := h.setFlag(w, r, "postUnmarkNSFW", false, func(pst *post.Post) { pst.NSFW = false })
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("postUnmarkNSFW: %w", err),
+		)
+	}
+}
+
+func (h *PostHandler) PostSpoiler(w http.ResponseWriter, r *http.Request) {
+	err := h.setFlag(w, r, "postSpoiler", false, func(pst *post.Post) { pst.Spoiler = true })
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("postSpoiler: %w", err),
+		)
+	}
+}
+
+func (h *PostHandler) PostUnspoiler(w http.ResponseWriter, r *http.Request) {
+	err := h.setFlag(w, r, "postUnspoiler", false, func(pst *post.Post) { pst.Spoiler = false })
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("postUnspoiler: %w", err),
+		)
+	}
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"redditclone/pkg/comment"
+	"redditclone/pkg/database/mocks"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/post"
+	"redditclone/pkg/user"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostFlags(t *testing.T) {
+	type testCase struct {
+		handler func(h *PostHandler) http.HandlerFunc
+		viewer  user.User
+		owner   bool
+		role    string
+		roleErr error
+
+		postRepoLockStatus  bool
+		postRepoGet         post.Post
+		postRepoUpdateError error
+
+		statusCode int
+		response   string
+		updated    *post.Post
+	}
+
+	author := user.User{Username: "author", UserID: 1}
+	other := user.User{Username: "other", UserID: 2}
+
+	testCases := []testCase{
+		{
+			handler:            func(h *PostHandler) http.HandlerFunc { return h.PostPin },
+			viewer:             other,
+			owner:              true,
+			postRepoLockStatus: true,
+			postRepoGet:        post.Post{ID: 3, Author: author, Category: "music"},
+			statusCode:         http.StatusOK,
+			updated:            &post.Post{ID: 3, Author: author, Category: "music", Pinned: true},
+		},
+		{
+			handler:            func(h *PostHandler) http.HandlerFunc { return h.PostPin },
+			viewer:             author,
+			role:               user.RoleUser,
+			postRepoLockStatus: true,
+			postRepoGet:        post.Post{ID: 3, Author: author, Category: "music"},
+			statusCode:         http.StatusForbidden,
+			response:           "{\"message\":\"you can't change this post\"}\n",
+		},
+		{
+			handler:            func(h *PostHandler) http.HandlerFunc { return h.PostUnpin },
+			viewer:             other,
+			role:               user.RoleModerator,
+			postRepoLockStatus: true,
+			postRepoGet:        post.Post{ID: 3, Author: author, Pinned: true},
+			statusCode:         http.StatusOK,
+			updated:            &post.Post{ID: 3, Author: author},
+		},
+		{
+			handler:            func(h *PostHandler) http.HandlerFunc { return h.PostLock },
+			viewer:             other,
+			role:               user.RoleAdmin,
+			postRepoLockStatus: true,
+			postRepoGet:        post.Post{ID: 3, Author: author},
+			statusCode:         http.StatusOK,
+			updated:            &post.Post{ID: 3, Author: author, Locked: true},
+		},
+		{
+			handler:            func(h *PostHandler) http.HandlerFunc { return h.PostUnlock },
+			viewer:             author,
+			postRepoLockStatus: true,
+			postRepoGet:        post.Post{ID: 3, Author: author, Locked: true},
+			statusCode:         http.StatusOK,
+			updated:            &post.Post{ID: 3, Author: author},
+		},
+		{
+			handler:            func(h *PostHandler) http.HandlerFunc { return h.PostMarkNSFW },
+			viewer:             author,
+			postRepoLockStatus: true,
+			postRepoGet:        post.Post{ID: 3, Author: author},
+			statusCode:         http.StatusOK,
+			updated:            &post.Post{ID: 3, Author: author, NSFW: true},
+		},
+		{
+			handler:            func(h *PostHandler) http.HandlerFunc { return h.PostUnspoiler },
+			viewer:             other,
+			role:               user.RoleModerator,
+			postRepoLockStatus: true,
+			postRepoGet:        post.Post{ID: 3, Author: author, Spoiler: true},
+			statusCode:         http.StatusOK,
+			updated:            &post.Post{ID: 3, Author: author},
+		},
+		{
+			handler:            func(h *PostHandler) http.HandlerFunc { return h.PostLock },
+			viewer:             other,
+			role:               user.RoleUser,
+			postRepoLockStatus: true,
+			postRepoGet:        post.Post{ID: 3, Author: author},
+			statusCode:         http.StatusForbidden,
+			response:           "{\"message\":\"you can't change this post\"}\n",
+		},
+		{
+			handler:            func(h *PostHandler) http.HandlerFunc { return h.PostPin },
+			viewer:             other,
+			roleErr:            fmt.Errorf("test error"),
+			postRepoLockStatus: true,
+			postRepoGet:        post.Post{ID: 3, Author: author},
+			statusCode:         http.StatusInternalServerError,
+			response:           "",
+		},
+		{
+			handler:            func(h *PostHandler) http.HandlerFunc { return h.PostPin },
+			viewer:             author,
+			postRepoLockStatus: false,
+			statusCode:         http.StatusNotFound,
+			response:           "{\"message\":\"post not found\"}\n",
+		},
+		{
+			handler:             func(h *PostHandler) http.HandlerFunc { return h.PostPin },
+			viewer:              author,
+			owner:               true,
+			postRepoLockStatus:  true,
+			postRepoGet:         post.Post{ID: 3, Author: author},
+			postRepoUpdateError: fmt.Errorf("test error"),
+			statusCode:          http.StatusInternalServerError,
+			response:            "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		postHandler := setupPost()
+		defer postHandler.Logger.Sync()
+
+		r := httptest.NewRequest("POST", "/api/post/3/pin", nil)
+		r = mux.SetURLVars(r, map[string]string{"post_id": "3"})
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, testCase.viewer)
+		w := httptest.NewRecorder()
+
+		postHandler.PostRepo.(*mocks.PostRepo).On("Lock", uint64(3)).Return(testCase.postRepoLockStatus)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Get", uint64(3)).Return(testCase.postRepoGet, nil)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Update", mock.AnythingOfType("post.Post")).Return(testCase.postRepoUpdateError)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Unlock", uint64(3)).Return(true)
+		postHandler.UserRepo.(*mocks.UserRepo).On("Role", testCase.viewer.UserID).Return(testCase.role, testCase.roleErr)
+		postHandler.CategoryRepo.(*mocks.CategoryRepo).On("IsOwner", testCase.postRepoGet.Category, testCase.viewer).Return(testCase.owner, nil)
+
+		testCase.handler(postHandler)(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		if testCase.updated == nil {
+			require.Equal(t, string(body), testCase.response)
+			continue
+		}
+		postHandler.PostRepo.(*mocks.PostRepo).AssertCalled(t, "Update", *testCase.updated)
+	}
+}
+
+func TestClosedPost(t *testing.T) {
+	type testCase struct {
+		handler func(h *PostHandler) http.HandlerFunc
+		vars    map[string]string
+		body    string
+	}
+
+	testCases := []testCase{
+		{
+			handler: func(h *PostHandler) http.HandlerFunc { return h.PostRatingUp },
+			vars:    map[string]string{"post_id": "3"},
+		},
+		{
+			handler: func(h *PostHandler) http.HandlerFunc { return h.CommentRatingDown },
+			vars:    map[string]string{"post_id": "3", "comment_id": "0"},
+		},
+		{
+			handler: func(h *PostHandler) http.HandlerFunc { return h.CommentAdd },
+			vars:    map[string]string{"post_id": "3"},
+			body:    `{"comment":"too late"}`,
+		},
+	}
+
+	closed := []struct {
+		pst     post.Post
+		message string
+	}{
+		{post.Post{ID: 3, Locked: true, Comments: []comment.Comment{{ID: 0}}}, "this post is locked"},
+		{post.Post{ID: 3, Archived: true, Locked: true, Comments: []comment.Comment{{ID: 0}}}, "this post is archived"},
+	}
+
+	for _, testCase := range testCases {
+		for _, closedPost := range closed {
+			postHandler := setupPost()
+			defer postHandler.Logger.Sync()
+			setupMentionUsers(postHandler)
+
+			r := httptest.NewRequest("POST", "/api/post/3", strings.NewReader(testCase.body))
+			r = mux.SetURLVars(r, testCase.vars)
+			ctx := context.WithValue(r.Context(), middleware.UserContextKey, user.User{Username: "test", UserID: 0})
+			w := httptest.NewRecorder()
+
+			postHandler.PostRepo.(*mocks.PostRepo).On("Lock", uint64(3)).Return(true)
+			postHandler.PostRepo.(*mocks.PostRepo).On("Get", uint64(3)).Return(closedPost.pst, nil)
+			postHandler.PostRepo.(*mocks.PostRepo).On("Unlock", uint64(3)).Return(true)
+
+			testCase.handler(postHandler)(w, r.WithContext(ctx))
+
+			resp := w.Result()
+
+			body, errRead := ioutil.ReadAll(resp.Body)
+
+			require.NoError(t, errRead)
+			require.Equal(t, resp.StatusCode, http.StatusForbidden)
+			require.Equal(t, string(body), "{\"message\":\""+closedPost.message+"\"}\n")
+			postHandler.PostRepo.(*mocks.PostRepo).AssertNotCalled(t, "Update", mock.Anything)
+		}
+	}
+}
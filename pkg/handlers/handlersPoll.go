@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"redditclone/pkg/errors"
+	"redditclone/pkg/frontendMessages"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/post"
+	"redditclone/pkg/token"
+	"redditclone/pkg/user"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// preparePoll checks the poll of a new post. It returns false if the
+// response was already sent.
+func (h *PostHandler) preparePoll(w http.ResponseWriter, pst *post.Post, from string) bool {
+	if pst.Type != post.TypePoll {
+		pst.Poll = nil
+		return true
+	}
+	problem := "is required"
+	if pst.Poll != nil {
+		problem = pst.Poll.Check(time.Now())
+	}
+	if problem == "" {
+		return true
+	}
+	frontendMessages.SendError(w, []frontendMessages.ErrorMessage{{
+		Location: "body",
+		Param:    "poll",
+		Message:  problem,
+	}}, http.StatusUnprocessableEntity, h.Logger, from)
+	return false
+}
+
+func (h *PostHandler) pollVote(w http.ResponseWriter, r *http.Request) error {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+	postID, errGet := token.GetMapItemUint64(mux.Vars(r), "post_id")
+	if errGet != nil {
+		return errors.ErrRequest{Err: errGet}
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	readVote := struct {
+		Option *int `json:"option"`
+	}{}
+	if errUnmarshal := json.Unmarshal(body, &readVote); errUnmarshal != nil {
+		return errors.ErrUnmarshalRequest{Err: errUnmarshal}
+	}
+	if !h.PostRepo.Lock(postID) {
+		frontendMessages.SendMessage(w,
+			"post not found",
+			http.StatusNotFound,
+			h.Logger, "pollVote",
+		)
+		return nil
+	}
+	pst, errGet := h.PostRepo.Get(postID)
+	if errGet != nil {
+		h.PostRepo.Unlock(postID)
+		return errGet
+	}
+	if h.closedPost(w, &pst, usr, "pollVote") {
+		h.PostRepo.Unlock(postID)
+		return nil
+	}
+	if pst.Poll == nil {
+		h.PostRepo.Unlock(postID)
+		frontendMessages.SendMessage(w,
+			"this post is not a poll",
+			http.StatusNotFound,
+			h.Logger, "pollVote",
+		)
+		return nil
+	}
+	if pst.Poll.IsClosed(time.Now()) {
+		h.PostRepo.Unlock(postID)
+		frontendMessages.SendMessage(w,
+			"poll is closed",
+			http.StatusForbidden,
+			h.Logger, "pollVote",
+		)
+		return nil
+	}
+	if readVote.Option == nil || *readVote.Option < 0 || *readVote.Option >= len(pst.Poll.Options) {
+		h.PostRepo.Unlock(postID)
+		frontendMessages.SendError(w, []frontendMessages.ErrorMessage{{
+			Location: "body",
+			Param:    "option",
+			Value:    readVote.Option,
+			Message:  "is invalid",
+		}}, http.StatusUnprocessableEntity, h.Logger, "pollVote")
+		return nil
+	}
+	pst.Poll.Vote(usr.UserID, *readVote.Option)
+	errUpdate := h.PostRepo.Update(pst)
+	if errUpdate != nil {
+		h.PostRepo.Unlock(postID)
+		return errUpdate
+	}
+	if !h.PostRepo.Unlock(postID) {
+		return fmt.Errorf("can`t unlock post")
+	}
+	pst.ForViewer(&usr)
+	res, err := json.Marshal(pst)
+	if err != nil {
+		return errors.ErrMarshal{Err: err}
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+	return nil
+}
+
+func (h *PostHandler) PollVote(w http.ResponseWriter, r *http.Request) {
+	err := h.pollVote(w, r)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("pollVote: %w", err),
+		)
+	}
+}
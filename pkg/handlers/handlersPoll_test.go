@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"redditclone/pkg/database/mocks"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/poll"
+	"redditclone/pkg/post"
+	"redditclone/pkg/user"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPollVote(t *testing.T) {
+	type testCase struct {
+		body string
+
+		postRepoLockStatus  bool
+		postRepoGet         post.Post
+		postRepoUpdateError error
+
+		statusCode int
+		response   string
+		poll       *poll.Poll
+	}
+
+	author := user.User{Username: "author", UserID: 2}
+	closed := time.Now().Add(-time.Hour)
+	options := func() []poll.Option {
+		return []poll.Option{{Text: "yes"}, {Text: "no"}}
+	}
+	choice := func(option int) *int {
+		return &option
+	}
+
+	testCases := []testCase{
+		{
+			body:               `{"option":1}`,
+			postRepoLockStatus: true,
+			postRepoGet: post.Post{Author: author, Type: post.TypePoll, Poll: &poll.Poll{
+				Options: options(),
+				Voters:  []poll.Vote{{UserID: 3, Option: 1}, {UserID: 4, Option: 0}},
+			}},
+			statusCode: http.StatusOK,
+			poll: &poll.Poll{
+				Options:    []poll.Option{{Text: "yes", Votes: 1, Percentage: 33}, {Text: "no", Votes: 2, Percentage: 67}},
+				TotalVotes: 3,
+				MyChoice:   choice(1),
+			},
+		},
+		{
+			body:               `{"option":0}`,
+			postRepoLockStatus: true,
+			postRepoGet: post.Post{Author: author, Type: post.TypePoll, Poll: &poll.Poll{
+				Options: options(),
+				Voters:  []poll.Vote{{UserID: 0, Option: 1}},
+			}},
+			statusCode: http.StatusOK,
+			poll: &poll.Poll{
+				Options:    []poll.Option{{Text: "yes", Votes: 1, Percentage: 100}, {Text: "no"}},
+				TotalVotes: 1,
+				MyChoice:   choice(0),
+			},
+		},
+		{
+			body:               `{"option":0}`,
+			postRepoLockStatus: true,
+			postRepoGet: post.Post{Author: author, Type: post.TypePoll, Poll: &poll.Poll{
+				Options:     options(),
+				HideResults: true,
+			}},
+			statusCode: http.StatusOK,
+			poll: &poll.Poll{
+				Options:     []poll.Option{{Text: "yes", Votes: 1, Percentage: 100}, {Text: "no"}},
+				HideResults: true,
+				TotalVotes:  1,
+				MyChoice:    choice(0),
+			},
+		},
+		{
+			body:               `{"option":0}`,
+			postRepoLockStatus: true,
+			postRepoGet: post.Post{Author: author, Type: post.TypePoll, Poll: &poll.Poll{
+				Options:  options(),
+				ClosesAt: &closed,
+			}},
+			statusCode: http.StatusForbidden,
+			response:   "{\"message\":\"poll is closed\"}\n",
+		},
+		{
+			body:               `{"option":2}`,
+			postRepoLockStatus: true,
+			postRepoGet:        post.Post{Author: author, Type: post.TypePoll, Poll: &poll.Poll{Options: options()}},
+			statusCode:         http.StatusUnprocessableEntity,
+			response:           "{\"errors\":[{\"location\":\"body\",\"param\":\"option\",\"value\":2,\"msg\":\"is invalid\"}]}\n",
+		},
+		{
+			body:               `{}`,
+			postRepoLockStatus: true,
+			postRepoGet:        post.Post{Author: author, Type: post.TypePoll, Poll: &poll.Poll{Options: options()}},
+			statusCode:         http.StatusUnprocessableEntity,
+			response:           "{\"errors\":[{\"location\":\"body\",\"param\":\"option\",\"value\":null,\"msg\":\"is invalid\"}]}\n",
+		},
+		{
+			body:               `{"option":0}`,
+			postRepoLockStatus: true,
+			postRepoGet:        post.Post{Author: author, Type: post.TypeText},
+			statusCode:         http.StatusNotFound,
+			response:           "{\"message\":\"this post is not a poll\"}\n",
+		},
+		{
+			body:               `{"option":0}`,
+			postRepoLockStatus: false,
+			statusCode:         http.StatusNotFound,
+			response:           "{\"message\":\"post not found\"}\n",
+		},
+		{
+			body:       `{"option":`,
+			statusCode: http.StatusInternalServerError,
+			response:   "",
+		},
+		{
+			body:                `{"option":0}`,
+			postRepoLockStatus:  true,
+			postRepoGet:         post.Post{Author: author, Type: post.TypePoll, Poll: &poll.Poll{Options: options()}},
+			postRepoUpdateError: fmt.Errorf("test error"),
+			statusCode:          http.StatusInternalServerError,
+			response:            "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		postHandler := setupPost()
+		defer postHandler.Logger.Sync()
+
+		r := httptest.NewRequest("POST", "/api/post/0/poll", strings.NewReader(testCase.body))
+		r = mux.SetURLVars(r, map[string]string{"post_id": "0"})
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, user.User{Username: "test", UserID: 0})
+		w := httptest.NewRecorder()
+
+		postHandler.PostRepo.(*mocks.PostRepo).
+			On("Lock", uint64(0)).
+			Return(testCase.postRepoLockStatus)
+
+		postHandler.PostRepo.(*mocks.PostRepo).
+			On("Get", uint64(0)).
+			Return(testCase.postRepoGet, nil)
+
+		postHandler.PostRepo.(*mocks.PostRepo).
+			On("Update", mock.AnythingOfType("post.Post")).
+			Return(testCase.postRepoUpdateError)
+
+		postHandler.PostRepo.(*mocks.PostRepo).
+			On("Unlock", uint64(0)).
+			Return(true)
+
+		postHandler.PollVote(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		if testCase.poll == nil {
+			require.Equal(t, string(body), testCase.response)
+			continue
+		}
+		var pst post.Post
+		require.NoError(t, json.Unmarshal(body, &pst))
+		require.Equal(t, pst.Poll, testCase.poll)
+	}
+}
+
+func TestPostAddPoll(t *testing.T) {
+	type testCase struct {
+		body string
+
+		statusCode int
+		response   string
+	}
+
+	testCases := []testCase{
+		{
+			body:       `{"type":"poll","title":"title","category":"music"}`,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"poll\",\"msg\":\"is required\"}]}\n",
+		},
+		{
+			body:       `{"type":"poll","title":"title","category":"music","poll":{"options":[{"text":"only"}]}}`,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"poll\",\"msg\":\"must have from 2 to 10 options\"}]}\n",
+		},
+		{
+			body:       `{"type":"poll","title":"title","category":"music","poll":{"options":[{"text":"a"},{"text":"  "}]}}`,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"poll\",\"msg\":\"options must be from 1 to 100 characters long\"}]}\n",
+		},
+		{
+			body:       `{"type":"poll","title":"title","category":"music","poll":{"options":[{"text":"a"},{"text":"b"}],"closesAt":"2000-01-01T00:00:00Z"}}`,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"poll\",\"msg\":\"closing time must be in the future\"}]}\n",
+		},
+	}
+
+	for _, testCase := range testCases {
+		postHandler := setupPost()
+		defer postHandler.Logger.Sync()
+
+		r := httptest.NewRequest("POST", "/api/posts", strings.NewReader(testCase.body))
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, user.User{Username: "test", UserID: 0})
+		w := httptest.NewRecorder()
+
+		postHandler.PostAdd(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		require.Equal(t, string(body), testCase.response)
+		postHandler.PostRepo.(*mocks.PostRepo).AssertNotCalled(t, "Add", mock.Anything)
+	}
+}
+
+func TestPostAddPollCreated(t *testing.T) {
+	postHandler := setupPost()
+	defer postHandler.Logger.Sync()
+
+	r := httptest.NewRequest("POST", "/api/posts", strings.NewReader(
+		`{"type":"poll","title":"title","category":"music","poll":{"options":[{"text":" yes ","votes":5},{"text":"no"}],"hideResults":true}}`,
+	))
+	ctx := context.WithValue(r.Context(), middleware.UserContextKey, user.User{Username: "test", UserID: 0})
+	w := httptest.NewRecorder()
+
+	postHandler.PostRepo.(*mocks.PostRepo).
+		On("Add", mock.AnythingOfType("*post.Post")).
+		Return(nil)
+
+	postHandler.PostAdd(w, r.WithContext(ctx))
+
+	resp := w.Result()
+	require.Equal(t, resp.StatusCode, http.StatusOK)
+	added := postHandler.PostRepo.(*mocks.PostRepo).Calls[0].Arguments.Get(0).(*post.Post)
+	require.Equal(t, added.Poll, &poll.Poll{Options: []poll.Option{{Text: "yes"}, {Text: "no"}}, HideResults: true})
+}
@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"redditclone/pkg/database"
 	"redditclone/pkg/errors"
+	"redditclone/pkg/events"
 	"redditclone/pkg/frontendMessages"
 	"redditclone/pkg/middleware"
+	"redditclone/pkg/notification"
 	"redditclone/pkg/post"
 	"redditclone/pkg/token"
 	"redditclone/pkg/user"
@@ -33,7 +36,69 @@ func (h *PostHandler) PostAdd(w http.ResponseWriter, r *http.Request) {
 		)
 		return
 	}
+	h.addPost(w, usr, pst)
+}
+
+// addPost checks a post of usr sent as JSON and creates it, it reports
+// whether the post was created. The response is always sent.
+func (h *PostHandler) addPost(w http.ResponseWriter, usr user.User, pst post.Post) bool {
+	if pst.Type == post.TypeImage || pst.Type == post.TypeCrosspost {
+		message := "images are uploaded with multipart form"
+		if pst.Type == post.TypeCrosspost {
+			message = "crossposts are made from the original post"
+		}
+		frontendMessages.SendError(w, []frontendMessages.ErrorMessage{{
+			Location: "body",
+			Param:    "type",
+			Value:    pst.Type,
+			Message:  message,
+		}}, http.StatusUnprocessableEntity, h.Logger, "postAdd")
+		return false
+	}
+	pst.Image, pst.Crosspost = nil, nil
+	pst.Pinned, pst.Locked, pst.Archived = false, false, false
+	ok, err := h.preparePost(w, &pst, "postAdd")
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("postAdd: %w", err),
+		)
+		return false
+	}
+	if !ok {
+		return false
+	}
+	return h.createPost(w, usr, pst)
+}
+
+// preparePost checks the fields of a post set by its author and fills the
+// derived ones, on false the response was already sent. Errors are reported
+// as coming from the handler from.
+func (h *PostHandler) preparePost(w http.ResponseWriter, pst *post.Post, from string) (bool, error) {
+	if pst.PublishAt != nil && !pst.PublishAt.After(time.Now()) {
+		frontendMessages.SendError(w, []frontendMessages.ErrorMessage{{
+			Location: "body",
+			Param:    "publishAt",
+			Value:    pst.PublishAt,
+			Message:  "must be in the future",
+		}}, http.StatusUnprocessableEntity, h.Logger, from)
+		return false, nil
+	}
+	if !h.preparePoll(w, pst, from) {
+		return false, nil
+	}
+	ok, err := h.prepareFlair(w, pst, from)
+	if err != nil || !ok {
+		return false, err
+	}
+	return h.prepareLink(w, pst, from)
+}
+
+// createPost saves a checked post of usr and sends it back, it reports
+// whether the post was saved.
+func (h *PostHandler) createPost(w http.ResponseWriter, usr user.User, pst post.Post) bool {
 	pst.Author = usr
+	pst.Mentions = h.mentions(pst.Text, usr)
 	pst.Time = time.Now().Format(time.RFC3339)
 	pst.Votes = []frontendMessages.Vote{{UserID: usr.UserID, Vote: 1}}
 	pst.GetVotes()
@@ -41,19 +106,41 @@ func (h *PostHandler) PostAdd(w http.ResponseWriter, r *http.Request) {
 	if errAdd != nil {
 		h.Logger.Errorf("can`t add post to database: %s", errAdd)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+		return false
 	}
 	h.Logger.Debugf("adding post with id: %d", pst.ID)
+	if pst.Type == post.TypeLink && h.Previews != nil {
+		go h.fetchPreview(pst.ID, pst.URL)
+	}
+	if pst.PublishAt == nil {
+		h.announce(pst)
+	}
+	pst.ForViewer(&usr)
 	pstStr, errConvJson := json.Marshal(pst)
 	if errConvJson != nil {
 		errors.SendHttpError(
 			h.Logger, w,
 			fmt.Errorf("postAdd: %w", errors.ErrMarshal{Err: errConvJson}),
 		)
-		return
+		return true
 	}
 	w.WriteHeader(http.StatusOK)
 	w.Write(pstStr)
+	return true
+}
+
+// announce notifies mentioned users and subscribers of the category about a
+// freshly published post. Event streams are open to anonymous visitors, so
+// the post is shown as it is to them.
+func (h *PostHandler) announce(pst post.Post) {
+	for _, mentioned := range pst.Mentions {
+		h.notifyMention(mentioned, pst.Author, notification.KindPostMention, pst.ID, 0, pst.Title)
+	}
+	pst.ForViewer(nil)
+	if !pst.ApplyPreferences(user.DefaultPreferences()) {
+		return
+	}
+	h.Events.Publish(events.Event{Type: events.PostCreated, PostID: pst.ID, Category: pst.Category, Data: pst})
 }
 
 func (h *PostHandler) PostGet(w http.ResponseWriter, r *http.Request) {
@@ -84,6 +171,12 @@ func (h *PostHandler) PostGet(w http.ResponseWriter, r *http.Request) {
 		)
 		return
 	}
+	viewer := viewerFromContext(r)
+	blocked, visible := h.visiblePost(w, &pst, viewer, "postGet")
+	if !visible {
+		h.PostRepo.Unlock(id)
+		return
+	}
 	pst.Views++
 	errUpdate := h.PostRepo.Update(pst)
 	if errUpdate != nil {
@@ -102,6 +195,8 @@ func (h *PostHandler) PostGet(w http.ResponseWriter, r *http.Request) {
 		)
 		return
 	}
+	pst.ForViewer(viewer)
+	pst.DropBlocked(blocked)
 	pstJson, err := json.Marshal(pst)
 	if err != nil {
 		errors.SendHttpError(
@@ -114,8 +209,160 @@ func (h *PostHandler) PostGet(w http.ResponseWriter, r *http.Request) {
 	w.Write(pstJson)
 }
 
+// visiblePost applies the checks of opening a single post: scheduled posts
+// of others, hidden posts and posts of blocked authors are not found, NSFW
+// and spoilers follow the preferences of viewer. It returns the ids of users
+// blocked by viewer; on false the response was already sent.
+func (h *PostHandler) visiblePost(w http.ResponseWriter, pst *post.Post, viewer *user.User, from string) ([]int64, bool) {
+	notFound := func() ([]int64, bool) {
+		frontendMessages.SendMessage(w,
+			"post not found",
+			http.StatusNotFound,
+			h.Logger, from,
+		)
+		return nil, false
+	}
+	if pst.PublishAt != nil && (viewer == nil || !isAuthor(*viewer, pst.Author)) {
+		return notFound()
+	}
+	if pst.Hidden {
+		allowed, errAllowed := canSeeHidden(h.UserRepo, pst.Author, viewer)
+		if errAllowed != nil {
+			errors.SendHttpError(
+				h.Logger, w,
+				fmt.Errorf("%s: can`t get role: %w", from, errAllowed),
+			)
+			return nil, false
+		}
+		if !allowed {
+			return notFound()
+		}
+	}
+	if (pst.NSFW || pst.Spoiler) && (viewer == nil || !isAuthor(*viewer, pst.Author)) {
+		prefs, errPrefs := h.viewerPreferences(viewer)
+		if errPrefs != nil {
+			errors.SendHttpError(
+				h.Logger, w,
+				fmt.Errorf("%s: %w", from, errPrefs),
+			)
+			return nil, false
+		}
+		// a spoiler opened directly is wanted, so it is only blurred
+		if prefs.Spoiler == user.ContentHide {
+			prefs.Spoiler = user.ContentBlur
+		}
+		if !pst.ApplyPreferences(prefs) {
+			frontendMessages.SendMessage(w,
+				"this post is marked as nsfw",
+				http.StatusForbidden,
+				h.Logger, from,
+			)
+			return nil, false
+		}
+	}
+	if viewer == nil {
+		return nil, true
+	}
+	blocked, errBlocked := h.blockedIDs(*viewer)
+	if errBlocked != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("%s: %w", from, errBlocked),
+		)
+		return nil, false
+	}
+	for _, userID := range blocked {
+		if userID == pst.Author.UserID {
+			return notFound()
+		}
+	}
+	return blocked, true
+}
+
+// closedPost sends why pst accepts no comments and votes from usr and
+// reports whether it did. A scheduled post is not found for anyone but its
+// author, like in PostGet.
+func (h *PostHandler) closedPost(w http.ResponseWriter, pst *post.Post, usr user.User, from string) bool {
+	if pst.PublishAt != nil && !isAuthor(usr, pst.Author) {
+		frontendMessages.SendMessage(w,
+			"post not found",
+			http.StatusNotFound,
+			h.Logger, from,
+		)
+		return true
+	}
+	reason := pst.ClosedReason()
+	if reason == "" {
+		return false
+	}
+	frontendMessages.SendMessage(w,
+		reason,
+		http.StatusForbidden,
+		h.Logger, from,
+	)
+	return true
+}
+
+func viewerFromContext(r *http.Request) *user.User {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		return nil
+	}
+	return &usr
+}
+
+// viewerPreferences returns content preferences of viewer, anonymous
+// visitors get the default ones.
+func (h *PostHandler) viewerPreferences(viewer *user.User) (user.Preferences, error) {
+	if viewer == nil {
+		return user.DefaultPreferences(), nil
+	}
+	prefs, err := h.UserRepo.Preferences(viewer.UserID)
+	if err != nil {
+		return prefs, fmt.Errorf("can`t get preferences: %w", err)
+	}
+	return prefs, nil
+}
+
+// blockedIDs returns ids of users blocked by viewer.
+func (h *PostHandler) blockedIDs(viewer user.User) ([]int64, error) {
+	blocks, err := h.Blocks.Blocks(viewer)
+	if err != nil {
+		return nil, fmt.Errorf("can`t get blocked users: %w", err)
+	}
+	ids := make([]int64, 0, len(blocks))
+	for _, blocked := range blocks {
+		ids = append(ids, blocked.UserID)
+	}
+	return ids, nil
+}
+
+func (h *PostHandler) listingFilter(r *http.Request, filter post.Filter) (post.Filter, error) {
+	var err error
+	filter.Viewer = viewerFromContext(r)
+	filter.Preferences, err = h.viewerPreferences(filter.Viewer)
+	if err != nil || filter.Viewer == nil {
+		return filter, err
+	}
+	hidden, err := h.PostMarks.List(filter.Viewer.UserID, database.MarkHidden)
+	if err != nil {
+		return filter, fmt.Errorf("can`t get hidden posts: %w", err)
+	}
+	filter.Exclude = hidden
+	filter.Blocked, err = h.blockedIDs(*filter.Viewer)
+	return filter, err
+}
+
 func (h *PostHandler) Posts(w http.ResponseWriter, r *http.Request) {
-	postsStr, err := h.PostRepo.ToJson("", "")
+	filter, errFilter := h.listingFilter(r, post.Filter{})
+	if errFilter != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("Posts: %w", errFilter),
+		)
+		return
+	}
+	postsStr, err := h.PostRepo.ToJson(filter)
 	if err != nil {
 		errors.SendHttpError(
 			h.Logger, w,
@@ -137,7 +384,26 @@ func (h *PostHandler) Categories(w http.ResponseWriter, r *http.Request) {
 		)
 		return
 	}
-	postsStr, err := h.PostRepo.ToJson(category, "")
+	flairText, errFlair := h.flairText(category, r.URL.Query().Get("flair"))
+	if errFlair != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("Categories: %w", errFlair),
+		)
+		return
+	}
+	filter, errFilter := h.listingFilter(r, post.Filter{
+		Category: category,
+		Flair:    flairText,
+	})
+	if errFilter != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("Categories: %w", errFilter),
+		)
+		return
+	}
+	postsStr, err := h.PostRepo.ToJson(filter)
 	if err != nil {
 		errors.SendHttpError(
 			h.Logger, w,
@@ -159,7 +425,15 @@ func (h *PostHandler) UserPosts(w http.ResponseWriter, r *http.Request) {
 		)
 		return
 	}
-	postsStr, err := h.PostRepo.ToJson("", username)
+	filter, errFilter := h.listingFilter(r, post.Filter{Username: username})
+	if errFilter != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("UserPosts: %w", errFilter),
+		)
+		return
+	}
+	postsStr, err := h.PostRepo.ToJson(filter)
 	if err != nil {
 		errors.SendHttpError(
 			h.Logger, w,
@@ -213,15 +487,18 @@ func (h *PostHandler) PostRemove(w http.ResponseWriter, r *http.Request) {
 		)
 		return
 	}
-	ok = h.PostRepo.Remove(idPost)
+	pst.MarkDeleted(usr, r.URL.Query().Get("reason"))
+	errUpdate := h.PostRepo.Update(pst)
 	h.PostRepo.Unlock(idPost)
-	if !ok {
+	if errUpdate != nil {
 		errors.SendHttpError(
 			h.Logger, w,
-			fmt.Errorf("postRemove: can`t remove post with id %d", idPost),
+			fmt.Errorf("postRemove: %w", errUpdate),
 		)
 		return
 	}
+	h.markCrossposts(idPost, true)
+	h.Events.Publish(events.Event{Type: events.PostRemoved, PostID: idPost, Category: pst.Category})
 	frontendMessages.SendMessage(w,
 		"success",
 		http.StatusOK,
@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"redditclone/pkg/comment"
 	"redditclone/pkg/database/mocks"
 	"redditclone/pkg/frontendMessages"
 	"redditclone/pkg/middleware"
@@ -58,6 +59,7 @@ func TestPostAdd(t *testing.T) {
 						Vote:   1,
 					},
 				},
+				MyVote:   1,
 				Comments: nil,
 			},
 		},
@@ -154,6 +156,7 @@ func TestPostGet(t *testing.T) {
 			responseHasPost: true,
 			responsePost: post.Post{
 				Views: 1,
+				Votes: []frontendMessages.Vote{},
 			},
 		},
 		{
@@ -299,7 +302,7 @@ func TestPosts(t *testing.T) {
 		w := httptest.NewRecorder()
 
 		postHandler.PostRepo.(*mocks.PostRepo).
-			On("ToJson", "", "").
+			On("ToJson", post.Filter{Preferences: user.DefaultPreferences()}).
 			Return(testCase.postRepoToJsonRes, testCase.postRepoToJsonError)
 
 		postHandler.Posts(w, r)
@@ -361,7 +364,7 @@ func TestCategories(t *testing.T) {
 		w := httptest.NewRecorder()
 
 		postHandler.PostRepo.(*mocks.PostRepo).
-			On("ToJson", testCase.category, "").
+			On("ToJson", post.Filter{Category: testCase.category, Preferences: user.DefaultPreferences()}).
 			Return(testCase.postRepoToJsonRes, testCase.postRepoToJsonError)
 
 		postHandler.Categories(w, r)
@@ -423,7 +426,7 @@ func TestUserPosts(t *testing.T) {
 		w := httptest.NewRecorder()
 
 		postHandler.PostRepo.(*mocks.PostRepo).
-			On("ToJson", "", testCase.username).
+			On("ToJson", post.Filter{Username: testCase.username, Preferences: user.DefaultPreferences()}).
 			Return(testCase.postRepoToJsonRes, testCase.postRepoToJsonError)
 
 		postHandler.UserPosts(w, r)
@@ -445,10 +448,10 @@ func TestPostRemove(t *testing.T) {
 
 		postID uint64
 
-		postRepoLockStatus   bool
-		postRepoGetPost      post.Post
-		postRepoGetError     error
-		postRepoRemoveStatus bool
+		postRepoLockStatus  bool
+		postRepoGetPost     post.Post
+		postRepoGetError    error
+		postRepoUpdateError error
 
 		statusCode int
 		response   string
@@ -456,88 +459,88 @@ func TestPostRemove(t *testing.T) {
 
 	testCases := []testCase{
 		{
-			valueVars:            map[string]string{"post_id": "0"},
-			contextKey:           middleware.UserContextKey,
-			contextValue:         user.User{Username: "test", UserID: 0},
-			postID:               0,
-			postRepoLockStatus:   true,
-			postRepoGetPost:      post.Post{Author: user.User{Username: "test", UserID: 0}},
-			postRepoGetError:     nil,
-			postRepoRemoveStatus: true,
-			statusCode:           http.StatusOK,
-			response:             "{\"message\":\"success\"}\n",
+			valueVars:           map[string]string{"post_id": "0"},
+			contextKey:          middleware.UserContextKey,
+			contextValue:        user.User{Username: "test", UserID: 0},
+			postID:              0,
+			postRepoLockStatus:  true,
+			postRepoGetPost:     post.Post{Author: user.User{Username: "test", UserID: 0}},
+			postRepoGetError:    nil,
+			postRepoUpdateError: nil,
+			statusCode:          http.StatusOK,
+			response:            "{\"message\":\"success\"}\n",
 		},
 		{
-			valueVars:            map[string]string{"wrong vars": "0"},
-			contextKey:           middleware.UserContextKey,
-			contextValue:         user.User{Username: "test", UserID: 0},
-			postID:               0,
-			postRepoLockStatus:   true,
-			postRepoGetPost:      post.Post{Author: user.User{Username: "test", UserID: 0}},
-			postRepoGetError:     nil,
-			postRepoRemoveStatus: true,
-			statusCode:           http.StatusInternalServerError,
-			response:             "",
+			valueVars:           map[string]string{"wrong vars": "0"},
+			contextKey:          middleware.UserContextKey,
+			contextValue:        user.User{Username: "test", UserID: 0},
+			postID:              0,
+			postRepoLockStatus:  true,
+			postRepoGetPost:     post.Post{Author: user.User{Username: "test", UserID: 0}},
+			postRepoGetError:    nil,
+			postRepoUpdateError: nil,
+			statusCode:          http.StatusInternalServerError,
+			response:            "",
 		},
 		{
-			valueVars:            map[string]string{"post_id": "0"},
-			contextKey:           middleware.AuthtorizationContextKey,
-			contextValue:         user.User{Username: "test", UserID: 0},
-			postID:               0,
-			postRepoLockStatus:   true,
-			postRepoGetPost:      post.Post{Author: user.User{Username: "test", UserID: 0}},
-			postRepoGetError:     nil,
-			postRepoRemoveStatus: true,
-			statusCode:           http.StatusInternalServerError,
-			response:             "Internal server error\n",
+			valueVars:           map[string]string{"post_id": "0"},
+			contextKey:          middleware.AuthtorizationContextKey,
+			contextValue:        user.User{Username: "test", UserID: 0},
+			postID:              0,
+			postRepoLockStatus:  true,
+			postRepoGetPost:     post.Post{Author: user.User{Username: "test", UserID: 0}},
+			postRepoGetError:    nil,
+			postRepoUpdateError: nil,
+			statusCode:          http.StatusInternalServerError,
+			response:            "Internal server error\n",
 		},
 		{
-			valueVars:            map[string]string{"post_id": "0"},
-			contextKey:           middleware.UserContextKey,
-			contextValue:         user.User{Username: "test", UserID: 0},
-			postID:               0,
-			postRepoLockStatus:   false,
-			postRepoGetPost:      post.Post{Author: user.User{Username: "test", UserID: 0}},
-			postRepoGetError:     nil,
-			postRepoRemoveStatus: true,
-			statusCode:           http.StatusNotFound,
-			response:             "{\"message\":\"post not found\"}\n",
+			valueVars:           map[string]string{"post_id": "0"},
+			contextKey:          middleware.UserContextKey,
+			contextValue:        user.User{Username: "test", UserID: 0},
+			postID:              0,
+			postRepoLockStatus:  false,
+			postRepoGetPost:     post.Post{Author: user.User{Username: "test", UserID: 0}},
+			postRepoGetError:    nil,
+			postRepoUpdateError: nil,
+			statusCode:          http.StatusNotFound,
+			response:            "{\"message\":\"post not found\"}\n",
 		},
 		{
-			valueVars:            map[string]string{"post_id": "0"},
-			contextKey:           middleware.UserContextKey,
-			contextValue:         user.User{Username: "test", UserID: 0},
-			postID:               0,
-			postRepoLockStatus:   true,
-			postRepoGetPost:      post.Post{},
-			postRepoGetError:     fmt.Errorf("test error"),
-			postRepoRemoveStatus: true,
-			statusCode:           http.StatusInternalServerError,
-			response:             "",
+			valueVars:           map[string]string{"post_id": "0"},
+			contextKey:          middleware.UserContextKey,
+			contextValue:        user.User{Username: "test", UserID: 0},
+			postID:              0,
+			postRepoLockStatus:  true,
+			postRepoGetPost:     post.Post{},
+			postRepoGetError:    fmt.Errorf("test error"),
+			postRepoUpdateError: nil,
+			statusCode:          http.StatusInternalServerError,
+			response:            "",
 		},
 		{
-			valueVars:            map[string]string{"post_id": "0"},
-			contextKey:           middleware.UserContextKey,
-			contextValue:         user.User{Username: "test2", UserID: 0},
-			postID:               0,
-			postRepoLockStatus:   true,
-			postRepoGetPost:      post.Post{Author: user.User{Username: "test", UserID: 0}},
-			postRepoGetError:     nil,
-			postRepoRemoveStatus: true,
-			statusCode:           http.StatusNotFound,
-			response:             "{\"message\":\"this post doesn't belong to this user\"}\n",
+			valueVars:           map[string]string{"post_id": "0"},
+			contextKey:          middleware.UserContextKey,
+			contextValue:        user.User{Username: "test2", UserID: 0},
+			postID:              0,
+			postRepoLockStatus:  true,
+			postRepoGetPost:     post.Post{Author: user.User{Username: "test", UserID: 0}},
+			postRepoGetError:    nil,
+			postRepoUpdateError: nil,
+			statusCode:          http.StatusNotFound,
+			response:            "{\"message\":\"this post doesn't belong to this user\"}\n",
 		},
 		{
-			valueVars:            map[string]string{"post_id": "0"},
-			contextKey:           middleware.UserContextKey,
-			contextValue:         user.User{Username: "test", UserID: 0},
-			postID:               0,
-			postRepoLockStatus:   true,
-			postRepoGetPost:      post.Post{Author: user.User{Username: "test", UserID: 0}},
-			postRepoGetError:     nil,
-			postRepoRemoveStatus: false,
-			statusCode:           http.StatusInternalServerError,
-			response:             "",
+			valueVars:           map[string]string{"post_id": "0"},
+			contextKey:          middleware.UserContextKey,
+			contextValue:        user.User{Username: "test", UserID: 0},
+			postID:              0,
+			postRepoLockStatus:  true,
+			postRepoGetPost:     post.Post{Author: user.User{Username: "test", UserID: 0}},
+			postRepoGetError:    nil,
+			postRepoUpdateError: fmt.Errorf("test error"),
+			statusCode:          http.StatusInternalServerError,
+			response:            "",
 		},
 	}
 
@@ -561,8 +564,12 @@ func TestPostRemove(t *testing.T) {
 			Return(testCase.postRepoGetPost, testCase.postRepoGetError)
 
 		postHandler.PostRepo.(*mocks.PostRepo).
-			On("Remove", testCase.postID).
-			Return(testCase.postRepoRemoveStatus)
+			On("Update", mock.AnythingOfType("post.Post")).
+			Return(testCase.postRepoUpdateError)
+
+		postHandler.PostRepo.(*mocks.PostRepo).
+			On("MarkCrossposts", testCase.postID, true).
+			Return(nil)
 
 		postHandler.PostRepo.(*mocks.PostRepo).
 			On("Unlock", testCase.postID).
@@ -579,3 +586,242 @@ func TestPostRemove(t *testing.T) {
 
 	}
 }
+
+func TestPostGetViewer(t *testing.T) {
+	type testCase struct {
+		viewer   interface{}
+		response string
+	}
+
+	stored := post.Post{
+		Votes: []frontendMessages.Vote{{UserID: 1, Vote: 1}, {UserID: 2, Vote: -1}},
+	}
+	testCases := []testCase{
+		{
+			viewer:   nil,
+			response: `{"title":"","author":{"username":"","id":"0"},"category":"","id":"0","created":"","score":0,"views":1,"upvotePercentage":0,"type":"","votes":[],"myVote":0,"comments":null}`,
+		},
+		{
+			viewer:   user.User{Username: "test2", UserID: 2},
+			response: `{"title":"","author":{"username":"","id":"0"},"category":"","id":"0","created":"","score":0,"views":1,"upvotePercentage":0,"type":"","votes":[{"user":"2","vote":-1}],"myVote":-1,"comments":null}`,
+		},
+		{
+			viewer:   user.User{Username: "test3", UserID: 3},
+			response: `{"title":"","author":{"username":"","id":"0"},"category":"","id":"0","created":"","score":0,"views":1,"upvotePercentage":0,"type":"","votes":[],"myVote":0,"comments":null}`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		postHandler := setupPost()
+		defer postHandler.Logger.Sync()
+
+		r := httptest.NewRequest("GET", "/api/post/0", nil)
+		r = mux.SetURLVars(r, map[string]string{"post_id": "0"})
+		if testCase.viewer != nil {
+			r = r.WithContext(context.WithValue(r.Context(), middleware.UserContextKey, testCase.viewer))
+		}
+		w := httptest.NewRecorder()
+
+		pst := stored
+		pst.Votes = append([]frontendMessages.Vote{}, stored.Votes...)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Lock", uint64(0)).Return(true)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Get", uint64(0)).Return(pst, nil)
+		postHandler.PostRepo.(*mocks.PostRepo).
+			On("Update", mock.AnythingOfType("post.Post")).
+			Run(func(args mock.Arguments) {
+				require.Len(t, args.Get(0).(post.Post).Votes, 2, "stored votes must not be filtered")
+			}).
+			Return(nil)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Unlock", uint64(0)).Return(true)
+		postHandler.Blocks.(*mocks.BlockRepo).On("Blocks", mock.AnythingOfType("user.User")).Return([]user.User{}, nil)
+
+		postHandler.PostGet(w, r)
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, http.StatusOK)
+		require.Equal(t, string(body), testCase.response)
+	}
+}
+
+func TestPostGetHidden(t *testing.T) {
+	type testCase struct {
+		viewer    interface{}
+		role      string
+		roleError error
+
+		statusCode int
+	}
+
+	author := user.User{Username: "author", UserID: 1}
+	testCases := []testCase{
+		{viewer: nil, statusCode: http.StatusNotFound},
+		{viewer: author, statusCode: http.StatusOK},
+		{viewer: user.User{Username: "other", UserID: 2}, role: user.RoleUser, statusCode: http.StatusNotFound},
+		{viewer: user.User{Username: "other", UserID: 2}, role: user.RoleModerator, statusCode: http.StatusOK},
+		{viewer: user.User{Username: "other", UserID: 2}, roleError: fmt.Errorf("test error"), statusCode: http.StatusInternalServerError},
+	}
+
+	for _, testCase := range testCases {
+		postHandler := setupPost()
+		defer postHandler.Logger.Sync()
+
+		r := httptest.NewRequest("GET", "/api/post/0", nil)
+		r = mux.SetURLVars(r, map[string]string{"post_id": "0"})
+		if testCase.viewer != nil {
+			r = r.WithContext(context.WithValue(r.Context(), middleware.UserContextKey, testCase.viewer))
+		}
+		w := httptest.NewRecorder()
+
+		postHandler.PostRepo.(*mocks.PostRepo).On("Lock", uint64(0)).Return(true)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Get", uint64(0)).Return(post.Post{Author: author, Hidden: true}, nil)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Update", mock.AnythingOfType("post.Post")).Return(nil)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Unlock", uint64(0)).Return(true)
+		postHandler.Blocks.(*mocks.BlockRepo).On("Blocks", mock.AnythingOfType("user.User")).Return([]user.User{}, nil)
+		postHandler.UserRepo.(*mocks.UserRepo).On("Role", int64(2)).Return(testCase.role, testCase.roleError)
+
+		postHandler.PostGet(w, r)
+
+		resp := w.Result()
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		postHandler.PostRepo.(*mocks.PostRepo).AssertCalled(t, "Unlock", uint64(0))
+	}
+}
+
+func TestPostGetPreferences(t *testing.T) {
+	type testCase struct {
+		viewer  interface{}
+		stored  post.Post
+		prefs   user.Preferences
+		blurred bool
+
+		statusCode int
+	}
+
+	author := user.User{Username: "author", UserID: 1}
+	other := user.User{Username: "other", UserID: 2}
+	testCases := []testCase{
+		{viewer: nil, stored: post.Post{Author: author, NSFW: true}, statusCode: http.StatusForbidden},
+		{viewer: nil, stored: post.Post{Author: author, Spoiler: true}, blurred: true, statusCode: http.StatusOK},
+		{viewer: author, stored: post.Post{Author: author, NSFW: true}, statusCode: http.StatusOK},
+		{
+			viewer:     other,
+			stored:     post.Post{Author: author, NSFW: true},
+			prefs:      user.Preferences{NSFW: user.ContentBlur, Spoiler: user.ContentShow},
+			blurred:    true,
+			statusCode: http.StatusOK,
+		},
+		{
+			viewer:     other,
+			stored:     post.Post{Author: author, Spoiler: true},
+			prefs:      user.Preferences{NSFW: user.ContentHide, Spoiler: user.ContentHide},
+			blurred:    true,
+			statusCode: http.StatusOK,
+		},
+		{
+			viewer:     other,
+			stored:     post.Post{Author: author, NSFW: true, Spoiler: true},
+			prefs:      user.Preferences{NSFW: user.ContentShow, Spoiler: user.ContentShow},
+			statusCode: http.StatusOK,
+		},
+	}
+
+	for caseNum, testCase := range testCases {
+		postHandler := setupPost()
+		defer postHandler.Logger.Sync()
+
+		r := httptest.NewRequest("GET", "/api/post/0", nil)
+		r = mux.SetURLVars(r, map[string]string{"post_id": "0"})
+		if testCase.viewer != nil {
+			r = r.WithContext(context.WithValue(r.Context(), middleware.UserContextKey, testCase.viewer))
+		}
+		w := httptest.NewRecorder()
+
+		postHandler.PostRepo.(*mocks.PostRepo).On("Lock", uint64(0)).Return(true)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Get", uint64(0)).Return(testCase.stored, nil)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Update", mock.AnythingOfType("post.Post")).Return(nil)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Unlock", uint64(0)).Return(true)
+		postHandler.Blocks.(*mocks.BlockRepo).On("Blocks", mock.AnythingOfType("user.User")).Return([]user.User{}, nil)
+		postHandler.UserRepo.(*mocks.UserRepo).On("Preferences", other.UserID).Return(testCase.prefs, nil)
+
+		postHandler.PostGet(w, r)
+
+		resp := w.Result()
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode, "case %d", caseNum)
+		if testCase.statusCode == http.StatusForbidden {
+			require.Equal(t, string(body), "{\"message\":\"this post is marked as nsfw\"}\n", "case %d", caseNum)
+			continue
+		}
+		require.Equal(t, strings.Contains(string(body), `"blurred":true`), testCase.blurred, "case %d", caseNum)
+	}
+}
+
+func TestPostGetBlocked(t *testing.T) {
+	viewer := user.User{Username: "viewer", UserID: 1}
+	author := user.User{Username: "author", UserID: 2}
+	troll := user.User{Username: "troll", UserID: 3}
+	parentID := uint64(0)
+
+	type testCase struct {
+		stored post.Post
+
+		statusCode int
+		comments   []comment.Comment
+	}
+
+	testCases := []testCase{
+		{
+			stored:     post.Post{Author: troll},
+			statusCode: http.StatusNotFound,
+		},
+		{
+			stored: post.Post{
+				Author: author,
+				Comments: []comment.Comment{
+					{Author: troll, Body: "bait", ID: 0, Time: "t0"},
+					{Author: author, Body: "reply", ID: 1, Parent: &parentID, Time: "t1"},
+					{Author: troll, Body: "noise", ID: 2, Time: "t2"},
+				},
+			},
+			statusCode: http.StatusOK,
+			comments: []comment.Comment{
+				{ID: 0, Time: "t0", Blocked: true},
+				{Author: author, Body: "reply", BodyHTML: "<p>reply</p>\n", ID: 1, Parent: &parentID, Time: "t1"},
+			},
+		},
+	}
+
+	for caseNum, testCase := range testCases {
+		postHandler := setupPost()
+		defer postHandler.Logger.Sync()
+
+		r := httptest.NewRequest("GET", "/api/post/0", nil)
+		r = mux.SetURLVars(r, map[string]string{"post_id": "0"})
+		r = r.WithContext(context.WithValue(r.Context(), middleware.UserContextKey, viewer))
+		w := httptest.NewRecorder()
+
+		postHandler.PostRepo.(*mocks.PostRepo).On("Lock", uint64(0)).Return(true)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Get", uint64(0)).Return(testCase.stored, nil)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Update", mock.AnythingOfType("post.Post")).Return(nil)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Unlock", uint64(0)).Return(true)
+		postHandler.Blocks.(*mocks.BlockRepo).On("Blocks", viewer).Return([]user.User{troll}, nil)
+
+		postHandler.PostGet(w, r)
+
+		resp := w.Result()
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode, "case %d", caseNum)
+		postHandler.PostRepo.(*mocks.PostRepo).AssertCalled(t, "Unlock", uint64(0))
+		if testCase.statusCode != http.StatusOK {
+			continue
+		}
+		var got post.Post
+		require.NoError(t, json.Unmarshal(body, &got))
+		require.Equal(t, got.Comments, testCase.comments, "case %d", caseNum)
+	}
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"redditclone/pkg/errors"
+	"redditclone/pkg/frontendMessages"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/token"
+	"redditclone/pkg/user"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	maxBioLen    = 500
+	maxAvatarLen = 500
+)
+
+type profileJson struct {
+	Bio       *string `json:"bio"`
+	AvatarURL *string `json:"avatar"`
+}
+
+func (h *UserHandler) sendProfile(w http.ResponseWriter, usr user.User, from string) {
+	prf, errProfile := h.UserRepo.Profile(usr.Username)
+	if errProfile != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("%s: can`t get profile: %w", from, errProfile),
+		)
+		return
+	}
+	posts, comments, errCount := h.PostRepo.Counts(usr)
+	if errCount != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("%s: can`t count posts: %w", from, errCount),
+		)
+		return
+	}
+	prf.PostCount = posts
+	prf.CommentCount = comments
+	res, errMarshal := json.Marshal(prf)
+	if errMarshal != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("%s: %w", from, errors.ErrMarshal{Err: errMarshal}),
+		)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+func (h *UserHandler) Profile(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	username, errGet := token.GetMapItemString(vars, "user_login")
+	if errGet != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("profile: %w", errors.ErrRequest{Err: errGet}),
+		)
+		return
+	}
+	usr, ok := h.UserRepo.Find(username)
+	if !ok {
+		frontendMessages.SendMessage(w,
+			"user not found",
+			http.StatusNotFound,
+			h.Logger, "profile",
+		)
+		return
+	}
+	h.sendProfile(w, usr, "profile")
+}
+
+func (h *UserHandler) ProfileUpdate(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	prfJson := profileJson{}
+	errUnmarshal := json.Unmarshal(body, &prfJson)
+	if errUnmarshal != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("profileUpdate: %w", errors.ErrUnmarshalRequest{Err: errUnmarshal}),
+		)
+		return
+	}
+	prf, errProfile := h.UserRepo.Profile(usr.Username)
+	if errProfile != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("profileUpdate: can`t get profile: %w", errProfile),
+		)
+		return
+	}
+	if prfJson.Bio != nil {
+		prf.Bio = *prfJson.Bio
+	}
+	if prfJson.AvatarURL != nil {
+		prf.AvatarURL = *prfJson.AvatarURL
+	}
+	errs := []frontendMessages.ErrorMessage{}
+	if utf8.RuneCountInString(prf.Bio) > maxBioLen {
+		errs = append(errs, frontendMessages.ErrorMessage{
+			Location: "body",
+			Param:    "bio",
+			Message:  fmt.Sprintf("must be at most %d characters long", maxBioLen),
+		})
+	}
+	if prf.AvatarURL != "" {
+		avatar, errParse := url.Parse(prf.AvatarURL)
+		if errParse != nil || (avatar.Scheme != "http" && avatar.Scheme != "https") || avatar.Host == "" {
+			errs = append(errs, frontendMessages.ErrorMessage{
+				Location: "body",
+				Param:    "avatar",
+				Value:    prf.AvatarURL,
+				Message:  "is invalid",
+			})
+		} else if len(prf.AvatarURL) > maxAvatarLen {
+			errs = append(errs, frontendMessages.ErrorMessage{
+				Location: "body",
+				Param:    "avatar",
+				Message:  fmt.Sprintf("must be at most %d characters long", maxAvatarLen),
+			})
+		}
+	}
+	if len(errs) != 0 {
+		frontendMessages.SendError(w, errs, http.StatusUnprocessableEntity, h.Logger, "profileUpdate")
+		return
+	}
+	errUpdate := h.UserRepo.UpdateProfile(usr, prf.Bio, prf.AvatarURL)
+	if errUpdate != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("profileUpdate: can`t update profile: %w", errUpdate),
+		)
+		return
+	}
+	h.Logger.Infof(`updated profile of user: "%s", userID: "%d"`, usr.Username, usr.UserID)
+	h.sendProfile(w, usr, "profileUpdate")
+}
+
+func (h *UserHandler) sendPreferences(w http.ResponseWriter, prefs user.Preferences, from string) {
+	res, errMarshal := json.Marshal(prefs)
+	if errMarshal != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("%s: %w", from, errors.ErrMarshal{Err: errMarshal}),
+		)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+func (h *UserHandler) Preferences(w http.ResponseWriter, r *http.Request) {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	prefs, err := h.UserRepo.Preferences(usr.UserID)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("preferences: can`t get preferences: %w", err),
+		)
+		return
+	}
+	h.sendPreferences(w, prefs, "preferences")
+}
+
+func (h *UserHandler) PreferencesUpdate(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	prefsJson := struct {
+		NSFW    *string `json:"nsfw"`
+		Spoiler *string `json:"spoiler"`
+	}{}
+	errUnmarshal := json.Unmarshal(body, &prefsJson)
+	if errUnmarshal != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("preferencesUpdate: %w", errors.ErrUnmarshalRequest{Err: errUnmarshal}),
+		)
+		return
+	}
+	prefs, errPrefs := h.UserRepo.Preferences(usr.UserID)
+	if errPrefs != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("preferencesUpdate: can`t get preferences: %w", errPrefs),
+		)
+		return
+	}
+	if prefsJson.NSFW != nil {
+		prefs.NSFW = *prefsJson.NSFW
+	}
+	if prefsJson.Spoiler != nil {
+		prefs.Spoiler = *prefsJson.Spoiler
+	}
+	errs := []frontendMessages.ErrorMessage{}
+	if !user.ValidContentMode(prefs.NSFW) {
+		errs = append(errs, frontendMessages.ErrorMessage{
+			Location: "body",
+			Param:    "nsfw",
+			Value:    prefs.NSFW,
+			Message:  "must be one of show, blur, hide",
+		})
+	}
+	if !user.ValidContentMode(prefs.Spoiler) {
+		errs = append(errs, frontendMessages.ErrorMessage{
+			Location: "body",
+			Param:    "spoiler",
+			Value:    prefs.Spoiler,
+			Message:  "must be one of show, blur, hide",
+		})
+	}
+	if len(errs) != 0 {
+		frontendMessages.SendError(w, errs, http.StatusUnprocessableEntity, h.Logger, "preferencesUpdate")
+		return
+	}
+	errUpdate := h.UserRepo.UpdatePreferences(usr, prefs)
+	if errUpdate != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("preferencesUpdate: can`t update preferences: %w", errUpdate),
+		)
+		return
+	}
+	h.sendPreferences(w, prefs, "preferencesUpdate")
+}
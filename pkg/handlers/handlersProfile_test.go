@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"redditclone/pkg/database/mocks"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/user"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfile(t *testing.T) {
+	type testCase struct {
+		valueVars map[string]string
+		username  string
+
+		userRepoFindUser   user.User
+		userRepoFindStatus bool
+		userRepoProfile    user.Profile
+		userRepoProfileErr error
+		postRepoPosts      uint64
+		postRepoComments   uint64
+		postRepoCountsErr  error
+
+		statusCode int
+		response   string
+	}
+
+	testCases := []testCase{
+		{
+			valueVars:          map[string]string{"user_login": "test"},
+			username:           "test",
+			userRepoFindUser:   user.User{Username: "test", UserID: 1},
+			userRepoFindStatus: true,
+			userRepoProfile: user.Profile{
+				Username:     "test",
+				UserID:       1,
+				Created:      "2022-05-02T18:32:00Z",
+				Bio:          "test bio",
+				PostKarma:    10,
+				CommentKarma: -2,
+			},
+			postRepoPosts:    3,
+			postRepoComments: 4,
+			statusCode:       http.StatusOK,
+			response:         `{"username":"test","id":"1","created":"2022-05-02T18:32:00Z","bio":"test bio","avatar":"","postKarma":10,"commentKarma":-2,"postCount":3,"commentCount":4}`,
+		},
+		{
+			valueVars:  map[string]string{"wrong vars": "test"},
+			username:   "test",
+			statusCode: http.StatusInternalServerError,
+			response:   "",
+		},
+		{
+			valueVars:          map[string]string{"user_login": "test"},
+			username:           "test",
+			userRepoFindStatus: false,
+			statusCode:         http.StatusNotFound,
+			response:           "{\"message\":\"user not found\"}\n",
+		},
+		{
+			valueVars:          map[string]string{"user_login": "test"},
+			username:           "test",
+			userRepoFindUser:   user.User{Username: "test", UserID: 1},
+			userRepoFindStatus: true,
+			userRepoProfileErr: fmt.Errorf("test error"),
+			statusCode:         http.StatusInternalServerError,
+			response:           "",
+		},
+		{
+			valueVars:          map[string]string{"user_login": "test"},
+			username:           "test",
+			userRepoFindUser:   user.User{Username: "test", UserID: 1},
+			userRepoFindStatus: true,
+			postRepoCountsErr:  fmt.Errorf("test error"),
+			statusCode:         http.StatusInternalServerError,
+			response:           "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		userHandler := setupUser()
+		defer userHandler.Logger.Sync()
+
+		r := httptest.NewRequest("GET", "/api/user/"+testCase.username+"/profile", nil)
+		r = mux.SetURLVars(r, testCase.valueVars)
+		w := httptest.NewRecorder()
+
+		userHandler.UserRepo.(*mocks.UserRepo).
+			On("Find", testCase.username).
+			Return(testCase.userRepoFindUser, testCase.userRepoFindStatus)
+
+		userHandler.UserRepo.(*mocks.UserRepo).
+			On("Profile", testCase.username).
+			Return(testCase.userRepoProfile, testCase.userRepoProfileErr)
+
+		userHandler.PostRepo.(*mocks.PostRepo).
+			On("Counts", testCase.userRepoFindUser).
+			Return(testCase.postRepoPosts, testCase.postRepoComments, testCase.postRepoCountsErr)
+
+		userHandler.Profile(w, r)
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		require.Equal(t, string(body), testCase.response)
+	}
+}
+
+func TestProfileUpdate(t *testing.T) {
+	type testCase struct {
+		contextKey   middleware.Key
+		contextValue interface{}
+
+		userRepoProfile   user.Profile
+		userRepoUpdateErr error
+
+		request    string
+		bio        string
+		avatar     string
+		statusCode int
+		response   string
+	}
+
+	usr := user.User{Username: "test", UserID: 1}
+	testCases := []testCase{
+		{
+			contextKey:      middleware.UserContextKey,
+			contextValue:    usr,
+			userRepoProfile: user.Profile{Username: "test", UserID: 1, Bio: "old bio"},
+			request:         `{"avatar":"https://example.com/a.png"}`,
+			bio:             "old bio",
+			avatar:          "https://example.com/a.png",
+			statusCode:      http.StatusOK,
+			response:        `{"username":"test","id":"1","created":"","bio":"old bio","avatar":"","postKarma":0,"commentKarma":0,"postCount":0,"commentCount":0}`,
+		},
+		{
+			contextKey:   middleware.AuthtorizationContextKey,
+			contextValue: usr,
+			request:      `{}`,
+			statusCode:   http.StatusInternalServerError,
+			response:     "Internal server error\n",
+		},
+		{
+			contextKey:   middleware.UserContextKey,
+			contextValue: usr,
+			request:      `wrong json`,
+			statusCode:   http.StatusInternalServerError,
+			response:     "",
+		},
+		{
+			contextKey:   middleware.UserContextKey,
+			contextValue: usr,
+			request:      `{"avatar":"javascript:alert(1)"}`,
+			statusCode:   http.StatusUnprocessableEntity,
+			response:     "{\"errors\":[{\"location\":\"body\",\"param\":\"avatar\",\"value\":\"javascript:alert(1)\",\"msg\":\"is invalid\"}]}\n",
+		},
+		{
+			contextKey:   middleware.UserContextKey,
+			contextValue: usr,
+			request:      `{"bio":"` + strings.Repeat("a", maxBioLen+1) + `"}`,
+			statusCode:   http.StatusUnprocessableEntity,
+			response:     "{\"errors\":[{\"location\":\"body\",\"param\":\"bio\",\"msg\":\"must be at most 500 characters long\"}]}\n",
+		},
+		{
+			contextKey:        middleware.UserContextKey,
+			contextValue:      usr,
+			request:           `{"bio":"new bio"}`,
+			bio:               "new bio",
+			userRepoUpdateErr: fmt.Errorf("test error"),
+			statusCode:        http.StatusInternalServerError,
+			response:          "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		userHandler := setupUser()
+		defer userHandler.Logger.Sync()
+
+		r := httptest.NewRequest("PATCH", "/api/user/me/profile", strings.NewReader(testCase.request))
+		ctx := r.Context()
+		ctx = context.WithValue(ctx, testCase.contextKey, testCase.contextValue)
+		w := httptest.NewRecorder()
+
+		userHandler.UserRepo.(*mocks.UserRepo).
+			On("Profile", usr.Username).
+			Return(testCase.userRepoProfile, nil)
+
+		userHandler.UserRepo.(*mocks.UserRepo).
+			On("UpdateProfile", usr, testCase.bio, testCase.avatar).
+			Return(testCase.userRepoUpdateErr)
+
+		userHandler.PostRepo.(*mocks.PostRepo).
+			On("Counts", usr).
+			Return(uint64(0), uint64(0), nil)
+
+		userHandler.ProfileUpdate(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		require.Equal(t, string(body), testCase.response)
+		if testCase.statusCode == http.StatusOK {
+			userHandler.UserRepo.(*mocks.UserRepo).AssertCalled(t, "UpdateProfile", usr, testCase.bio, testCase.avatar)
+		}
+	}
+}
+
+func TestPreferencesUpdate(t *testing.T) {
+	type testCase struct {
+		request string
+		stored  user.Preferences
+		updated user.Preferences
+
+		statusCode int
+		response   string
+	}
+
+	usr := user.User{Username: "test", UserID: 1}
+	testCases := []testCase{
+		{
+			request:    `{"nsfw":"blur"}`,
+			stored:     user.DefaultPreferences(),
+			updated:    user.Preferences{NSFW: user.ContentBlur, Spoiler: user.ContentBlur},
+			statusCode: http.StatusOK,
+			response:   `{"nsfw":"blur","spoiler":"blur"}`,
+		},
+		{
+			request:    `{"nsfw":"maybe","spoiler":""}`,
+			stored:     user.DefaultPreferences(),
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"nsfw\",\"value\":\"maybe\",\"msg\":\"must be one of show, blur, hide\"},{\"location\":\"body\",\"param\":\"spoiler\",\"value\":\"\",\"msg\":\"must be one of show, blur, hide\"}]}\n",
+		},
+		{
+			request:    `wrong json`,
+			statusCode: http.StatusInternalServerError,
+			response:   "",
+		},
+	}
+
+	for caseNum, testCase := range testCases {
+		userHandler := setupUser()
+		defer userHandler.Logger.Sync()
+
+		r := httptest.NewRequest("PATCH", "/api/user/me/preferences", strings.NewReader(testCase.request))
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, usr)
+		w := httptest.NewRecorder()
+
+		userHandler.UserRepo.(*mocks.UserRepo).
+			On("Preferences", usr.UserID).
+			Return(testCase.stored, nil)
+		userHandler.UserRepo.(*mocks.UserRepo).
+			On("UpdatePreferences", usr, testCase.updated).
+			Return(nil)
+
+		userHandler.PreferencesUpdate(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode, "case %d", caseNum)
+		require.Equal(t, string(body), testCase.response, "case %d", caseNum)
+		if testCase.statusCode != http.StatusOK {
+			userHandler.UserRepo.(*mocks.UserRepo).AssertNotCalled(t, "UpdatePreferences", mock.Anything, mock.Anything)
+		}
+	}
+}
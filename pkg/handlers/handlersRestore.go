@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"redditclone/pkg/errors"
+	"redditclone/pkg/frontendMessages"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/token"
+	"redditclone/pkg/user"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// canRestore reports whether usr may bring back an item of author deleted by
+// deletedBy at deletedAt: authors may undo their own removal within
+// RestoreWindow, admins may restore anything until it is purged.
+func (h *PostHandler) canRestore(usr, author, deletedBy user.User, deletedAt time.Time) (bool, error) {
+	if usr.Username == author.Username && usr.UserID == author.UserID &&
+		deletedBy.Username == author.Username && deletedBy.UserID == author.UserID &&
+		time.Since(deletedAt) <= h.RestoreWindow {
+		return true, nil
+	}
+	role, err := h.UserRepo.Role(usr.UserID)
+	if err != nil {
+		return false, err
+	}
+	return role == user.RoleAdmin, nil
+}
+
+func (h *PostHandler) restore(w http.ResponseWriter, r *http.Request, from string) error {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+	vars := mux.Vars(r)
+	postID, errGet := token.GetMapItemUint64(vars, "post_id")
+	if errGet != nil {
+		return errGet
+	}
+	_, isComment := vars["comment_id"]
+	var commentID uint64
+	if isComment {
+		commentID, errGet = token.GetMapItemUint64(vars, "comment_id")
+		if errGet != nil {
+			return errGet
+		}
+	}
+	locked := false
+	if isComment {
+		locked = h.PostRepo.Lock(postID)
+	} else {
+		locked = h.PostRepo.LockDeleted(postID)
+	}
+	if !locked {
+		frontendMessages.SendMessage(w,
+			"post not found",
+			http.StatusNotFound,
+			h.Logger, from,
+		)
+		return nil
+	}
+	defer h.PostRepo.Unlock(postID)
+	pst, errGet := h.PostRepo.Get(postID)
+	if errGet != nil {
+		return errGet
+	}
+	author, deletedBy, deletedAt := pst.Author, pst.DeletedBy, pst.DeletedAt
+	index := -1
+	if isComment {
+		for i, cmt := range pst.Comments {
+			if cmt.ID == commentID && cmt.DeletedAt != nil {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			frontendMessages.SendMessage(w,
+				"comment not found",
+				http.StatusNotFound,
+				h.Logger, from,
+			)
+			return nil
+		}
+		cmt := pst.Comments[index]
+		author, deletedBy, deletedAt = cmt.Author, cmt.DeletedBy, cmt.DeletedAt
+	}
+	allowed, errRole := h.canRestore(usr, author, *deletedBy, *deletedAt)
+	if errRole != nil {
+		return errRole
+	}
+	if !allowed {
+		frontendMessages.SendMessage(w,
+			"you can't restore this item",
+			http.StatusForbidden,
+			h.Logger, from,
+		)
+		return nil
+	}
+	if isComment {
+		pst.Comments[index].Restore()
+	} else {
+		pst.Restore()
+	}
+	if errUpdate := h.PostRepo.Update(pst); errUpdate != nil {
+		return errUpdate
+	}
+	if !isComment {
+		h.markCrossposts(postID, false)
+	}
+	pst.ForViewer(&usr)
+	res, errMarshal := json.Marshal(pst)
+	if errMarshal != nil {
+		return errors.ErrMarshal{Err: errMarshal}
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+	return nil
+}
+
+func (h *PostHandler) PostRestore(w http.ResponseWriter, r *http.Request) {
+	err := h.restore(w, r, "PostRestore")
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("postRestore: %w", err),
+		)
+	}
+}
+
+func (h *PostHandler) CommentRestore(w http.ResponseWriter, r *http.Request) {
+	err := h.restore(w, r, "CommentRestore")
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("commentRestore: %w", err),
+		)
+	}
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"redditclone/pkg/comment"
+	"redditclone/pkg/database/mocks"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/post"
+	"redditclone/pkg/user"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestore(t *testing.T) {
+	type testCase struct {
+		valueVars map[string]string
+		viewer    user.User
+
+		deletedBy user.User
+		deletedAt time.Time
+		role      string
+
+		postRepoLock bool
+		getError     error
+		updateError  error
+
+		statusCode int
+		response   string
+	}
+
+	author := user.User{Username: "test", UserID: 1}
+	admin := user.User{Username: "admin", UserID: 2}
+	moder := user.User{Username: "moder", UserID: 3}
+	now := time.Now()
+	testCases := []testCase{
+		{
+			valueVars:    map[string]string{"post_id": "1"},
+			viewer:       author,
+			deletedBy:    author,
+			deletedAt:    now,
+			postRepoLock: true,
+			statusCode:   http.StatusOK,
+		},
+		{
+			valueVars:    map[string]string{"post_id": "1", "comment_id": "5"},
+			viewer:       author,
+			deletedBy:    author,
+			deletedAt:    now,
+			postRepoLock: true,
+			statusCode:   http.StatusOK,
+		},
+		{
+			valueVars:    map[string]string{"post_id": "1"},
+			viewer:       author,
+			deletedBy:    author,
+			deletedAt:    now.Add(-48 * time.Hour),
+			role:         user.RoleUser,
+			postRepoLock: true,
+			statusCode:   http.StatusForbidden,
+			response:     "{\"message\":\"you can't restore this item\"}\n",
+		},
+		{
+			valueVars:    map[string]string{"post_id": "1"},
+			viewer:       author,
+			deletedBy:    moder,
+			deletedAt:    now,
+			role:         user.RoleUser,
+			postRepoLock: true,
+			statusCode:   http.StatusForbidden,
+			response:     "{\"message\":\"you can't restore this item\"}\n",
+		},
+		{
+			valueVars:    map[string]string{"post_id": "1", "comment_id": "5"},
+			viewer:       moder,
+			deletedBy:    moder,
+			deletedAt:    now,
+			role:         user.RoleModerator,
+			postRepoLock: true,
+			statusCode:   http.StatusForbidden,
+			response:     "{\"message\":\"you can't restore this item\"}\n",
+		},
+		{
+			valueVars:    map[string]string{"post_id": "1", "comment_id": "5"},
+			viewer:       admin,
+			deletedBy:    moder,
+			deletedAt:    now.Add(-48 * time.Hour),
+			role:         user.RoleAdmin,
+			postRepoLock: true,
+			statusCode:   http.StatusOK,
+		},
+		{
+			valueVars:    map[string]string{"post_id": "1", "comment_id": "6"},
+			viewer:       author,
+			deletedBy:    author,
+			deletedAt:    now,
+			postRepoLock: true,
+			statusCode:   http.StatusNotFound,
+			response:     "{\"message\":\"comment not found\"}\n",
+		},
+		{
+			valueVars:    map[string]string{"post_id": "1"},
+			viewer:       author,
+			postRepoLock: false,
+			statusCode:   http.StatusNotFound,
+			response:     "{\"message\":\"post not found\"}\n",
+		},
+		{
+			valueVars:    map[string]string{"post": "1"},
+			viewer:       author,
+			postRepoLock: true,
+			statusCode:   http.StatusInternalServerError,
+			response:     "",
+		},
+		{
+			valueVars:    map[string]string{"post_id": "1"},
+			viewer:       author,
+			postRepoLock: true,
+			getError:     fmt.Errorf("test error"),
+			statusCode:   http.StatusInternalServerError,
+			response:     "",
+		},
+		{
+			valueVars:    map[string]string{"post_id": "1"},
+			viewer:       author,
+			deletedBy:    author,
+			deletedAt:    now,
+			postRepoLock: true,
+			updateError:  fmt.Errorf("test error"),
+			statusCode:   http.StatusInternalServerError,
+			response:     "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		postHandler := setupPost()
+		postHandler.RestoreWindow = 24 * time.Hour
+		defer postHandler.Logger.Sync()
+
+		r := httptest.NewRequest("POST", "/api/post/1/restore", nil)
+		r = mux.SetURLVars(r, testCase.valueVars)
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, testCase.viewer)
+		w := httptest.NewRecorder()
+
+		_, isComment := testCase.valueVars["comment_id"]
+		pst := post.Post{
+			ID:       1,
+			Author:   author,
+			Comments: []comment.Comment{{ID: 5, Author: author}, {ID: 6, Author: author}},
+		}
+		deletedBy, deletedAt := testCase.deletedBy, testCase.deletedAt
+		if isComment {
+			pst.Comments[0].DeletedBy, pst.Comments[0].DeletedAt = &deletedBy, &deletedAt
+		} else {
+			pst.DeletedBy, pst.DeletedAt = &deletedBy, &deletedAt
+		}
+
+		postHandler.PostRepo.(*mocks.PostRepo).On("Lock", uint64(1)).Return(testCase.postRepoLock)
+		postHandler.PostRepo.(*mocks.PostRepo).On("LockDeleted", uint64(1)).Return(testCase.postRepoLock)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Unlock", uint64(1)).Return(true)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Get", uint64(1)).Return(pst, testCase.getError)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Update", mock.AnythingOfType("post.Post")).Return(testCase.updateError)
+		postHandler.PostRepo.(*mocks.PostRepo).On("MarkCrossposts", mock.AnythingOfType("uint64"), false).Return(nil)
+		postHandler.UserRepo.(*mocks.UserRepo).On("Role", testCase.viewer.UserID).Return(testCase.role, nil)
+
+		if isComment {
+			postHandler.CommentRestore(w, r.WithContext(ctx))
+		} else {
+			postHandler.PostRestore(w, r.WithContext(ctx))
+		}
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		if testCase.statusCode != http.StatusOK {
+			require.Equal(t, string(body), testCase.response)
+			continue
+		}
+		postHandler.PostRepo.(*mocks.PostRepo).AssertCalled(t, "Update",
+			mock.MatchedBy(func(pst post.Post) bool {
+				return pst.DeletedAt == nil && pst.Comments[0].DeletedAt == nil
+			}),
+		)
+	}
+}
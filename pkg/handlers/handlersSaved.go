@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"redditclone/pkg/database"
+	"redditclone/pkg/errors"
+	"redditclone/pkg/frontendMessages"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/post"
+	"redditclone/pkg/token"
+	"redditclone/pkg/user"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *PostHandler) setMark(w http.ResponseWriter, r *http.Request, kind string, add bool) error {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: UserContextKey")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+	vars := mux.Vars(r)
+	postID, errGet := token.GetMapItemUint64(vars, "post_id")
+	if errGet != nil {
+		return errors.ErrRequest{Err: errGet}
+	}
+	pst, errPost := h.PostRepo.Get(postID)
+	if errPost != nil || pst.DeletedAt != nil {
+		frontendMessages.SendMessage(w,
+			"post not found",
+			http.StatusNotFound,
+			h.Logger, "setMark",
+		)
+		return nil
+	}
+	// marks must not reveal posts the user can`t open
+	if _, visible := h.visiblePost(w, &pst, &usr, "setMark"); !visible {
+		return nil
+	}
+	var errMark error
+	if add {
+		errMark = h.PostMarks.Add(usr.UserID, postID, kind)
+	} else {
+		errMark = h.PostMarks.Remove(usr.UserID, postID, kind)
+	}
+	if errMark != nil {
+		return errMark
+	}
+	frontendMessages.SendMessage(w,
+		"success",
+		http.StatusOK,
+		h.Logger, "setMark",
+	)
+	return nil
+}
+
+func (h *PostHandler) PostSave(w http.ResponseWriter, r *http.Request) {
+	err := h.setMark(w, r, database.MarkSaved, true)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("postSave: %w", err),
+		)
+	}
+}
+
+func (h *PostHandler) PostUnsave(w http.ResponseWriter, r *http.Request) {
+	err := h.setMark(w, r, database.MarkSaved, false)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("postUnsave: %w", err),
+		)
+	}
+}
+
+func (h *PostHandler) PostHide(w http.ResponseWriter, r *http.Request) {
+	err := h.setMark(w, r, database.MarkHidden, true)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("postHide: %w", err),
+		)
+	}
+}
+
+func (h *PostHandler) PostUnhide(w http.ResponseWriter, r *http.Request) {
+	err := h.setMark(w, r, database.MarkHidden, false)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("postUnhide: %w", err),
+		)
+	}
+}
+
+func (h *PostHandler) SavedPosts(w http.ResponseWriter, r *http.Request) {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	saved, errSaved := h.PostMarks.List(usr.UserID, database.MarkSaved)
+	if errSaved != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("SavedPosts: can`t get saved posts: %w", errSaved),
+		)
+		return
+	}
+	if len(saved) == 0 {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("[]"))
+		return
+	}
+	postsStr, err := h.PostRepo.ToJson(post.Filter{IDs: saved, Viewer: &usr})
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("SavedPosts: %w", errors.ErrMarshal{Err: err}),
+		)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(postsStr)
+}
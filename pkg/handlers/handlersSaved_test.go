@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"redditclone/pkg/database"
+	"redditclone/pkg/database/mocks"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/post"
+	"redditclone/pkg/user"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostMarks(t *testing.T) {
+	type testCase struct {
+		kind string
+		add  bool
+
+		contextKey   middleware.Key
+		contextValue interface{}
+		valueVars    map[string]string
+		postID       uint64
+
+		postRepoGet      post.Post
+		postRepoGetError error
+		postMarksError   error
+
+		statusCode int
+		response   string
+	}
+
+	usr := user.User{Username: "test", UserID: 1}
+	publishAt := time.Date(2022, 5, 2, 18, 30, 0, 0, time.UTC)
+	testCases := []testCase{
+		{
+			kind:         database.MarkSaved,
+			add:          true,
+			contextKey:   middleware.UserContextKey,
+			contextValue: usr,
+			valueVars:    map[string]string{"post_id": "1"},
+			postID:       1,
+			postRepoGet:  post.Post{ID: 1},
+			statusCode:   http.StatusOK,
+			response:     "{\"message\":\"success\"}\n",
+		},
+		{
+			kind:         database.MarkSaved,
+			add:          false,
+			contextKey:   middleware.UserContextKey,
+			contextValue: usr,
+			valueVars:    map[string]string{"post_id": "1"},
+			postID:       1,
+			postRepoGet:  post.Post{ID: 1},
+			statusCode:   http.StatusOK,
+			response:     "{\"message\":\"success\"}\n",
+		},
+		{
+			kind:             database.MarkHidden,
+			add:              true,
+			contextKey:       middleware.UserContextKey,
+			contextValue:     usr,
+			valueVars:        map[string]string{"post_id": "1"},
+			postID:           1,
+			postRepoGetError: fmt.Errorf("not found"),
+			statusCode:       http.StatusNotFound,
+			response:         "{\"message\":\"post not found\"}\n",
+		},
+		{
+			kind:           database.MarkHidden,
+			add:            false,
+			contextKey:     middleware.UserContextKey,
+			contextValue:   usr,
+			valueVars:      map[string]string{"post_id": "1"},
+			postID:         1,
+			postRepoGet:    post.Post{ID: 1},
+			postMarksError: fmt.Errorf("test error"),
+			statusCode:     http.StatusInternalServerError,
+			response:       "",
+		},
+		{
+			kind:         database.MarkSaved,
+			add:          true,
+			contextKey:   middleware.UserContextKey,
+			contextValue: usr,
+			valueVars:    map[string]string{"post_id": "1"},
+			postID:       1,
+			postRepoGet:  post.Post{ID: 1, Author: user.User{Username: "author", UserID: 2}, PublishAt: &publishAt},
+			statusCode:   http.StatusNotFound,
+			response:     "{\"message\":\"post not found\"}\n",
+		},
+		{
+			kind:         database.MarkHidden,
+			add:          true,
+			contextKey:   middleware.UserContextKey,
+			contextValue: usr,
+			valueVars:    map[string]string{"post_id": "1"},
+			postID:       1,
+			postRepoGet:  post.Post{ID: 1, Author: user.User{Username: "blocked", UserID: 9}},
+			statusCode:   http.StatusNotFound,
+			response:     "{\"message\":\"post not found\"}\n",
+		},
+		{
+			kind:         database.MarkHidden,
+			add:          true,
+			contextKey:   middleware.AuthtorizationContextKey,
+			contextValue: usr,
+			valueVars:    map[string]string{"post_id": "1"},
+			postID:       1,
+			statusCode:   http.StatusInternalServerError,
+			response:     "Internal server error\n",
+		},
+		{
+			kind:         database.MarkSaved,
+			add:          true,
+			contextKey:   middleware.UserContextKey,
+			contextValue: usr,
+			valueVars:    map[string]string{"wrong vars": "1"},
+			statusCode:   http.StatusInternalServerError,
+			response:     "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		postHandler := setupPost()
+		defer postHandler.Logger.Sync()
+
+		r := httptest.NewRequest("POST", "/api/post/1/save", nil)
+		r = mux.SetURLVars(r, testCase.valueVars)
+		ctx := r.Context()
+		ctx = context.WithValue(ctx, testCase.contextKey, testCase.contextValue)
+		w := httptest.NewRecorder()
+
+		postHandler.PostRepo.(*mocks.PostRepo).
+			On("Get", testCase.postID).
+			Return(testCase.postRepoGet, testCase.postRepoGetError)
+		postHandler.Blocks.(*mocks.BlockRepo).
+			On("Blocks", usr).
+			Return([]user.User{{UserID: 9}}, nil)
+
+		postHandler.PostMarks.(*mocks.PostMarkRepo).
+			On("Add", usr.UserID, testCase.postID, testCase.kind).
+			Return(testCase.postMarksError)
+
+		postHandler.PostMarks.(*mocks.PostMarkRepo).
+			On("Remove", usr.UserID, testCase.postID, testCase.kind).
+			Return(testCase.postMarksError)
+
+		switch {
+		case testCase.kind == database.MarkSaved && testCase.add:
+			postHandler.PostSave(w, r.WithContext(ctx))
+		case testCase.kind == database.MarkSaved:
+			postHandler.PostUnsave(w, r.WithContext(ctx))
+		case testCase.add:
+			postHandler.PostHide(w, r.WithContext(ctx))
+		default:
+			postHandler.PostUnhide(w, r.WithContext(ctx))
+		}
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		require.Equal(t, string(body), testCase.response)
+	}
+}
+
+func TestSavedPosts(t *testing.T) {
+	type testCase struct {
+		contextKey middleware.Key
+
+		postMarksList  []uint64
+		postMarksError error
+
+		postRepoToJsonRes   []byte
+		postRepoToJsonError error
+
+		statusCode int
+		response   string
+	}
+
+	usr := user.User{Username: "test", UserID: 1}
+	testCases := []testCase{
+		{
+			contextKey:        middleware.UserContextKey,
+			postMarksList:     []uint64{2, 1},
+			postRepoToJsonRes: []byte("test result"),
+			statusCode:        http.StatusOK,
+			response:          "test result",
+		},
+		{
+			contextKey:    middleware.UserContextKey,
+			postMarksList: []uint64{},
+			statusCode:    http.StatusOK,
+			response:      "[]",
+		},
+		{
+			contextKey:     middleware.UserContextKey,
+			postMarksError: fmt.Errorf("test error"),
+			statusCode:     http.StatusInternalServerError,
+			response:       "",
+		},
+		{
+			contextKey:          middleware.UserContextKey,
+			postMarksList:       []uint64{2, 1},
+			postRepoToJsonError: fmt.Errorf("test error"),
+			statusCode:          http.StatusInternalServerError,
+			response:            "",
+		},
+		{
+			contextKey: middleware.AuthtorizationContextKey,
+			statusCode: http.StatusInternalServerError,
+			response:   "Internal server error\n",
+		},
+	}
+
+	for _, testCase := range testCases {
+		postHandler := setupPost()
+		defer postHandler.Logger.Sync()
+
+		r := httptest.NewRequest("GET", "/api/user/me/saved", nil)
+		ctx := r.Context()
+		ctx = context.WithValue(ctx, testCase.contextKey, usr)
+		w := httptest.NewRecorder()
+
+		postHandler.PostMarks.(*mocks.PostMarkRepo).
+			On("List", usr.UserID, database.MarkSaved).
+			Return(testCase.postMarksList, testCase.postMarksError)
+
+		postHandler.PostRepo.(*mocks.PostRepo).
+			On("ToJson", post.Filter{IDs: testCase.postMarksList, Viewer: &usr}).
+			Return(testCase.postRepoToJsonRes, testCase.postRepoToJsonError)
+
+		postHandler.SavedPosts(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		require.Equal(t, string(body), testCase.response)
+	}
+}
+
+func TestListingsExcludeHidden(t *testing.T) {
+	usr := user.User{Username: "test", UserID: 1}
+
+	postHandler := setupPost()
+	defer postHandler.Logger.Sync()
+
+	postHandler.PostMarks.(*mocks.PostMarkRepo).
+		On("List", usr.UserID, database.MarkHidden).
+		Return([]uint64{3}, nil)
+	prefs := user.Preferences{NSFW: user.ContentBlur, Spoiler: user.ContentShow}
+	postHandler.UserRepo.(*mocks.UserRepo).
+		On("Preferences", usr.UserID).
+		Return(prefs, nil)
+	postHandler.Blocks.(*mocks.BlockRepo).
+		On("Blocks", usr).
+		Return([]user.User{{Username: "troll", UserID: 9}}, nil)
+
+	postHandler.PostRepo.(*mocks.PostRepo).
+		On("ToJson", post.Filter{Exclude: []uint64{3}, Blocked: []int64{9}, Viewer: &usr, Preferences: prefs}).
+		Return([]byte("all"), nil)
+	postHandler.PostRepo.(*mocks.PostRepo).
+		On("ToJson", post.Filter{Category: "music", Exclude: []uint64{3}, Blocked: []int64{9}, Viewer: &usr, Preferences: prefs}).
+		Return([]byte("music"), nil)
+	postHandler.PostRepo.(*mocks.PostRepo).
+		On("ToJson", post.Filter{Username: "author", Exclude: []uint64{3}, Blocked: []int64{9}, Viewer: &usr, Preferences: prefs}).
+		Return([]byte("author"), nil)
+
+	handlers := []struct {
+		handler   http.HandlerFunc
+		valueVars map[string]string
+		response  string
+	}{
+		{postHandler.Posts, nil, "all"},
+		{postHandler.Categories, map[string]string{"category_name": "music"}, "music"},
+		{postHandler.UserPosts, map[string]string{"user_login": "author"}, "author"},
+	}
+	for _, handler := range handlers {
+		r := httptest.NewRequest("GET", "/api/posts/", nil)
+		r = mux.SetURLVars(r, handler.valueVars)
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, usr)
+		w := httptest.NewRecorder()
+
+		handler.handler(w, r.WithContext(ctx))
+
+		resp := w.Result()
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, http.StatusOK)
+		require.Equal(t, string(body), handler.response)
+	}
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"redditclone/pkg/errors"
+	"redditclone/pkg/frontendMessages"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/post"
+	"redditclone/pkg/token"
+	"redditclone/pkg/user"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func isAuthor(usr, author user.User) bool {
+	return usr.Username == author.Username && usr.UserID == author.UserID
+}
+
+// PublishScheduled publishes posts whose time has come and announces them
+// like new ones.
+func (h *PostHandler) PublishScheduled(now time.Time) {
+	published, err := h.PostRepo.Publish(now)
+	if err != nil {
+		h.Logger.Errorf("publishScheduled: %s", err)
+	}
+	for _, pst := range published {
+		h.Logger.Debugf("publishing scheduled post with id: %d", pst.ID)
+		h.announce(pst)
+	}
+}
+
+func (h *PostHandler) ScheduledPosts(w http.ResponseWriter, r *http.Request) {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	postsStr, err := h.PostRepo.ToJson(post.Filter{Username: usr.Username, Scheduled: true, Viewer: &usr})
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("ScheduledPosts: %w", errors.ErrMarshal{Err: err}),
+		)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(postsStr)
+}
+
+// lockScheduled locks the scheduled post of usr from the request vars and
+// returns it, on false the response was already sent and nothing is locked.
+func (h *PostHandler) lockScheduled(w http.ResponseWriter, r *http.Request, usr user.User, from string) (post.Post, bool, error) {
+	postID, errGet := token.GetMapItemUint64(mux.Vars(r), "post_id")
+	if errGet != nil {
+		return post.Post{}, false, errors.ErrRequest{Err: errGet}
+	}
+	if !h.PostRepo.Lock(postID) {
+		frontendMessages.SendMessage(w,
+			"post not found",
+			http.StatusNotFound,
+			h.Logger, from,
+		)
+		return post.Post{}, false, nil
+	}
+	pst, errGet := h.PostRepo.Get(postID)
+	if errGet != nil {
+		h.PostRepo.Unlock(postID)
+		return post.Post{}, false, errGet
+	}
+	if !isAuthor(usr, pst.Author) {
+		h.PostRepo.Unlock(postID)
+		frontendMessages.SendMessage(w,
+			"this post doesn't belong to this user",
+			http.StatusNotFound,
+			h.Logger, from,
+		)
+		return post.Post{}, false, nil
+	}
+	if pst.PublishAt == nil {
+		h.PostRepo.Unlock(postID)
+		frontendMessages.SendMessage(w,
+			"post is already published",
+			http.StatusConflict,
+			h.Logger, from,
+		)
+		return post.Post{}, false, nil
+	}
+	return pst, true, nil
+}
+
+func (h *PostHandler) scheduledEdit(w http.ResponseWriter, r *http.Request) error {
+	body, _ := ioutil.ReadAll(r.Body)
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+	readEdit := struct {
+		Title     *string    `json:"title"`
+		Text      *string    `json:"text"`
+		Category  *string    `json:"category"`
+		PublishAt *time.Time `json:"publishAt"`
+	}{}
+	if errUnmarshal := json.Unmarshal(body, &readEdit); errUnmarshal != nil {
+		return errors.ErrUnmarshalRequest{Err: errUnmarshal}
+	}
+	errs := []frontendMessages.ErrorMessage{}
+	if readEdit.Title != nil && strings.TrimSpace(*readEdit.Title) == "" {
+		errs = append(errs, frontendMessages.ErrorMessage{
+			Location: "body",
+			Param:    "title",
+			Message:  "is required",
+		})
+	}
+	if readEdit.Category != nil && *readEdit.Category == "" {
+		errs = append(errs, frontendMessages.ErrorMessage{
+			Location: "body",
+			Param:    "category",
+			Message:  "is required",
+		})
+	}
+	if readEdit.PublishAt != nil && !readEdit.PublishAt.After(time.Now()) {
+		errs = append(errs, frontendMessages.ErrorMessage{
+			Location: "body",
+			Param:    "publishAt",
+			Value:    readEdit.PublishAt,
+			Message:  "must be in the future",
+		})
+	}
+	if len(errs) != 0 {
+		frontendMessages.SendError(w, errs, http.StatusUnprocessableEntity, h.Logger, "scheduledEdit")
+		return nil
+	}
+	pst, ok, err := h.lockScheduled(w, r, usr, "scheduledEdit")
+	if !ok {
+		return err
+	}
+	if readEdit.Title != nil {
+		pst.Title = *readEdit.Title
+	}
+	if readEdit.Text != nil && pst.Type == post.TypeText {
+		pst.Text = *readEdit.Text
+		pst.Mentions = h.mentions(pst.Text, usr)
+		pst.Render()
+	}
+	if readEdit.Category != nil {
+		pst.Category = *readEdit.Category
+	}
+	if readEdit.PublishAt != nil {
+		pst.PublishAt = readEdit.PublishAt
+	}
+	// the link can't be edited, so its preview stays valid
+	prv := pst.Preview
+	ok, err = h.preparePost(w, &pst, "scheduledEdit")
+	if !ok {
+		h.PostRepo.Unlock(pst.ID)
+		return err
+	}
+	pst.Preview = prv
+	errUpdate := h.PostRepo.Update(pst)
+	if errUpdate != nil {
+		h.PostRepo.Unlock(pst.ID)
+		return errUpdate
+	}
+	if !h.PostRepo.Unlock(pst.ID) {
+		return fmt.Errorf("can`t unlock post")
+	}
+	pst.ForViewer(&usr)
+	res, errMarshal := json.Marshal(pst)
+	if errMarshal != nil {
+		return errors.ErrMarshal{Err: errMarshal}
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+	return nil
+}
+
+func (h *PostHandler) ScheduledEdit(w http.ResponseWriter, r *http.Request) {
+	err := h.scheduledEdit(w, r)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("scheduledEdit: %w", err),
+		)
+	}
+}
+
+func (h *PostHandler) scheduledCancel(w http.ResponseWriter, r *http.Request) error {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: %s", middleware.UserContextKey)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+	pst, ok, err := h.lockScheduled(w, r, usr, "scheduledCancel")
+	if !ok {
+		return err
+	}
+	pst.MarkDeleted(usr, "")
+	errUpdate := h.PostRepo.Update(pst)
+	h.PostRepo.Unlock(pst.ID)
+	if errUpdate != nil {
+		return errUpdate
+	}
+	frontendMessages.SendMessage(w,
+		"success",
+		http.StatusOK,
+		h.Logger, "scheduledCancel",
+	)
+	return nil
+}
+
+func (h *PostHandler) ScheduledCancel(w http.ResponseWriter, r *http.Request) {
+	err := h.scheduledCancel(w, r)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("scheduledCancel: %w", err),
+		)
+	}
+}
@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"redditclone/pkg/database/mocks"
+	"redditclone/pkg/events"
+	"redditclone/pkg/flair"
+	"redditclone/pkg/middleware"
+	"redditclone/pkg/notification"
+	"redditclone/pkg/post"
+	"redditclone/pkg/preview"
+	"redditclone/pkg/user"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostAddScheduled(t *testing.T) {
+	author := user.User{Username: "test", UserID: 1}
+	alice := user.User{Username: "alice", UserID: 2}
+	publishAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	postHandler := setupPost()
+	defer postHandler.Logger.Sync()
+	setupMentionUsers(postHandler, alice)
+	sub := postHandler.Events.SubscribeCategory("")
+	defer postHandler.Events.Unsubscribe(sub)
+
+	r := httptest.NewRequest("POST", "/api/posts", strings.NewReader(
+		`{"type":"text","title":"title","text":"hi @alice","category":"music","publishAt":"`+publishAt.Format(time.RFC3339)+`"}`,
+	))
+	ctx := context.WithValue(r.Context(), middleware.UserContextKey, author)
+	w := httptest.NewRecorder()
+
+	postHandler.PostRepo.(*mocks.PostRepo).
+		On("Add", mock.AnythingOfType("*post.Post")).
+		Return(nil)
+
+	postHandler.PostAdd(w, r.WithContext(ctx))
+
+	require.Equal(t, w.Result().StatusCode, http.StatusOK)
+	added := postHandler.PostRepo.(*mocks.PostRepo).Calls[0].Arguments.Get(0).(*post.Post)
+	require.Equal(t, *added.PublishAt, publishAt)
+	require.Equal(t, added.Mentions, []user.User{alice})
+	postHandler.Notifications.(*mocks.NotificationRepo).AssertNotCalled(t, "Add", mock.Anything)
+	require.Len(t, sub.Events, 0)
+
+	r = httptest.NewRequest("POST", "/api/posts", strings.NewReader(
+		`{"type":"text","title":"title","category":"music","publishAt":"2000-01-01T00:00:00Z"}`,
+	))
+	w = httptest.NewRecorder()
+	postHandler.PostAdd(w, r.WithContext(ctx))
+
+	body, errRead := ioutil.ReadAll(w.Result().Body)
+	require.NoError(t, errRead)
+	require.Equal(t, w.Result().StatusCode, http.StatusUnprocessableEntity)
+	require.Equal(t, string(body), "{\"errors\":[{\"location\":\"body\",\"param\":\"publishAt\",\"value\":\"2000-01-01T00:00:00Z\",\"msg\":\"must be in the future\"}]}\n")
+}
+
+func TestPostGetScheduled(t *testing.T) {
+	type testCase struct {
+		viewer     *user.User
+		statusCode int
+	}
+
+	author := user.User{Username: "author", UserID: 1}
+	publishAt := time.Now().Add(time.Hour)
+
+	testCases := []testCase{
+		{viewer: nil, statusCode: http.StatusNotFound},
+		{viewer: &user.User{Username: "other", UserID: 2}, statusCode: http.StatusNotFound},
+		{viewer: &user.User{Username: "author", UserID: 3}, statusCode: http.StatusNotFound},
+		{viewer: &author, statusCode: http.StatusOK},
+	}
+
+	for _, testCase := range testCases {
+		postHandler := setupPost()
+		defer postHandler.Logger.Sync()
+
+		r := httptest.NewRequest("GET", "/api/post/3", nil)
+		r = mux.SetURLVars(r, map[string]string{"post_id": "3"})
+		if testCase.viewer != nil {
+			r = r.WithContext(context.WithValue(r.Context(), middleware.UserContextKey, *testCase.viewer))
+		}
+		w := httptest.NewRecorder()
+
+		postHandler.PostRepo.(*mocks.PostRepo).On("Lock", uint64(3)).Return(true)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Get", uint64(3)).Return(post.Post{ID: 3, Author: author, PublishAt: &publishAt}, nil)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Update", mock.AnythingOfType("post.Post")).Return(nil)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Unlock", uint64(3)).Return(true)
+		postHandler.Blocks.(*mocks.BlockRepo).On("Blocks", author).Return([]user.User{}, nil)
+
+		postHandler.PostGet(w, r)
+
+		require.Equal(t, w.Result().StatusCode, testCase.statusCode)
+	}
+}
+
+func TestScheduledEdit(t *testing.T) {
+	type testCase struct {
+		handler func(h *PostHandler) http.HandlerFunc
+		viewer  user.User
+		body    string
+
+		postRepoLockStatus  bool
+		postRepoGet         post.Post
+		postRepoUpdateError error
+
+		statusCode int
+		response   string
+		updated    func(pst post.Post) bool
+	}
+
+	author := user.User{Username: "author", UserID: 1}
+	publishAt := time.Now().Add(time.Hour)
+	later := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Second)
+	scheduled := post.Post{ID: 3, Author: author, Type: post.TypeText, Title: "old", Category: "music", PublishAt: &publishAt}
+	flaired := scheduled
+	flaired.Flair = &flair.Flair{Text: "Live", Color: "#ff0000"}
+	linked := scheduled
+	linked.Type, linked.URL, linked.Domain = post.TypeLink, "https://example.com/a", "example.com"
+	linked.Preview = &preview.Preview{Title: "Example"}
+	edit := func(h *PostHandler) http.HandlerFunc { return h.ScheduledEdit }
+	cancel := func(h *PostHandler) http.HandlerFunc { return h.ScheduledCancel }
+
+	testCases := []testCase{
+		{
+			handler:            edit,
+			viewer:             author,
+			body:               `{"title":"new","category":"news","publishAt":"` + later.Format(time.RFC3339) + `"}`,
+			postRepoLockStatus: true,
+			postRepoGet:        scheduled,
+			statusCode:         http.StatusOK,
+			updated: func(pst post.Post) bool {
+				return pst.Title == "new" && pst.Category == "news" && pst.PublishAt.Equal(later)
+			},
+		},
+		{
+			handler:            edit,
+			viewer:             author,
+			body:               `{"title":"new"}`,
+			postRepoLockStatus: true,
+			postRepoGet:        linked,
+			statusCode:         http.StatusOK,
+			updated: func(pst post.Post) bool {
+				return pst.Title == "new" && pst.Preview != nil && pst.Preview.Title == "Example"
+			},
+		},
+		{
+			handler:            edit,
+			viewer:             author,
+			body:               `{"category":"news"}`,
+			postRepoLockStatus: true,
+			postRepoGet:        flaired,
+			statusCode:         http.StatusUnprocessableEntity,
+			response:           "{\"errors\":[{\"location\":\"body\",\"param\":\"flair\",\"value\":\"Live\",\"msg\":\"is not defined in this category\"}]}\n",
+		},
+		{
+			handler:    edit,
+			viewer:     author,
+			body:       `{"title":" ","publishAt":"2000-01-01T00:00:00Z"}`,
+			statusCode: http.StatusUnprocessableEntity,
+			response:   "{\"errors\":[{\"location\":\"body\",\"param\":\"title\",\"msg\":\"is required\"},{\"location\":\"body\",\"param\":\"publishAt\",\"value\":\"2000-01-01T00:00:00Z\",\"msg\":\"must be in the future\"}]}\n",
+		},
+		{
+			handler:            edit,
+			viewer:             user.User{Username: "other", UserID: 2},
+			body:               `{"title":"new"}`,
+			postRepoLockStatus: true,
+			postRepoGet:        scheduled,
+			statusCode:         http.StatusNotFound,
+			response:           "{\"message\":\"this post doesn't belong to this user\"}\n",
+		},
+		{
+			handler:            edit,
+			viewer:             author,
+			body:               `{"title":"new"}`,
+			postRepoLockStatus: true,
+			postRepoGet:        post.Post{ID: 3, Author: author},
+			statusCode:         http.StatusConflict,
+			response:           "{\"message\":\"post is already published\"}\n",
+		},
+		{
+			handler:            edit,
+			viewer:             author,
+			body:               `{"title":"new"}`,
+			postRepoLockStatus: false,
+			statusCode:         http.StatusNotFound,
+			response:           "{\"message\":\"post not found\"}\n",
+		},
+		{
+			handler:             edit,
+			viewer:              author,
+			body:                `{"title":"new"}`,
+			postRepoLockStatus:  true,
+			postRepoGet:         scheduled,
+			postRepoUpdateError: fmt.Errorf("test error"),
+			statusCode:          http.StatusInternalServerError,
+			response:            "",
+		},
+		{
+			handler:            cancel,
+			viewer:             author,
+			postRepoLockStatus: true,
+			postRepoGet:        scheduled,
+			statusCode:         http.StatusOK,
+			response:           "{\"message\":\"success\"}\n",
+			updated: func(pst post.Post) bool {
+				return pst.DeletedAt != nil && *pst.DeletedBy == author
+			},
+		},
+		{
+			handler:            cancel,
+			viewer:             author,
+			postRepoLockStatus: true,
+			postRepoGet:        post.Post{ID: 3, Author: author},
+			statusCode:         http.StatusConflict,
+			response:           "{\"message\":\"post is already published\"}\n",
+		},
+	}
+
+	for _, testCase := range testCases {
+		postHandler := setupPost()
+		defer postHandler.Logger.Sync()
+		setupMentionUsers(postHandler)
+
+		r := httptest.NewRequest("PUT", "/api/post/3/schedule", strings.NewReader(testCase.body))
+		r = mux.SetURLVars(r, map[string]string{"post_id": "3"})
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, testCase.viewer)
+		w := httptest.NewRecorder()
+
+		postHandler.PostRepo.(*mocks.PostRepo).On("Lock", uint64(3)).Return(testCase.postRepoLockStatus)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Get", uint64(3)).Return(testCase.postRepoGet, nil)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Update", mock.AnythingOfType("post.Post")).Return(testCase.postRepoUpdateError)
+		postHandler.PostRepo.(*mocks.PostRepo).On("Unlock", uint64(3)).Return(true)
+		postHandler.CategoryRepo.(*mocks.CategoryRepo).On("Flairs", "news").Return([]flair.Flair{}, nil)
+
+		testCase.handler(postHandler)(w, r.WithContext(ctx))
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		if testCase.updated == nil {
+			require.Equal(t, string(body), testCase.response)
+			if testCase.postRepoUpdateError == nil {
+				postHandler.PostRepo.(*mocks.PostRepo).AssertNotCalled(t, "Update", mock.Anything)
+			}
+			continue
+		}
+		postHandler.PostRepo.(*mocks.PostRepo).AssertCalled(t, "Update", mock.MatchedBy(testCase.updated))
+	}
+}
+
+func TestPublishScheduled(t *testing.T) {
+	alice := user.User{Username: "alice", UserID: 2}
+	now := time.Now()
+
+	postHandler := setupPost()
+	defer postHandler.Logger.Sync()
+	setupMentionUsers(postHandler)
+	sub := postHandler.Events.SubscribeCategory("music")
+	defer postHandler.Events.Unsubscribe(sub)
+
+	postHandler.PostRepo.(*mocks.PostRepo).On("Publish", now).Return([]post.Post{{
+		ID:       3,
+		Author:   user.User{Username: "author", UserID: 1},
+		Category: "music",
+		Title:    "title",
+		Mentions: []user.User{alice},
+	}}, nil)
+
+	postHandler.PublishScheduled(now)
+
+	requireNotified(t, postHandler, []notification.Notification{
+		{UserID: alice.UserID, Kind: notification.KindPostMention, PostID: 3},
+	})
+	evt := <-sub.Events
+	require.Equal(t, evt.Type, events.PostCreated)
+	require.Equal(t, evt.PostID, uint64(3))
+
+	postHandler.PostRepo.(*mocks.PostRepo).On("Publish", now.Add(time.Minute)).Return([]post.Post{
+		{ID: 4, Category: "music", Title: "nsfw", NSFW: true},
+		{ID: 5, Category: "music", Title: "spoiler", Spoiler: true},
+	}, nil)
+
+	postHandler.PublishScheduled(now.Add(time.Minute))
+
+	evt = <-sub.Events
+	require.Equal(t, evt.PostID, uint64(5))
+	require.True(t, evt.Data.(post.Post).Blurred)
+}
@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"redditclone/pkg/database/mocks"
+	"redditclone/pkg/events"
+	"redditclone/pkg/mailer"
 
 	"go.uber.org/zap"
 )
@@ -11,9 +13,17 @@ func setupUser() *UserHandler {
 	logger := zapLogger.Sugar()
 
 	return &UserHandler{
-		Logger:    logger,
-		UserRepo:  &mocks.UserRepo{},
-		SecretKey: "test key",
+		Logger:        logger,
+		UserRepo:      &mocks.UserRepo{},
+		PostRepo:      &mocks.PostRepo{},
+		PostMarks:     &mocks.PostMarkRepo{},
+		Messages:      &mocks.MessageRepo{},
+		Drafts:        &mocks.DraftRepo{},
+		Notifications: &mocks.NotificationRepo{},
+		Tokens:        &mocks.TokenRepo{},
+		Mailer:        mailer.NewMemoryMailer(),
+		SiteURL:       "http://localhost:8080",
+		SecretKey:     "test key",
 	}
 }
 
@@ -22,8 +32,60 @@ func setupPost() *PostHandler {
 	logger := zapLogger.Sugar()
 
 	return &PostHandler{
-		Logger:    logger,
-		PostRepo:  &mocks.PostRepo{},
-		SecretKey: "test key",
+		Logger:        logger,
+		PostRepo:      &mocks.PostRepo{},
+		UserRepo:      &mocks.UserRepo{},
+		PostMarks:     &mocks.PostMarkRepo{},
+		Notifications: &mocks.NotificationRepo{},
+		Drafts:        &mocks.DraftRepo{},
+		CategoryRepo:  &mocks.CategoryRepo{},
+		Blocks:        &mocks.BlockRepo{},
+		Events:        events.NewHub(),
+		SecretKey:     "test key",
+	}
+}
+
+func setupNotification() *NotificationHandler {
+	zapLogger := zap.NewNop()
+	logger := zapLogger.Sugar()
+
+	return &NotificationHandler{
+		Logger:        logger,
+		Notifications: &mocks.NotificationRepo{},
+	}
+}
+
+func setupCategory() *CategoryHandler {
+	zapLogger := zap.NewNop()
+	logger := zapLogger.Sugar()
+
+	return &CategoryHandler{
+		Logger:       logger,
+		UserRepo:     &mocks.UserRepo{},
+		CategoryRepo: &mocks.CategoryRepo{},
+	}
+}
+
+func setupMessage() *MessageHandler {
+	zapLogger := zap.NewNop()
+	logger := zapLogger.Sugar()
+
+	return &MessageHandler{
+		Logger:   logger,
+		UserRepo: &mocks.UserRepo{},
+		Messages: &mocks.MessageRepo{},
+	}
+}
+
+func setupModeration() *ModerationHandler {
+	zapLogger := zap.NewNop()
+	logger := zapLogger.Sugar()
+
+	return &ModerationHandler{
+		Logger:        logger,
+		PostRepo:      &mocks.PostRepo{},
+		UserRepo:      &mocks.UserRepo{},
+		Reports:       &mocks.ReportRepo{},
+		HideThreshold: 2,
 	}
 }
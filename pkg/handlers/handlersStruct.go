@@ -1,19 +1,73 @@
 package handlers
 
 import (
+	"redditclone/pkg/blob"
 	"redditclone/pkg/database"
+	"redditclone/pkg/events"
+	"redditclone/pkg/mailer"
+	"redditclone/pkg/preview"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 type UserHandler struct {
-	Logger    *zap.SugaredLogger
-	UserRepo  database.UserRepo
+	Logger        *zap.SugaredLogger
+	UserRepo      database.UserRepo
+	PostRepo      database.PostRepo
+	PostMarks     database.PostMarkRepo
+	Messages      database.MessageRepo
+	Drafts        database.DraftRepo
+	Notifications database.NotificationRepo
+	Tokens        database.TokenRepo
+	Mailer        mailer.Mailer
+	// SiteURL starts links in letters, without a trailing slash
+	SiteURL   string
 	SecretKey string
 }
 
 type PostHandler struct {
-	Logger    *zap.SugaredLogger
-	PostRepo  database.PostRepo
-	SecretKey string
+	Logger        *zap.SugaredLogger
+	PostRepo      database.PostRepo
+	UserRepo      database.UserRepo
+	PostMarks     database.PostMarkRepo
+	Notifications database.NotificationRepo
+	Drafts        database.DraftRepo
+	CategoryRepo  database.CategoryRepo
+	Blocks        database.BlockRepo
+	Events        *events.Hub
+	RestoreWindow time.Duration
+	// DuplicateWindow is how long a link counts as a repost in its category,
+	// zero disables the check
+	DuplicateWindow  time.Duration
+	RejectDuplicates bool
+	Previews         preview.Fetcher
+	Images           blob.Store
+	MaxImageSize     int64
+	SecretKey        string
+}
+
+type NotificationHandler struct {
+	Logger        *zap.SugaredLogger
+	Notifications database.NotificationRepo
+}
+
+type CategoryHandler struct {
+	Logger       *zap.SugaredLogger
+	UserRepo     database.UserRepo
+	CategoryRepo database.CategoryRepo
+}
+
+type MessageHandler struct {
+	Logger   *zap.SugaredLogger
+	UserRepo database.UserRepo
+	Messages database.MessageRepo
+}
+
+type ModerationHandler struct {
+	Logger        *zap.SugaredLogger
+	PostRepo      database.PostRepo
+	UserRepo      database.UserRepo
+	Reports       database.ReportRepo
+	HideThreshold int64
 }
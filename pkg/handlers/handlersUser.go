@@ -16,6 +16,7 @@ import (
 type userJson struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Email    string `json:"email"`
 }
 
 type sendToken struct {
@@ -27,6 +28,7 @@ func (u userJson) ToUser() user.User {
 		Username:     u.Username,
 		PasswordHash: user.GetPasswordHash(u.Password),
 		UserID:       0,
+		Email:        u.Email,
 	}
 }
 
@@ -65,6 +67,17 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, string(res), http.StatusUnprocessableEntity)
 		return
 	}
+	if usr.Email != "" {
+		if problem := h.emailProblem(usr.Email, 0); problem != "" {
+			frontendMessages.SendError(w, []frontendMessages.ErrorMessage{{
+				Location: "body",
+				Param:    "email",
+				Value:    usr.Email,
+				Message:  problem,
+			}}, http.StatusUnprocessableEntity, h.Logger, "registration")
+			return
+		}
+	}
 	errAdd := h.UserRepo.Add(&usr)
 	if errAdd != nil {
 		errors.SendHttpError(
@@ -81,7 +94,7 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 		)
 		return
 	}
-	errAuth := auth.AddAuth(w)
+	errAuth := auth.AddAuth(w, usr.UserID)
 	if errAuth != nil {
 		errors.SendHttpError(
 			h.Logger, w,
@@ -97,6 +110,11 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 		)
 		return
 	}
+	if usr.Email != "" {
+		if errSend := h.sendVerification(usr, usr.Email); errSend != nil {
+			h.Logger.Errorf("registration: can`t send verification: %s", errSend)
+		}
+	}
 	h.Logger.Infof(`registered user: "%s", userID: "%d"`, usr.Username, usr.UserID)
 	w.WriteHeader(http.StatusCreated)
 	w.Write(send)
@@ -147,7 +165,7 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		)
 		return
 	}
-	errAuth := auth.AddAuth(w)
+	errAuth := auth.AddAuth(w, userGet.UserID)
 	if errAuth != nil {
 		errors.SendHttpError(
 			h.Logger, w,
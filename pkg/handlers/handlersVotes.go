@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"redditclone/pkg/errors"
+	"redditclone/pkg/events"
 	"redditclone/pkg/frontendMessages"
 	"redditclone/pkg/middleware"
 	"redditclone/pkg/token"
@@ -13,6 +14,24 @@ import (
 	"github.com/gorilla/mux"
 )
 
+func changeVote(votes []frontendMessages.Vote, userID int64, value int) ([]frontendMessages.Vote, int) {
+	for i, vt := range votes {
+		if vt.UserID != userID {
+			continue
+		}
+		delta := value - vt.Vote
+		if value == 0 {
+			return token.RemoveInArr(votes, uint(i)), delta
+		}
+		votes[i].Vote = value
+		return votes, delta
+	}
+	if value == 0 {
+		return votes, 0
+	}
+	return append(votes, frontendMessages.Vote{UserID: userID, Vote: value}), value
+}
+
 func (h *PostHandler) setVoice(w http.ResponseWriter, r *http.Request, value int) error {
 	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
 	if !ok {
@@ -33,25 +52,17 @@ func (h *PostHandler) setVoice(w http.ResponseWriter, r *http.Request, value int
 		)
 		return nil
 	}
-	flag := value != 0
 	pst, errGet := h.PostRepo.Get(postID)
 	if errGet != nil {
 		h.PostRepo.Unlock(postID)
 		return errGet
 	}
-	for i, vt := range pst.Votes {
-		if vt.UserID == usr.UserID {
-			if flag {
-				flag = false
-				pst.Votes[i].Vote = value
-				break
-			}
-			pst.Votes = token.RemoveInArr(pst.Votes, uint(i))
-		}
-	}
-	if flag {
-		pst.Votes = append(pst.Votes, frontendMessages.Vote{UserID: usr.UserID, Vote: value})
+	if h.closedPost(w, &pst, usr, "setVoice") {
+		h.PostRepo.Unlock(postID)
+		return nil
 	}
+	var delta int
+	pst.Votes, delta = changeVote(pst.Votes, usr.UserID, value)
 	pst.GetVotes()
 	errUpdate := h.PostRepo.Update(pst)
 	if errUpdate != nil {
@@ -62,6 +73,22 @@ func (h *PostHandler) setVoice(w http.ResponseWriter, r *http.Request, value int
 	if !ok {
 		return fmt.Errorf("can`t unlock post")
 	}
+	if delta != 0 {
+		// own votes never count, the automatic upvote of the author included
+		if !isAuthor(usr, pst.Author) {
+			errKarma := h.UserRepo.AddKarma(pst.Author.UserID, int64(delta), 0)
+			if errKarma != nil {
+				h.Logger.Errorf("setVoice: can`t change karma of user %d: %s", pst.Author.UserID, errKarma)
+			}
+		}
+		h.Events.Publish(events.Event{
+			Type:     events.VoteChanged,
+			PostID:   pst.ID,
+			Category: pst.Category,
+			Data:     events.Vote{Score: pst.Score, UpvotePercentage: pst.UpvotePercentage},
+		})
+	}
+	pst.ForViewer(&usr)
 	res, err := json.Marshal(pst)
 	if err != nil {
 		return errors.ErrMarshal{Err: err}
@@ -100,3 +127,113 @@ func (h *PostHandler) PostRatingDefault(w http.ResponseWriter, r *http.Request)
 		)
 	}
 }
+
+func (h *PostHandler) setCommentVoice(w http.ResponseWriter, r *http.Request, value int) error {
+	usr, ok := r.Context().Value(middleware.UserContextKey).(user.User)
+	if !ok {
+		h.Logger.Errorf("no context value: UserContextKey")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+	vars := mux.Vars(r)
+	postID, errGet := token.GetMapItemUint64(vars, "post_id")
+	if errGet != nil {
+		return errGet
+	}
+	commentID, errGet := token.GetMapItemUint64(vars, "comment_id")
+	if errGet != nil {
+		return errGet
+	}
+	if !h.PostRepo.Lock(postID) {
+		frontendMessages.SendMessage(w,
+			"post not found",
+			http.StatusNotFound,
+			h.Logger, "setCommentVoice",
+		)
+		return nil
+	}
+	pst, errGet := h.PostRepo.Get(postID)
+	if errGet != nil {
+		h.PostRepo.Unlock(postID)
+		return errGet
+	}
+	if h.closedPost(w, &pst, usr, "setCommentVoice") {
+		h.PostRepo.Unlock(postID)
+		return nil
+	}
+	index := commentIndex(pst, commentID)
+	if index == -1 {
+		h.PostRepo.Unlock(postID)
+		frontendMessages.SendMessage(w,
+			"comment not found",
+			http.StatusNotFound,
+			h.Logger, "setCommentVoice",
+		)
+		return nil
+	}
+	cmt := &pst.Comments[index]
+	var delta int
+	cmt.Votes, delta = changeVote(cmt.Votes, usr.UserID, value)
+	cmt.GetVotes()
+	errUpdate := h.PostRepo.Update(pst)
+	if errUpdate != nil {
+		h.PostRepo.Unlock(postID)
+		return errUpdate
+	}
+	ok = h.PostRepo.Unlock(postID)
+	if !ok {
+		return fmt.Errorf("can`t unlock post")
+	}
+	if delta != 0 {
+		if !isAuthor(usr, cmt.Author) {
+			errKarma := h.UserRepo.AddKarma(cmt.Author.UserID, 0, int64(delta))
+			if errKarma != nil {
+				h.Logger.Errorf("setCommentVoice: can`t change karma of user %d: %s", cmt.Author.UserID, errKarma)
+			}
+		}
+		h.Events.Publish(events.Event{
+			Type:     events.VoteChanged,
+			PostID:   pst.ID,
+			Category: pst.Category,
+			Data:     events.Vote{CommentID: &commentID, Score: cmt.Score},
+		})
+	}
+	pst.ForViewer(&usr)
+	res, err := json.Marshal(pst)
+	if err != nil {
+		return errors.ErrMarshal{Err: err}
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+	return nil
+}
+
+func (h *PostHandler) CommentRatingUp(w http.ResponseWriter, r *http.Request) {
+	err := h.setCommentVoice(w, r, 1)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("commentRatingUp: %w", err),
+		)
+	}
+}
+
+func (h *PostHandler) CommentRatingDown(w http.ResponseWriter, r *http.Request) {
+	err := h.setCommentVoice(w, r, -1)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("commentRatingDown: %w", err),
+		)
+	}
+}
+
+func (h *PostHandler) CommentRatingDefault(w http.ResponseWriter, r *http.Request) {
+	err := h.setCommentVoice(w, r, 0)
+	if err != nil {
+		errors.SendHttpError(
+			h.Logger, w,
+			fmt.Errorf("commentRatingDefault: %w", err),
+		)
+	}
+}
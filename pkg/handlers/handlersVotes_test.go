@@ -6,12 +6,14 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"redditclone/pkg/comment"
 	"redditclone/pkg/database/mocks"
 	"redditclone/pkg/frontendMessages"
 	"redditclone/pkg/middleware"
 	"redditclone/pkg/post"
 	"redditclone/pkg/user"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/mock"
@@ -39,6 +41,7 @@ func TestVoices(t *testing.T) {
 		response   string
 	}
 
+	publishAt := time.Date(2022, 5, 2, 18, 30, 0, 0, time.UTC)
 	testCases := []testCase{
 		{
 			voicetype:            1,
@@ -53,7 +56,7 @@ func TestVoices(t *testing.T) {
 			postRepoUpdateError:  nil,
 			postRepoUnlockStatus: true,
 			statusCode:           http.StatusOK,
-			response:             "{\"title\":\"\",\"author\":{\"username\":\"\",\"id\":\"0\"},\"category\":\"\",\"id\":\"0\",\"created\":\"\",\"score\":1,\"views\":0,\"upvotePercentage\":100,\"type\":\"\",\"votes\":[{\"user\":\"0\",\"vote\":1}],\"comments\":null}",
+			response:             "{\"title\":\"\",\"author\":{\"username\":\"\",\"id\":\"0\"},\"category\":\"\",\"id\":\"0\",\"created\":\"\",\"score\":1,\"views\":0,\"upvotePercentage\":100,\"type\":\"\",\"votes\":[{\"user\":\"0\",\"vote\":1}],\"myVote\":1,\"comments\":null}",
 		},
 		{
 			voicetype:            0,
@@ -160,6 +163,32 @@ func TestVoices(t *testing.T) {
 			statusCode:           http.StatusInternalServerError,
 			response:             "",
 		},
+		{
+			voicetype:            1,
+			keyUser:              middleware.UserContextKey,
+			valueUser:            user.User{Username: "test", UserID: 0},
+			keyVars:              middleware.GorrilaMuxVars,
+			valueVars:            map[string]string{"post_id": "0"},
+			postID:               0,
+			postRepoLockStatus:   true,
+			postRepoGet:          post.Post{Author: user.User{Username: "author", UserID: 2}, PublishAt: &publishAt},
+			postRepoUnlockStatus: true,
+			statusCode:           http.StatusNotFound,
+			response:             "{\"message\":\"post not found\"}\n",
+		},
+		{
+			voicetype:            1,
+			keyUser:              middleware.UserContextKey,
+			valueUser:            user.User{Username: "test", UserID: 0},
+			keyVars:              middleware.GorrilaMuxVars,
+			valueVars:            map[string]string{"post_id": "0"},
+			postID:               0,
+			postRepoLockStatus:   true,
+			postRepoGet:          post.Post{Author: user.User{Username: "test", UserID: 0}, PublishAt: &publishAt},
+			postRepoUnlockStatus: true,
+			statusCode:           http.StatusForbidden,
+			response:             "{\"message\":\"this post is not published yet\"}\n",
+		},
 		{
 			voicetype:            -1,
 			keyUser:              middleware.UserContextKey,
@@ -213,6 +242,10 @@ func TestVoices(t *testing.T) {
 			On("Unlock", testCase.postID).
 			Return(testCase.postRepoUnlockStatus)
 
+		postHandler.UserRepo.(*mocks.UserRepo).
+			On("AddKarma", mock.AnythingOfType("int64"), mock.AnythingOfType("int64"), mock.AnythingOfType("int64")).
+			Return(nil)
+
 		switch testCase.voicetype {
 		case -1:
 			postHandler.PostRatingDown(w, r.WithContext(ctx))
@@ -231,3 +264,155 @@ func TestVoices(t *testing.T) {
 		require.Equal(t, string(body), testCase.response)
 	}
 }
+
+func TestCommentVoices(t *testing.T) {
+	type testCase struct {
+		voicetype int
+
+		valueVars map[string]string
+		postID    uint64
+
+		postRepoLockStatus  bool
+		postRepoGet         post.Post
+		postRepoUpdateError error
+
+		karmaUserID int64
+		karmaDelta  int64
+
+		statusCode int
+		response   string
+	}
+
+	commentAuthor := user.User{Username: "author", UserID: 2}
+	testCases := []testCase{
+		{
+			voicetype:          1,
+			valueVars:          map[string]string{"post_id": "0", "comment_id": "0"},
+			postID:             0,
+			postRepoLockStatus: true,
+			postRepoGet:        post.Post{Comments: []comment.Comment{{Author: commentAuthor, ID: 0}}},
+			karmaUserID:        2,
+			karmaDelta:         1,
+			statusCode:         http.StatusOK,
+			response:           "{\"title\":\"\",\"author\":{\"username\":\"\",\"id\":\"0\"},\"category\":\"\",\"id\":\"0\",\"created\":\"\",\"score\":0,\"views\":0,\"upvotePercentage\":0,\"type\":\"\",\"votes\":[],\"myVote\":0,\"comments\":[{\"author\":{\"username\":\"author\",\"id\":\"2\"},\"body\":\"\",\"created\":\"\",\"id\":\"0\",\"score\":1,\"votes\":[{\"user\":\"0\",\"vote\":1}],\"myVote\":1}]}",
+		},
+		{
+			voicetype:          -1,
+			valueVars:          map[string]string{"post_id": "0", "comment_id": "0"},
+			postID:             0,
+			postRepoLockStatus: true,
+			postRepoGet: post.Post{Comments: []comment.Comment{{
+				Author: commentAuthor,
+				ID:     0,
+				Score:  1,
+				Votes:  []frontendMessages.Vote{{UserID: 0, Vote: 1}},
+			}}},
+			karmaUserID: 2,
+			karmaDelta:  -2,
+			statusCode:  http.StatusOK,
+			response:    "{\"title\":\"\",\"author\":{\"username\":\"\",\"id\":\"0\"},\"category\":\"\",\"id\":\"0\",\"created\":\"\",\"score\":0,\"views\":0,\"upvotePercentage\":0,\"type\":\"\",\"votes\":[],\"myVote\":0,\"comments\":[{\"author\":{\"username\":\"author\",\"id\":\"2\"},\"body\":\"\",\"created\":\"\",\"id\":\"0\",\"score\":-1,\"votes\":[{\"user\":\"0\",\"vote\":-1}],\"myVote\":-1}]}",
+		},
+		{
+			voicetype:          0,
+			valueVars:          map[string]string{"post_id": "0", "comment_id": "0"},
+			postID:             0,
+			postRepoLockStatus: true,
+			postRepoGet: post.Post{Comments: []comment.Comment{{
+				Author: user.User{Username: "test", UserID: 0},
+				ID:     0,
+				Score:  1,
+				Votes:  []frontendMessages.Vote{{UserID: 0, Vote: 1}},
+			}}},
+			statusCode: http.StatusOK,
+			response:   "{\"title\":\"\",\"author\":{\"username\":\"\",\"id\":\"0\"},\"category\":\"\",\"id\":\"0\",\"created\":\"\",\"score\":0,\"views\":0,\"upvotePercentage\":0,\"type\":\"\",\"votes\":[],\"myVote\":0,\"comments\":[{\"author\":{\"username\":\"test\",\"id\":\"0\"},\"body\":\"\",\"created\":\"\",\"id\":\"0\",\"score\":0,\"myVote\":0}]}",
+		},
+		{
+			voicetype:          0,
+			valueVars:          map[string]string{"post_id": "0", "comment_id": "1"},
+			postID:             0,
+			postRepoLockStatus: true,
+			postRepoGet:        post.Post{Comments: []comment.Comment{{Author: commentAuthor, ID: 0}}},
+			statusCode:         http.StatusNotFound,
+			response:           "{\"message\":\"comment not found\"}\n",
+		},
+		{
+			voicetype:          0,
+			valueVars:          map[string]string{"post_id": "0", "comment_id": "0"},
+			postID:             0,
+			postRepoLockStatus: false,
+			statusCode:         http.StatusNotFound,
+			response:           "{\"message\":\"post not found\"}\n",
+		},
+		{
+			voicetype:          1,
+			valueVars:          map[string]string{"post_id": "0"},
+			postID:             0,
+			postRepoLockStatus: true,
+			statusCode:         http.StatusInternalServerError,
+			response:           "",
+		},
+		{
+			voicetype:           1,
+			valueVars:           map[string]string{"post_id": "0", "comment_id": "0"},
+			postID:              0,
+			postRepoLockStatus:  true,
+			postRepoGet:         post.Post{Comments: []comment.Comment{{Author: commentAuthor, ID: 0}}},
+			postRepoUpdateError: fmt.Errorf("test error"),
+			statusCode:          http.StatusInternalServerError,
+			response:            "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		postHandler := setupPost()
+		defer postHandler.Logger.Sync()
+
+		r := httptest.NewRequest("GET", "/api/post/0/0/upvote", nil)
+		r = mux.SetURLVars(r, testCase.valueVars)
+		ctx := r.Context()
+		ctx = context.WithValue(ctx, middleware.UserContextKey, user.User{Username: "test", UserID: 0})
+		w := httptest.NewRecorder()
+
+		postHandler.PostRepo.(*mocks.PostRepo).
+			On("Lock", testCase.postID).
+			Return(testCase.postRepoLockStatus)
+
+		postHandler.PostRepo.(*mocks.PostRepo).
+			On("Get", testCase.postID).
+			Return(testCase.postRepoGet, nil)
+
+		postHandler.PostRepo.(*mocks.PostRepo).
+			On("Update", mock.AnythingOfType("post.Post")).
+			Return(testCase.postRepoUpdateError)
+
+		postHandler.PostRepo.(*mocks.PostRepo).
+			On("Unlock", testCase.postID).
+			Return(true)
+
+		postHandler.UserRepo.(*mocks.UserRepo).
+			On("AddKarma", testCase.karmaUserID, int64(0), testCase.karmaDelta).
+			Return(nil)
+
+		switch testCase.voicetype {
+		case -1:
+			postHandler.CommentRatingDown(w, r.WithContext(ctx))
+		case 0:
+			postHandler.CommentRatingDefault(w, r.WithContext(ctx))
+		case 1:
+			postHandler.CommentRatingUp(w, r.WithContext(ctx))
+		}
+
+		resp := w.Result()
+
+		body, errRead := ioutil.ReadAll(resp.Body)
+
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		require.Equal(t, string(body), testCase.response)
+		if testCase.karmaDelta != 0 {
+			postHandler.UserRepo.(*mocks.UserRepo).AssertCalled(t, "AddKarma", testCase.karmaUserID, int64(0), testCase.karmaDelta)
+		} else {
+			postHandler.UserRepo.(*mocks.UserRepo).AssertNotCalled(t, "AddKarma", mock.Anything, mock.Anything, mock.Anything)
+		}
+	}
+}
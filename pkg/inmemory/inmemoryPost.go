@@ -3,6 +3,7 @@ package inmemory
 import (
 	"encoding/json"
 	"fmt"
+	"redditclone/pkg/comment"
 	"redditclone/pkg/post"
 	"sort"
 	"sync"
@@ -10,16 +11,41 @@ import (
 
 func NewDatabasePost() *PostRepo {
 	return &PostRepo{
-		data:       make(map[uint64]post.Post),
-		postsMuxes: make(map[uint64]*sync.Mutex),
-		mu:         &sync.Mutex{},
+		data:          make(map[uint64]post.Post),
+		postsMuxes:    make(map[uint64]*sync.Mutex),
+		commentsIndex: make(map[string]map[uint64]struct{}),
+		mu:            &sync.Mutex{},
+	}
+}
+
+func (d *PostRepo) unindexComments(pst post.Post) {
+	for _, cmt := range pst.Comments {
+		delete(d.commentsIndex[cmt.Author.Username], pst.ID)
+		if len(d.commentsIndex[cmt.Author.Username]) == 0 {
+			delete(d.commentsIndex, cmt.Author.Username)
+		}
+	}
+}
+
+func (d *PostRepo) indexComments(pst post.Post) {
+	for _, cmt := range pst.Comments {
+		posts, ok := d.commentsIndex[cmt.Author.Username]
+		if !ok {
+			posts = make(map[uint64]struct{})
+			d.commentsIndex[cmt.Author.Username] = posts
+		}
+		posts[pst.ID] = struct{}{}
 	}
 }
 
 func (d *PostRepo) Add(pst post.Post) bool {
 	d.mu.Lock()
 	defer d.mu.Unlock()
+	if old, ok := d.data[pst.ID]; ok {
+		d.unindexComments(old)
+	}
 	d.data[pst.ID] = pst
+	d.indexComments(pst)
 	_, ok := d.postsMuxes[pst.ID]
 	if !ok {
 		d.postsMuxes[pst.ID] = &sync.Mutex{}
@@ -66,10 +92,11 @@ func (d *PostRepo) Unlock(id uint64) bool {
 func (d *PostRepo) Remove(id uint64) bool {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	_, ok := d.data[id]
+	pst, ok := d.data[id]
 	if !ok {
 		return false
 	}
+	d.unindexComments(pst)
 	delete(d.data, id)
 	delete(d.postsMuxes, id)
 	return true
@@ -109,6 +136,43 @@ func (d *PostRepo) ToJson(category, username string) ([]byte, error) {
 	return resJson, nil
 }
 
+func (d *PostRepo) UserComments(username, sortBy string, page, limit int64) []comment.UserComment {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	res := []comment.UserComment{}
+	for id := range d.commentsIndex[username] {
+		pst := d.data[id]
+		if pst.Hidden || pst.DeletedAt != nil {
+			continue
+		}
+		for _, cmt := range pst.Comments {
+			if cmt.Author.Username != username || cmt.Hidden || cmt.DeletedAt != nil {
+				continue
+			}
+			res = append(res, comment.UserComment{
+				Comment:   cmt,
+				PostID:    pst.ID,
+				PostTitle: pst.Title,
+			})
+		}
+	}
+	sort.Slice(res, func(i1, i2 int) bool {
+		if sortBy == comment.SortTop && res[i1].Score != res[i2].Score {
+			return res[i1].Score > res[i2].Score
+		}
+		return res[i1].Time > res[i2].Time
+	})
+	from := page * limit
+	if from >= int64(len(res)) {
+		return []comment.UserComment{}
+	}
+	to := from + limit
+	if to > int64(len(res)) {
+		to = int64(len(res))
+	}
+	return res[from:to]
+}
+
 func (d *PostRepo) GetID() (res uint64) {
 	res = d.count
 	d.count++
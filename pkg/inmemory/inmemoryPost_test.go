@@ -0,0 +1,42 @@
+package inmemory
+
+import (
+	"redditclone/pkg/comment"
+	"redditclone/pkg/post"
+	"redditclone/pkg/user"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserComments(t *testing.T) {
+	author := user.User{Username: "test", UserID: 1}
+	other := user.User{Username: "other", UserID: 2}
+	deletedAt := time.Date(2022, 5, 2, 18, 30, 0, 0, time.UTC)
+
+	repo := NewDatabasePost()
+	repo.Add(post.Post{ID: 1, Title: "first", Comments: []comment.Comment{
+		{ID: 1, Author: author, Body: "old", Time: "2022-05-02T18:31:00Z", Score: 3},
+		{ID: 2, Author: other, Body: "reply", Time: "2022-05-02T18:32:00Z"},
+		{ID: 3, Author: author, Body: "removed", Time: "2022-05-02T18:33:00Z", DeletedAt: &deletedAt},
+		{ID: 4, Author: author, Body: "new", Time: "2022-05-02T18:34:00Z", Score: 1},
+		{ID: 5, Author: author, Body: "hidden", Time: "2022-05-02T18:35:00Z", Hidden: true},
+	}})
+	repo.Add(post.Post{ID: 2, Title: "deleted", DeletedAt: &deletedAt, Comments: []comment.Comment{
+		{ID: 1, Author: author, Body: "on deleted post", Time: "2022-05-02T18:36:00Z"},
+	}})
+
+	ids := func(comments []comment.UserComment) []uint64 {
+		res := []uint64{}
+		for _, cmt := range comments {
+			res = append(res, cmt.ID)
+		}
+		return res
+	}
+
+	require.Equal(t, []uint64{4, 1}, ids(repo.UserComments("test", comment.SortNew, 0, 10)))
+	require.Equal(t, []uint64{1, 4}, ids(repo.UserComments("test", comment.SortTop, 0, 10)))
+	require.Equal(t, []uint64{1}, ids(repo.UserComments("test", comment.SortNew, 1, 1)))
+	require.Equal(t, []uint64{}, ids(repo.UserComments("test", comment.SortNew, 2, 1)))
+}
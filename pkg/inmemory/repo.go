@@ -14,10 +14,11 @@ type UserRepo struct {
 }
 
 type PostRepo struct {
-	data       map[uint64]post.Post
-	mu         *sync.Mutex
-	postsMuxes map[uint64]*sync.Mutex
-	count      uint64
+	data          map[uint64]post.Post
+	mu            *sync.Mutex
+	postsMuxes    map[uint64]*sync.Mutex
+	commentsIndex map[string]map[uint64]struct{}
+	count         uint64
 }
 
 type CommentRepo struct {
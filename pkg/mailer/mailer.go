@@ -0,0 +1,137 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mail is a plain text letter to a single recipient.
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers letters to users.
+type Mailer interface {
+	Send(mail Mail) error
+}
+
+// format renders the letter as an RFC 5322 message.
+func format(from string, mail Mail, now time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", mail.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(mail.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return b.Bytes()
+}
+
+func checkHeader(mail Mail) error {
+	if strings.ContainsAny(mail.To, "\r\n") || strings.ContainsAny(mail.Subject, "\r\n") {
+		return fmt.Errorf("mailer: line break in header")
+	}
+	return nil
+}
+
+type SMTPMailer struct {
+	addr string
+	// from is the From header, sender is the bare address for the envelope
+	from   string
+	sender string
+	auth   smtp.Auth
+}
+
+// NewSMTPMailer sends letters through the server at addr (host:port), empty
+// username sends them without authentication. The sender may have a display
+// name, like "name <user@example.com>".
+func NewSMTPMailer(addr, username, password, from string) (*SMTPMailer, error) {
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("mailer: bad sender: %w", err)
+	}
+	var auth smtp.Auth
+	if username != "" {
+		host := addr
+		if i := strings.LastIndex(addr, ":"); i != -1 {
+			host = addr[:i]
+		}
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPMailer{addr: addr, from: from, sender: sender.Address, auth: auth}, nil
+}
+
+func (m *SMTPMailer) Send(mail Mail) error {
+	if err := checkHeader(mail); err != nil {
+		return err
+	}
+	return smtp.SendMail(m.addr, m.auth, m.sender, []string{mail.To}, format(m.from, mail, time.Now()))
+}
+
+// MemoryMailer keeps letters instead of sending them, for tests.
+type MemoryMailer struct {
+	mx   sync.Mutex
+	sent []Mail
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(mail Mail) error {
+	if err := checkHeader(mail); err != nil {
+		return err
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.sent = append(m.sent, mail)
+	return nil
+}
+
+// Sent returns copies of all letters sent so far.
+func (m *MemoryMailer) Sent() []Mail {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return append([]Mail{}, m.sent...)
+}
+
+// FileMailer writes every letter to its own .eml file in a directory, for
+// local development without a mail server.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("mailer: can`t create directory: %w", err)
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+func (m *FileMailer) Send(mail Mail) error {
+	if err := checkHeader(mail); err != nil {
+		return err
+	}
+	now := time.Now()
+	file, err := os.CreateTemp(m.dir, now.Format("20060102-150405")+"-*.eml")
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(format(m.from, mail, now)); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
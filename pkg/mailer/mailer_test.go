@@ -0,0 +1,127 @@
+package mailer
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	now := time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+	res := format("noreply@example.com", Mail{
+		To:      "user@example.com",
+		Subject: "Verify your email",
+		Body:    "first\nsecond",
+	}, now)
+	require.Equal(t, string(res), "From: noreply@example.com\r\n"+
+		"To: user@example.com\r\n"+
+		"Subject: Verify your email\r\n"+
+		"Date: Sun, 01 May 2022 10:00:00 +0000\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n"+
+		"\r\n"+
+		"first\r\nsecond")
+}
+
+func TestMemoryMailer(t *testing.T) {
+	mailer := NewMemoryMailer()
+	require.NoError(t, mailer.Send(Mail{To: "a@example.com", Subject: "first"}))
+	require.NoError(t, mailer.Send(Mail{To: "b@example.com", Subject: "second"}))
+	require.Error(t, mailer.Send(Mail{To: "c@example.com\r\nBcc: d@example.com"}))
+
+	sent := mailer.Sent()
+	require.Equal(t, sent, []Mail{
+		{To: "a@example.com", Subject: "first"},
+		{To: "b@example.com", Subject: "second"},
+	})
+	sent[0].To = "changed"
+	require.Equal(t, mailer.Sent()[0].To, "a@example.com")
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	mailer, err := NewFileMailer(dir, "noreply@example.com")
+	require.NoError(t, err)
+
+	require.NoError(t, mailer.Send(Mail{To: "a@example.com", Subject: "first", Body: "body"}))
+	require.NoError(t, mailer.Send(Mail{To: "b@example.com", Subject: "second", Body: "body"}))
+	require.Error(t, mailer.Send(Mail{To: "c@example.com", Subject: "bad\nsubject"}))
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, len(files), 2)
+	for _, file := range files {
+		require.True(t, strings.HasSuffix(file.Name(), ".eml"), file.Name())
+		data, errRead := os.ReadFile(filepath.Join(dir, file.Name()))
+		require.NoError(t, errRead)
+		require.Contains(t, string(data), "From: noreply@example.com\r\n")
+		require.True(t, strings.HasSuffix(string(data), "\r\n\r\nbody"))
+	}
+}
+
+// serveSMTP accepts one session on listener, answers every command with
+// success and sends the received commands to commands.
+func serveSMTP(listener net.Listener, commands chan<- string) {
+	defer close(commands)
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	conn.Write([]byte("220 localhost ready\r\n"))
+	inData := false
+	for {
+		line, errRead := reader.ReadString('\n')
+		if errRead != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if inData {
+			if line == "." {
+				inData = false
+				conn.Write([]byte("250 queued\r\n"))
+			}
+			continue
+		}
+		commands <- line
+		switch {
+		case strings.HasPrefix(line, "DATA"):
+			inData = true
+			conn.Write([]byte("354 go ahead\r\n"))
+		case strings.HasPrefix(line, "QUIT"):
+			conn.Write([]byte("221 bye\r\n"))
+			return
+		default:
+			conn.Write([]byte("250 ok\r\n"))
+		}
+	}
+}
+
+func TestSMTPMailer(t *testing.T) {
+	_, err := NewSMTPMailer("localhost:25", "", "", "redditclone noreply@example.com")
+	require.Error(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	commands := make(chan string, 16)
+	go serveSMTP(listener, commands)
+
+	mailer, err := NewSMTPMailer(listener.Addr().String(), "", "", "redditclone <noreply@example.com>")
+	require.NoError(t, err)
+	require.NoError(t, mailer.Send(Mail{To: "user@example.com", Subject: "hi", Body: "body"}))
+
+	received := []string{}
+	for command := range commands {
+		received = append(received, command)
+	}
+	require.Contains(t, received, "MAIL FROM:<noreply@example.com>")
+	require.Contains(t, received, "RCPT TO:<user@example.com>")
+}
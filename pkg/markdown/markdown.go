@@ -0,0 +1,343 @@
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Render converts a safe subset of Markdown to HTML: paragraphs, emphasis,
+// inline and fenced code, links, lists and quotes. Raw HTML is never passed
+// through, every piece of source text is escaped, so the result needs no
+// further sanitizing. Links get rel="nofollow", u/username and r/category
+// become links to the user and the category pages.
+func Render(source string) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	if strings.TrimSpace(source) == "" {
+		return ""
+	}
+	var b strings.Builder
+	renderBlocks(&b, strings.Split(source, "\n"), 0)
+	return b.String()
+}
+
+// maxDepth limits nesting of quotes, deeper quotes are rendered as text
+const maxDepth = 8
+
+var (
+	orderedItem = regexp.MustCompile(`^\d{1,9}[.)] `)
+	nameChars   = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-"
+)
+
+func isFence(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "```")
+}
+
+func isQuote(line string) bool {
+	return strings.HasPrefix(strings.TrimLeft(line, " "), ">")
+}
+
+// listItem returns the text of a list item and whether the list is ordered.
+func listItem(line string) (text string, ordered, ok bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(trimmed) >= 2 && strings.ContainsRune("-*+", rune(trimmed[0])) && trimmed[1] == ' ' {
+		return trimmed[2:], false, true
+	}
+	if loc := orderedItem.FindStringIndex(trimmed); loc != nil {
+		return trimmed[loc[1]:], true, true
+	}
+	return "", false, false
+}
+
+func renderBlocks(b *strings.Builder, lines []string, depth int) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+		case isFence(line):
+			i++
+			start := i
+			for i < len(lines) && !isFence(lines[i]) {
+				i++
+			}
+			b.WriteString("<pre><code>")
+			b.WriteString(html.EscapeString(strings.Join(lines[start:i], "\n")))
+			b.WriteString("</code></pre>\n")
+			i++
+		case isQuote(line) && depth < maxDepth:
+			quoted := []string{}
+			for ; i < len(lines) && isQuote(lines[i]); i++ {
+				text := strings.TrimPrefix(strings.TrimLeft(lines[i], " "), ">")
+				quoted = append(quoted, strings.TrimPrefix(text, " "))
+			}
+			b.WriteString("<blockquote>\n")
+			renderBlocks(b, quoted, depth+1)
+			b.WriteString("</blockquote>\n")
+		default:
+			if _, ordered, ok := listItem(line); ok {
+				tag := "ul"
+				if ordered {
+					tag = "ol"
+				}
+				b.WriteString("<" + tag + ">\n")
+				for ; i < len(lines); i++ {
+					text, itemOrdered, ok := listItem(lines[i])
+					if !ok || itemOrdered != ordered {
+						break
+					}
+					b.WriteString("<li>" + renderInline(text) + "</li>\n")
+				}
+				b.WriteString("</" + tag + ">\n")
+				continue
+			}
+			start := i
+			for i < len(lines) && strings.TrimSpace(lines[i]) != "" && !isFence(lines[i]) &&
+				(!isQuote(lines[i]) || depth >= maxDepth) {
+				if _, _, ok := listItem(lines[i]); ok && i != start {
+					break
+				}
+				i++
+			}
+			text := strings.TrimSpace(strings.Join(lines[start:i], "\n"))
+			b.WriteString("<p>" + renderInline(text) + "</p>\n")
+		}
+	}
+}
+
+func isNameChar(c byte) bool {
+	return strings.IndexByte(nameChars, c) != -1
+}
+
+func isWordChar(text string, i int) bool {
+	if i < 0 || i >= len(text) {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(text[i:])
+	if r == utf8.RuneError {
+		r, _ = utf8.DecodeLastRuneInString(text[:i+1])
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// safeURL allows only web links and site-relative paths.
+func safeURL(link string) (string, bool) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		if u.Host == "" {
+			return "", false
+		}
+	case "":
+		if !strings.HasPrefix(link, "/") || strings.HasPrefix(link, "//") {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+	return u.String(), true
+}
+
+func anchor(href, text string) string {
+	return `<a href="` + html.EscapeString(href) + `" rel="nofollow">` + text + "</a>"
+}
+
+// spans answers the lookups of inline rendering from tables built in one
+// pass over the text, so rendering stays linear in the length of the text
+// instead of rescanning the rest of it for every delimiter.
+type spans struct {
+	text string
+	// ticks and parens hold the position of the next ` and ) at or after i
+	ticks, parens []int
+	// brackets holds the position of the ] matching [ at i
+	brackets []int
+	// closers holds, for each emphasis delimiter, the closer found by
+	// scanning from i, skipping code
+	closers map[string][]int
+}
+
+func newSpans(text string) *spans {
+	s := &spans{
+		text:     text,
+		ticks:    make([]int, len(text)+1),
+		parens:   make([]int, len(text)+1),
+		brackets: make([]int, len(text)),
+		closers:  map[string][]int{},
+	}
+	s.ticks[len(text)], s.parens[len(text)] = -1, -1
+	for i := len(text) - 1; i >= 0; i-- {
+		s.ticks[i], s.parens[i] = s.ticks[i+1], s.parens[i+1]
+		switch text[i] {
+		case '`':
+			s.ticks[i] = i
+		case ')':
+			s.parens[i] = i
+		}
+	}
+	open := []int{}
+	for i := 0; i < len(text); i++ {
+		s.brackets[i] = -1
+		switch {
+		case text[i] == '[':
+			open = append(open, i)
+		case text[i] == ']' && len(open) != 0:
+			s.brackets[open[len(open)-1]] = i
+			open = open[:len(open)-1]
+		}
+	}
+	return s
+}
+
+// closerTable fills the closers of delim from the end of the text.
+func (s *spans) closerTable(delim string) []int {
+	if table, ok := s.closers[delim]; ok {
+		return table
+	}
+	text := s.text
+	table := make([]int, len(text)+1)
+	for i := len(text); i >= 0; i-- {
+		switch {
+		case i+len(delim) > len(text):
+			table[i] = -1
+		case text[i] == '`' && s.ticks[i+1] != -1:
+			table[i] = table[s.ticks[i+1]+1]
+		case i > 0 && strings.HasPrefix(text[i:], delim) && text[i-1] != ' ' && text[i-1] != '\n' &&
+			(delim[0] != '_' || !isWordChar(text, i+len(delim))):
+			table[i] = i
+		default:
+			table[i] = table[i+1]
+		}
+	}
+	s.closers[delim] = table
+	return table
+}
+
+// closing finds the end of an emphasis opened at start: the delimiter must
+// not touch spaces from inside and the content must not be empty.
+func (s *spans) closing(delim string, start int) int {
+	from := start + len(delim)
+	if from >= len(s.text) || s.text[from] == ' ' || s.text[from] == '\n' {
+		return -1
+	}
+	return s.closerTable(delim)[from+1]
+}
+
+func renderInline(text string) string {
+	return inline(text, true)
+}
+
+// inline renders emphasis, code and, if links is set, links; labels of
+// links are rendered without links, so anchors are never nested.
+func inline(text string, links bool) string {
+	var b strings.Builder
+	s := newSpans(text)
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && strings.IndexByte("\\`*_[]()#+-.!>~/", text[i+1]) != -1:
+			b.WriteString(html.EscapeString(text[i+1 : i+2]))
+			i += 2
+			continue
+		case c == '`':
+			if end := s.ticks[i+1]; end != -1 {
+				b.WriteString("<code>" + html.EscapeString(text[i+1:end]) + "</code>")
+				i = end + 1
+				continue
+			}
+		case c == '[' && links:
+			if label, link, n, ok := s.parseLink(i); ok {
+				if href, safe := safeURL(link); safe {
+					b.WriteString(anchor(href, inline(label, false)))
+					i += n
+					continue
+				}
+			}
+		case c == '*' || c == '_':
+			delim := string(c)
+			if strings.HasPrefix(text[i:], delim+delim) {
+				delim += delim
+			}
+			if c == '_' && isWordChar(text, i-1) {
+				break
+			}
+			if end := s.closing(delim, i); end != -1 {
+				tag := "em"
+				if len(delim) == 2 {
+					tag = "strong"
+				}
+				b.WriteString("<" + tag + ">" + inline(text[i+len(delim):end], links) + "</" + tag + ">")
+				i = end + len(delim)
+				continue
+			}
+		case (c == 'h' || c == 'H') && links && !isWordChar(text, i-1):
+			if n := autolinkLen(text[i:]); n != 0 {
+				if href, safe := safeURL(text[i : i+n]); safe {
+					b.WriteString(anchor(href, html.EscapeString(text[i:i+n])))
+					i += n
+					continue
+				}
+			}
+		case (c == 'u' || c == 'r') && links && i+2 < len(text) && text[i+1] == '/' &&
+			!isWordChar(text, i-1) && (i == 0 || text[i-1] != '/'):
+			n := 0
+			for i+2+n < len(text) && isNameChar(text[i+2+n]) {
+				n++
+			}
+			if n != 0 {
+				name := text[i+2 : i+2+n]
+				page := "/u/"
+				if c == 'r' {
+					page = "/a/"
+				}
+				b.WriteString(`<a href="` + page + html.EscapeString(name) + `">` + html.EscapeString(text[i:i+2+n]) + "</a>")
+				i += 2 + n
+				continue
+			}
+		}
+		b.WriteString(html.EscapeString(text[i : i+1]))
+		i++
+	}
+	return b.String()
+}
+
+// parseLink reads "[label](link)" starting at the [ at start and returns
+// the length of the whole construction.
+func (s *spans) parseLink(start int) (label, link string, n int, ok bool) {
+	text := s.text
+	i := s.brackets[start]
+	if i == -1 || i+1 >= len(text) || text[i+1] != '(' {
+		return "", "", 0, false
+	}
+	end := s.parens[i+2]
+	if end == -1 {
+		return "", "", 0, false
+	}
+	link = strings.TrimSpace(text[i+2 : end])
+	if link == "" || strings.ContainsAny(link, " \n") {
+		return "", "", 0, false
+	}
+	return text[start+1 : i], link, end + 1 - start, true
+}
+
+// autolinkLen returns the length of a bare http(s) link at the start of
+// text, trailing punctuation is left out.
+func autolinkLen(text string) int {
+	lower := strings.ToLower(text)
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+		return 0
+	}
+	n := 0
+	for n < len(text) && text[n] > ' ' && !strings.ContainsRune("<>\"`", rune(text[n])) {
+		n++
+	}
+	for n > 0 && strings.ContainsRune(".,:;!?)'*_", rune(text[n-1])) {
+		n--
+	}
+	return n
+}
@@ -0,0 +1,78 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	type testCase struct {
+		source string
+		html   string
+	}
+
+	testCases := []testCase{
+		{"", ""},
+		{"  \n ", ""},
+		{"plain text", "<p>plain text</p>\n"},
+		{"first\r\nsecond\n\nthird", "<p>first\nsecond</p>\n<p>third</p>\n"},
+		{"**bold** and *italic* and __b__ and _i_", "<p><strong>bold</strong> and <em>italic</em> and <strong>b</strong> and <em>i</em></p>\n"},
+		{"snake_case_name and 2 * 3 * 4", "<p>snake_case_name and 2 * 3 * 4</p>\n"},
+		{"**not closed", "<p>**not closed</p>\n"},
+		{"\\*escaped\\*", "<p>*escaped*</p>\n"},
+		{"use `a <b> *c*` here", "<p>use <code>a &lt;b&gt; *c*</code> here</p>\n"},
+		{"```\n<script>alert(1)</script>\n**x**\n```", "<pre><code>&lt;script&gt;alert(1)&lt;/script&gt;\n**x**</code></pre>\n"},
+		{"<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"},
+		{"<img src=x onerror=alert(1)>", "<p>&lt;img src=x onerror=alert(1)&gt;</p>\n"},
+		{"[site](https://example.com/a?b=1&c=2)", "<p><a href=\"https://example.com/a?b=1&amp;c=2\" rel=\"nofollow\">site</a></p>\n"},
+		{"[*em* link](/a/music)", "<p><a href=\"/a/music\" rel=\"nofollow\"><em>em</em> link</a></p>\n"},
+		{"[x](javascript:alert(1))", "<p>[x](javascript:alert(1))</p>\n"},
+		{"[x](//evil.com)", "<p>[x](//evil.com)</p>\n"},
+		{"[x](data:text/html,hi)", "<p>[x](data:text/html,hi)</p>\n"},
+		{"[x\" onclick=\"a](https://e.com/\"onclick)", "<p><a href=\"https://e.com/%22onclick\" rel=\"nofollow\">x&#34; onclick=&#34;a</a></p>\n"},
+		{"[https://a.com](https://b.com)", "<p><a href=\"https://b.com\" rel=\"nofollow\">https://a.com</a></p>\n"},
+		{"see https://example.com/x, ok", "<p>see <a href=\"https://example.com/x\" rel=\"nofollow\">https://example.com/x</a>, ok</p>\n"},
+		{"ask u/test_1 in r/music", "<p>ask <a href=\"/u/test_1\">u/test_1</a> in <a href=\"/a/music\">r/music</a></p>\n"},
+		{"menu/item and /r/x and u/", "<p>menu/item and /r/x and u/</p>\n"},
+		{"- one\n- **two**\n\n1. first\n2) second", "<ul>\n<li>one</li>\n<li><strong>two</strong></li>\n</ul>\n<ol>\n<li>first</li>\n<li>second</li>\n</ol>\n"},
+		{"text\n- item", "<p>text</p>\n<ul>\n<li>item</li>\n</ul>\n"},
+		{"> quote\n> > nested\n\nafter", "<blockquote>\n<p>quote</p>\n<blockquote>\n<p>nested</p>\n</blockquote>\n</blockquote>\n<p>after</p>\n"},
+		{"юникод *тоже*", "<p>юникод <em>тоже</em></p>\n"},
+	}
+
+	for _, testCase := range testCases {
+		require.Equal(t, Render(testCase.source), testCase.html, testCase.source)
+	}
+}
+
+func TestRenderLinear(t *testing.T) {
+	for _, unit := range []string{"*a ", "_a ", "**a ", "[a ", "[a](b ", "`a *"} {
+		source := strings.Repeat(unit, 40000/len(unit))
+		start := time.Now()
+		Render(source)
+		require.Less(t, int64(time.Since(start)), int64(200*time.Millisecond), unit)
+	}
+}
+
+func TestMentions(t *testing.T) {
+	type testCase struct {
+		text     string
+		limit    int
+		mentions []string
+	}
+
+	testCases := []testCase{
+		{"no mentions, mail@example.com", 5, []string{}},
+		{"@alice and u/bob, again @alice", 5, []string{"alice", "bob"}},
+		{"(@a) @b\n@c @d", 3, []string{"a", "b", "c"}},
+		{"`@code` and\n```\nu/block\n```\nbut @real", 5, []string{"real"}},
+		{"path/u/name and @@double", 5, []string{}},
+	}
+
+	for _, testCase := range testCases {
+		require.Equal(t, Mentions(testCase.text, testCase.limit), testCase.mentions, testCase.text)
+	}
+}
@@ -0,0 +1,26 @@
+package markdown
+
+import "regexp"
+
+var (
+	codeBlock = regexp.MustCompile("(?s)```.*?```|`[^`\n]*`")
+	mention   = regexp.MustCompile(`(?:^|[^A-Za-z0-9_/@-])(?:@|u/)([A-Za-z0-9_-]+)`)
+)
+
+// Mentions returns up to limit distinct usernames mentioned in text as
+// @username or u/username, in order of appearance. Code is skipped.
+func Mentions(text string, limit int) []string {
+	text = codeBlock.ReplaceAllString(text, " ")
+	res := []string{}
+	seen := map[string]bool{}
+	for _, match := range mention.FindAllStringSubmatch(text, -1) {
+		if len(res) == limit {
+			break
+		}
+		if name := match[1]; !seen[name] {
+			seen[name] = true
+			res = append(res, name)
+		}
+	}
+	return res
+}
@@ -0,0 +1,137 @@
+package media
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"net/http"
+
+	_ "image/gif"
+	_ "image/png"
+)
+
+const (
+	// maxPixels protects from images which are small in bytes but huge
+	// when decoded
+	maxPixels        = 40 << 20
+	thumbnailQuality = 80
+)
+
+var ErrUnsupported = fmt.Errorf("unsupported image")
+
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+type Image struct {
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail"`
+	ContentType  string `json:"contentType" bson:"content_type"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	Size         int64  `json:"size"`
+}
+
+// Upload is a checked image ready to be stored.
+type Upload struct {
+	Name          string
+	ThumbnailName string
+	Data          []byte
+	Thumbnail     []byte
+	Image         Image
+}
+
+// Process sniffs the content type of data, ignoring what the client claims,
+// and makes a JPEG thumbnail which fits into maxSide x maxSide. File names
+// are derived from the content.
+func Process(data []byte, maxSide int) (Upload, error) {
+	contentType := http.DetectContentType(data)
+	ext, ok := extensions[contentType]
+	if !ok {
+		return Upload{}, ErrUnsupported
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || extensions["image/"+format] != ext {
+		return Upload{}, ErrUnsupported
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return Upload{}, ErrUnsupported
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Upload{}, ErrUnsupported
+	}
+	thumbnail, err := Thumbnail(src, maxSide)
+	if err != nil {
+		return Upload{}, err
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	return Upload{
+		Name:          hash + ext,
+		ThumbnailName: hash + "_thumb.jpg",
+		Data:          data,
+		Thumbnail:     thumbnail,
+		Image: Image{
+			ContentType: contentType,
+			Width:       config.Width,
+			Height:      config.Height,
+			Size:        int64(len(data)),
+		},
+	}, nil
+}
+
+// Thumbnail scales src down by averaging pixels and encodes it as JPEG,
+// transparent parts become white.
+func Thumbnail(src image.Image, maxSide int) ([]byte, error) {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > maxSide || height > maxSide {
+		if width >= height {
+			width, height = maxSide, height*maxSide/width
+		} else {
+			width, height = width*maxSide/height, maxSide
+		}
+		if width == 0 {
+			width = 1
+		}
+		if height == 0 {
+			height = 1
+		}
+	}
+	scaled := image.NewRGBA64(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := bounds.Min.Y + (y+1)*bounds.Dy()/height
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := bounds.Min.X + (x+1)*bounds.Dx()/width
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			scaled.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n),
+			})
+		}
+	}
+	res := image.NewRGBA(scaled.Bounds())
+	draw.Draw(res, res.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(res, res.Bounds(), scaled, image.Point{}, draw.Over)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, res, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,89 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testPNG(t *testing.T, width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestProcess(t *testing.T) {
+	type testCase struct {
+		data      []byte
+		maxSide   int
+		width     int
+		height    int
+		thumbSize image.Point
+		hasError  bool
+	}
+
+	testCases := []testCase{
+		{
+			data:      testPNG(t, 200, 100),
+			maxSide:   50,
+			width:     200,
+			height:    100,
+			thumbSize: image.Point{X: 50, Y: 25},
+		},
+		{
+			data:      testPNG(t, 30, 90),
+			maxSide:   45,
+			width:     30,
+			height:    90,
+			thumbSize: image.Point{X: 15, Y: 45},
+		},
+		{
+			data:      testPNG(t, 20, 10),
+			maxSide:   50,
+			width:     20,
+			height:    10,
+			thumbSize: image.Point{X: 20, Y: 10},
+		},
+		{
+			data:     []byte("<html><body>not an image</body></html>"),
+			maxSide:  50,
+			hasError: true,
+		},
+		{
+			data:     append([]byte("\x89PNG\x0D\x0A\x1A\x0A"), []byte("broken")...),
+			maxSide:  50,
+			hasError: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		res, err := Process(testCase.data, testCase.maxSide)
+		if testCase.hasError {
+			require.ErrorIs(t, err, ErrUnsupported)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, res.Image, Image{
+			ContentType: "image/png",
+			Width:       testCase.width,
+			Height:      testCase.height,
+			Size:        int64(len(testCase.data)),
+		})
+		require.Regexp(t, "^[0-9a-f]{64}\\.png$", res.Name)
+		require.Equal(t, res.ThumbnailName, res.Name[:64]+"_thumb.jpg")
+		thumb, err := jpeg.Decode(bytes.NewReader(res.Thumbnail))
+		require.NoError(t, err)
+		require.Equal(t, thumb.Bounds().Size(), testCase.thumbSize)
+	}
+}
@@ -0,0 +1,20 @@
+package message
+
+import (
+	"redditclone/pkg/user"
+)
+
+type Message struct {
+	ID      int64     `json:"id,string"`
+	From    user.User `json:"from"`
+	To      user.User `json:"to"`
+	Body    string    `json:"body"`
+	Created string    `json:"created"`
+	Read    bool      `json:"read"`
+}
+
+type Conversation struct {
+	With        user.User `json:"with"`
+	LastMessage Message   `json:"lastMessage"`
+	Unread      uint64    `json:"unread"`
+}
@@ -45,7 +45,7 @@ func (m Middleware) CheckAuth(next http.HandlerFunc) http.HandlerFunc {
 		)
 	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		errAuth := m.Authorization.CheckAuth(r)
+		sessionUserID, errAuth := m.Authorization.CheckAuth(r)
 		if errAuth != nil {
 			switch errAuth.(type) {
 			case session.ErrorTokenIsExpired:
@@ -71,6 +71,11 @@ func (m Middleware) CheckAuth(next http.HandlerFunc) http.HandlerFunc {
 			m.Logger.Debugf("middleware: bad access token: %s", tokenStr)
 			return
 		}
+		if usr.UserID != sessionUserID {
+			badAuthorization("leave and login again", w)
+			m.Logger.Infof("middleware: session of user %d used with token of user %d", sessionUserID, usr.UserID)
+			return
+		}
 		err = m.Authorization.UpdateAuth(w, r)
 		if err != nil {
 			m.Logger.Errorf("middleware: can`t update auth token: %w", err)
@@ -89,3 +94,33 @@ func (m Middleware) CheckAuth(next http.HandlerFunc) http.HandlerFunc {
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+func (m Middleware) OptionalAuth(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		sessionUserID, errAuth := m.Authorization.CheckAuth(r)
+		if errAuth != nil {
+			m.Logger.Debugf("middleware: optional auth: %s", errAuth)
+			next.ServeHTTP(w, r)
+			return
+		}
+		tokenArr := strings.Split(authHeader, " ")
+		if len(tokenArr) != 2 || tokenArr[0] != "Bearer" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		usr, err := token.CheckToken(tokenArr[1], m.Secretkey)
+		if err != nil || usr.UserID != sessionUserID {
+			m.Logger.Debugf("middleware: optional auth: bad access token: %s", tokenArr[1])
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := r.Context()
+		ctx = context.WithValue(ctx, UserContextKey, usr)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
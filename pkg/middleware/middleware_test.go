@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"redditclone/pkg/session"
+	"redditclone/pkg/session/mocks"
+	"redditclone/pkg/token"
+	"redditclone/pkg/user"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCheckAuthSessionOwner(t *testing.T) {
+	type testCase struct {
+		session    string
+		statusCode int
+		response   string
+	}
+
+	usr := user.User{Username: "test", UserID: 1}
+	accessToken, err := token.GetToken(usr, "secret")
+	require.NoError(t, err)
+	timeTo := time.Now().Add(time.Hour).Unix()
+
+	databaseSession := &mocks.DatabaseSession{}
+	databaseSession.On("GetSession", "own").Return(usr.UserID, timeTo, nil).Once()
+	databaseSession.On("GetSession", "own").Return(int64(0), int64(0), sql.ErrNoRows)
+	databaseSession.On("GetSession", "other").Return(int64(2), timeTo, nil)
+	databaseSession.On("UpdateAuth", mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil)
+	databaseSession.On("RemoveUserTokens", usr.UserID, "").Return(nil)
+	authorization := session.InitSessionManager(databaseSession)
+
+	logger := zap.NewNop().Sugar()
+	m := Middleware{Authorization: authorization, Logger: logger, Secretkey: "secret"}
+	handler := m.CheckAuth(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	testCases := []testCase{
+		{
+			session:    "own",
+			statusCode: http.StatusOK,
+		},
+		{
+			session:    "other",
+			statusCode: http.StatusUnauthorized,
+			response:   "{\"message\":\"leave and login again\"}\n",
+		},
+	}
+	check := func(testCase testCase) {
+		r := httptest.NewRequest("GET", "/api/user/me", nil)
+		r.Header.Set("Authorization", "Bearer "+accessToken)
+		r.AddCookie(&http.Cookie{Name: "session_id", Value: testCase.session})
+		w := httptest.NewRecorder()
+
+		handler(w, r)
+
+		resp := w.Result()
+		body, errRead := ioutil.ReadAll(resp.Body)
+		require.NoError(t, errRead)
+		require.Equal(t, resp.StatusCode, testCase.statusCode)
+		require.Equal(t, string(body), testCase.response)
+	}
+	for _, testCase := range testCases {
+		check(testCase)
+	}
+
+	require.NoError(t, authorization.RevokeAll(usr.UserID))
+	check(testCase{
+		session:    "own",
+		statusCode: http.StatusUnauthorized,
+		response:   "{\"message\":\"leave and login again\"}\n",
+	})
+}
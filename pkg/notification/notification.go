@@ -0,0 +1,42 @@
+package notification
+
+import (
+	"redditclone/pkg/user"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	KindPostReply      = "post_reply"
+	KindCommentReply   = "comment_reply"
+	KindPostMention    = "post_mention"
+	KindCommentMention = "comment_mention"
+
+	snippetLen = 100
+)
+
+type Notification struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID    int64              `json:"-" bson:"user_id"`
+	Kind      string             `json:"kind" bson:"kind"`
+	From      user.User          `json:"from" bson:"from"`
+	PostID    uint64             `json:"postId,string" bson:"post_id"`
+	CommentID uint64             `json:"commentId,string" bson:"comment_id"`
+	Text      string             `json:"text" bson:"text"`
+	Read      bool               `json:"read" bson:"read"`
+	Created   time.Time          `json:"created" bson:"created"`
+}
+
+type Inbox struct {
+	Unread        uint64         `json:"unread"`
+	Notifications []Notification `json:"notifications"`
+}
+
+func Snippet(text string) string {
+	if utf8.RuneCountInString(text) <= snippetLen {
+		return text
+	}
+	return string([]rune(text)[:snippetLen]) + "..."
+}
@@ -0,0 +1,109 @@
+package poll
+
+import (
+	"math"
+	"redditclone/pkg/user"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MinOptions   = 2
+	MaxOptions   = 10
+	maxOptionLen = 100
+)
+
+type Option struct {
+	Text       string `json:"text"`
+	Votes      uint64 `json:"votes" bson:"-"`
+	Percentage int64  `json:"percentage" bson:"-"`
+}
+
+type Vote struct {
+	UserID int64 `bson:"user_id"`
+	Option int   `bson:"option"`
+}
+
+// Poll lives in the post document. Only the choices of voters are stored,
+// counts are computed for every viewer by ForViewer.
+type Poll struct {
+	Options     []Option   `json:"options"`
+	ClosesAt    *time.Time `json:"closesAt,omitempty" bson:"closes_at,omitempty"`
+	HideResults bool       `json:"hideResults,omitempty" bson:"hide_results,omitempty"`
+	Voters      []Vote     `json:"-" bson:"voters"`
+
+	TotalVotes    uint64 `json:"totalVotes" bson:"-"`
+	MyChoice      *int   `json:"myChoice,omitempty" bson:"-"`
+	Closed        bool   `json:"closed" bson:"-"`
+	ResultsHidden bool   `json:"resultsHidden,omitempty" bson:"-"`
+}
+
+// Check trims option texts and returns a problem with the poll as it is sent
+// by a client, "" if there is none.
+func (p *Poll) Check(now time.Time) string {
+	if len(p.Options) < MinOptions || len(p.Options) > MaxOptions {
+		return "must have from 2 to 10 options"
+	}
+	for i := range p.Options {
+		text := strings.TrimSpace(p.Options[i].Text)
+		if text == "" || utf8.RuneCountInString(text) > maxOptionLen {
+			return "options must be from 1 to 100 characters long"
+		}
+		p.Options[i] = Option{Text: text}
+	}
+	if p.ClosesAt != nil && !p.ClosesAt.After(now) {
+		return "closing time must be in the future"
+	}
+	p.Voters = nil
+	return ""
+}
+
+func (p *Poll) IsClosed(now time.Time) bool {
+	return p.ClosesAt != nil && !p.ClosesAt.After(now)
+}
+
+// Vote casts or changes the vote of the user.
+func (p *Poll) Vote(userID int64, option int) {
+	for i := range p.Voters {
+		if p.Voters[i].UserID == userID {
+			p.Voters[i].Option = option
+			return
+		}
+	}
+	p.Voters = append(p.Voters, Vote{UserID: userID, Option: option})
+}
+
+// ForViewer returns a copy of the poll with votes counted for viewer (nil for
+// anonymous visitors). With HideResults only the author and users who voted
+// see counts until the poll is closed. Never save the result.
+func (p Poll) ForViewer(viewer *user.User, author user.User, now time.Time) *Poll {
+	p.Options = append([]Option(nil), p.Options...)
+	p.Closed = p.IsClosed(now)
+	p.MyChoice = nil
+	counts := make([]uint64, len(p.Options))
+	for _, vote := range p.Voters {
+		if vote.Option >= 0 && vote.Option < len(counts) {
+			counts[vote.Option]++
+		}
+		if viewer != nil && vote.UserID == viewer.UserID {
+			choice := vote.Option
+			p.MyChoice = &choice
+		}
+	}
+	isAuthor := viewer != nil && viewer.UserID == author.UserID && viewer.Username == author.Username
+	p.ResultsHidden = p.HideResults && !p.Closed && p.MyChoice == nil && !isAuthor
+	p.TotalVotes = 0
+	if !p.ResultsHidden {
+		p.TotalVotes = uint64(len(p.Voters))
+	}
+	for i := range p.Options {
+		p.Options[i].Votes, p.Options[i].Percentage = 0, 0
+		if p.ResultsHidden || p.TotalVotes == 0 {
+			continue
+		}
+		p.Options[i].Votes = counts[i]
+		p.Options[i].Percentage = int64(math.Round(float64(counts[i]) * 100 / float64(p.TotalVotes)))
+	}
+	return &p
+}
@@ -0,0 +1,70 @@
+package poll
+
+import (
+	"redditclone/pkg/user"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestForViewer(t *testing.T) {
+	type testCase struct {
+		viewer   *user.User
+		closesAt *time.Time
+		result   *Poll
+	}
+
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	author := user.User{Username: "author", UserID: 1}
+	voter := user.User{Username: "voter", UserID: 2}
+	choice := 2
+	counted := []Option{{Text: "a", Votes: 1, Percentage: 33}, {Text: "b"}, {Text: "c", Votes: 2, Percentage: 67}}
+	hidden := []Option{{Text: "a"}, {Text: "b"}, {Text: "c"}}
+
+	testCases := []testCase{
+		{
+			viewer: nil,
+			result: &Poll{Options: hidden, HideResults: true, ResultsHidden: true},
+		},
+		{
+			viewer: &user.User{Username: "other", UserID: 3},
+			result: &Poll{Options: hidden, HideResults: true, ResultsHidden: true},
+		},
+		{
+			viewer: &author,
+			result: &Poll{Options: counted, HideResults: true, TotalVotes: 3},
+		},
+		{
+			viewer: &voter,
+			result: &Poll{Options: counted, HideResults: true, TotalVotes: 3, MyChoice: &choice},
+		},
+		{
+			viewer:   nil,
+			closesAt: &past,
+			result:   &Poll{Options: counted, HideResults: true, ClosesAt: &past, TotalVotes: 3, Closed: true},
+		},
+	}
+
+	for _, testCase := range testCases {
+		p := Poll{
+			Options:     []Option{{Text: "a"}, {Text: "b"}, {Text: "c"}},
+			ClosesAt:    testCase.closesAt,
+			HideResults: true,
+			Voters:      []Vote{{UserID: 5, Option: 0}, {UserID: 2, Option: 2}, {UserID: 6, Option: 2}},
+		}
+		result := p.ForViewer(testCase.viewer, author, now)
+		result.Voters = nil
+		require.Equal(t, result, testCase.result)
+		require.Equal(t, p.Options, hidden)
+	}
+}
+
+func TestVote(t *testing.T) {
+	p := Poll{Options: []Option{{Text: "a"}, {Text: "b"}}}
+	p.Vote(1, 0)
+	p.Vote(2, 1)
+	p.Vote(1, 1)
+	require.Equal(t, p.Voters, []Vote{{UserID: 1, Option: 1}, {UserID: 2, Option: 1}})
+}
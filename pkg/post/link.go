@@ -0,0 +1,78 @@
+package post
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+const maxURLLen = 2048
+
+// trackingParams are query parameters which don't change the target page and
+// are dropped from canonical URLs.
+var trackingParams = map[string]bool{
+	"fbclid": true,
+	"gclid":  true,
+	"yclid":  true,
+	"ref":    true,
+}
+
+// CanonicalURL validates a link and returns its canonical form together with
+// the registrable domain of its host, e.g. "news.bbc.co.uk" -> "bbc.co.uk".
+func CanonicalURL(raw string) (canonical, domain string, err error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || len(raw) > maxURLLen {
+		return "", "", fmt.Errorf("bad url length")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", err
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", "", fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	if u.User != nil {
+		return "", "", fmt.Errorf("url with credentials")
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return "", "", fmt.Errorf("url without host")
+	}
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		domain = ip.String()
+		if ip.To4() == nil {
+			host = "[" + host + "]"
+		}
+	} else {
+		domain, err = publicsuffix.EffectiveTLDPlusOne(host)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	u.Host = host
+	if port != "" {
+		u.Host += ":" + port
+	}
+	u.Fragment, u.RawFragment = "", ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	query := u.Query()
+	for key := range query {
+		if strings.HasPrefix(key, "utm_") || trackingParams[key] {
+			query.Del(key)
+		}
+	}
+	// Encode sorts the keys, so equal queries give equal URLs
+	u.RawQuery = query.Encode()
+	u.ForceQuery = false
+	return u.String(), domain, nil
+}
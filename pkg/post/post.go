@@ -3,16 +3,38 @@ package post
 import (
 	"math"
 	"redditclone/pkg/comment"
+	"redditclone/pkg/flair"
 	"redditclone/pkg/frontendMessages"
+	"redditclone/pkg/markdown"
+	"redditclone/pkg/media"
+	"redditclone/pkg/poll"
+	"redditclone/pkg/preview"
 	"redditclone/pkg/user"
+	"time"
+)
+
+const (
+	TypeText      = "text"
+	TypeLink      = "link"
+	TypeImage     = "image"
+	TypePoll      = "poll"
+	TypeCrosspost = "crosspost"
 )
 
 type Post struct {
 	Title            string                  `json:"title"`
 	Text             string                  `json:"text,omitempty"`
+	TextHTML         string                  `json:"textHtml,omitempty" bson:"text_html,omitempty"`
 	URL              string                  `json:"url,omitempty"`
+	Domain           string                  `json:"domain,omitempty" bson:"domain,omitempty"`
+	Preview          *preview.Preview        `json:"preview,omitempty" bson:"preview,omitempty"`
+	Image            *media.Image            `json:"image,omitempty" bson:"image,omitempty"`
+	Poll             *poll.Poll              `json:"poll,omitempty" bson:"poll,omitempty"`
+	Crosspost        *Origin                 `json:"crosspost,omitempty" bson:"crosspost,omitempty"`
 	Author           user.User               `json:"author"`
+	Mentions         []user.User             `json:"mentions,omitempty" bson:"mentions,omitempty"`
 	Category         string                  `json:"category"`
+	Flair            *flair.Flair            `json:"flair,omitempty" bson:"flair,omitempty"`
 	ID               uint64                  `json:"id,string"`
 	Time             string                  `json:"created"`
 	Score            int64                   `json:"score"`
@@ -20,8 +42,52 @@ type Post struct {
 	UpvotePercentage int64                   `json:"upvotePercentage"`
 	Type             string                  `json:"type"`
 	Votes            []frontendMessages.Vote `json:"votes"`
+	MyVote           int                     `json:"myVote" bson:"-"`
+	Hidden           bool                    `json:"hidden,omitempty" bson:"hidden,omitempty"`
+	Pinned           bool                    `json:"pinned,omitempty" bson:"pinned,omitempty"`
+	Locked           bool                    `json:"locked,omitempty" bson:"locked,omitempty"`
+	NSFW             bool                    `json:"nsfw,omitempty" bson:"nsfw,omitempty"`
+	Spoiler          bool                    `json:"spoiler,omitempty" bson:"spoiler,omitempty"`
+	Blurred          bool                    `json:"blurred,omitempty" bson:"-"`
+	Archived         bool                    `json:"archived,omitempty" bson:"archived,omitempty"`
+	PublishAt        *time.Time              `json:"publishAt,omitempty" bson:"publish_at,omitempty"`
 	Comments         []comment.Comment       `json:"comments"`
 	CommentID        uint64                  `json:"-"`
+	DeletedAt        *time.Time              `json:"-" bson:"deleted_at,omitempty"`
+	DeletedBy        *user.User              `json:"-" bson:"deleted_by,omitempty"`
+	DeleteReason     string                  `json:"-" bson:"delete_reason,omitempty"`
+	DuplicateOf      uint64                  `json:"duplicateOf,string,omitempty" bson:"-"`
+}
+
+// Origin describes the post a crosspost was made from.
+type Origin struct {
+	PostID   uint64    `json:"postId,string" bson:"post_id"`
+	Title    string    `json:"title" bson:"title"`
+	URL      string    `json:"url,omitempty" bson:"url,omitempty"`
+	Category string    `json:"category" bson:"category"`
+	Author   user.User `json:"author" bson:"author"`
+	// Removed is set once the original post is deleted
+	Removed bool `json:"removed,omitempty" bson:"removed,omitempty"`
+}
+
+type Filter struct {
+	Category string
+	Username string
+	Domain   string
+	// Flair is the text of the flair posts must have
+	Flair string
+	// Scheduled lists posts waiting for publication instead of published ones
+	Scheduled bool
+	// Archive lists posts moved to the archive collection
+	Archive bool
+	// IDs limits the listing to these posts, returned in the order of IDs
+	IDs     []uint64
+	Exclude []uint64
+	// Blocked holds ids of users blocked by the viewer
+	Blocked []int64
+	Viewer  *user.User
+	// Preferences of the viewer, zero value shows everything
+	Preferences user.Preferences
 }
 
 /*
@@ -73,3 +139,155 @@ func (p *Post) GetID() (res uint64) {
 	p.CommentID++
 	return
 }
+
+func (p *Post) MarkDeleted(by user.User, reason string) {
+	now := time.Now()
+	p.DeletedAt = &now
+	p.DeletedBy = &by
+	p.DeleteReason = reason
+}
+
+// ClosedReason returns why the post accepts no more comments and votes, ""
+// if it still does.
+func (p *Post) ClosedReason() string {
+	if p.PublishAt != nil {
+		return "this post is not published yet"
+	}
+	if p.Archived {
+		return "this post is archived"
+	}
+	if p.Locked {
+		return "this post is locked"
+	}
+	return ""
+}
+
+func (p *Post) Restore() {
+	p.DeletedAt = nil
+	p.DeletedBy = nil
+	p.DeleteReason = ""
+}
+
+// RenameAuthor replaces from with to wherever from is the author of the post,
+// its comments or the crossposted original, is mentioned in them or removed
+// them, and reports whether anything changed.
+func (p *Post) RenameAuthor(from, to user.User) (changed bool) {
+	if p.Author == from {
+		p.Author = to
+		changed = true
+	}
+	if p.Crosspost != nil && p.Crosspost.Author == from {
+		p.Crosspost.Author = to
+		changed = true
+	}
+	changed = renameUsers(p.Mentions, from, to) || changed
+	if p.DeletedBy != nil && *p.DeletedBy == from {
+		p.DeletedBy = &to
+		changed = true
+	}
+	for i := range p.Comments {
+		cmt := &p.Comments[i]
+		if cmt.Author == from {
+			cmt.Author = to
+			changed = true
+		}
+		changed = renameUsers(cmt.Mentions, from, to) || changed
+		if cmt.DeletedBy != nil && *cmt.DeletedBy == from {
+			cmt.DeletedBy = &to
+			changed = true
+		}
+	}
+	return
+}
+
+func renameUsers(users []user.User, from, to user.User) (changed bool) {
+	for i := range users {
+		if users[i] == from {
+			users[i] = to
+			changed = true
+		}
+	}
+	return
+}
+
+// ApplyPreferences blurs the post if prefs ask for it and reports whether
+// the post may be shown at all.
+func (p *Post) ApplyPreferences(prefs user.Preferences) bool {
+	modes := []string{}
+	if p.NSFW {
+		modes = append(modes, prefs.NSFW)
+	}
+	if p.Spoiler {
+		modes = append(modes, prefs.Spoiler)
+	}
+	for _, mode := range modes {
+		switch mode {
+		case user.ContentHide:
+			return false
+		case user.ContentBlur:
+			p.Blurred = true
+		}
+	}
+	return true
+}
+
+// DropBlocked removes comments of blocked users, comments with replies
+// stay as empty placeholders to keep threads whole. Call it after ForViewer.
+func (p *Post) DropBlocked(blocked []int64) {
+	if len(blocked) == 0 || p.Comments == nil {
+		return
+	}
+	isBlocked := map[int64]bool{}
+	for _, userID := range blocked {
+		isBlocked[userID] = true
+	}
+	hasReplies := map[uint64]bool{}
+	for _, cmt := range p.Comments {
+		if cmt.Parent != nil {
+			hasReplies[*cmt.Parent] = true
+		}
+	}
+	comments := make([]comment.Comment, 0, len(p.Comments))
+	for _, cmt := range p.Comments {
+		if isBlocked[cmt.Author.UserID] {
+			if !hasReplies[cmt.ID] {
+				continue
+			}
+			cmt = comment.Comment{ID: cmt.ID, Parent: cmt.Parent, Time: cmt.Time, Blocked: true}
+		}
+		comments = append(comments, cmt)
+	}
+	p.Comments = comments
+}
+
+// Render stores the HTML of the text, so it is rendered once on write
+// instead of on every read. Call it whenever Text changes.
+func (p *Post) Render() {
+	p.TextHTML = markdown.Render(p.Text)
+}
+
+// ForViewer prepares the post for sending to viewer (nil for anonymous visitors):
+// it fills MyVote, renders Markdown saved without HTML, counts poll votes, drops
+// votes of other users, deleted comments and bodies of hidden comments. Never
+// save the result.
+func (p *Post) ForViewer(viewer *user.User) {
+	p.Votes, p.MyVote = frontendMessages.ViewerVotes(p.Votes, viewer)
+	if p.TextHTML == "" {
+		p.Render()
+	}
+	if p.Poll != nil {
+		p.Poll = p.Poll.ForViewer(viewer, p.Author, time.Now())
+	}
+	if p.Comments == nil {
+		return
+	}
+	comments := make([]comment.Comment, 0, len(p.Comments))
+	for _, cmt := range p.Comments {
+		if cmt.DeletedAt != nil {
+			continue
+		}
+		cmt.ForViewer(viewer)
+		comments = append(comments, cmt)
+	}
+	p.Comments = comments
+}
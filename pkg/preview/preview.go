@@ -0,0 +1,239 @@
+package preview
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	maxRedirects      = 5
+	maxTitleLen       = 300
+	maxDescriptionLen = 1000
+)
+
+type Preview struct {
+	Title       string `json:"title,omitempty" bson:"title,omitempty"`
+	Description string `json:"description,omitempty" bson:"description,omitempty"`
+	Image       string `json:"image,omitempty" bson:"image,omitempty"`
+}
+
+func (p Preview) Empty() bool {
+	return p.Title == "" && p.Description == "" && p.Image == ""
+}
+
+type Fetcher interface {
+	Fetch(ctx context.Context, link string) (Preview, error)
+}
+
+// HTTPFetcher reads OpenGraph and HTML meta tags of a page. It refuses to
+// connect to loopback, private and link-local addresses, the check is done
+// on the resolved address, so redirects and DNS tricks don't bypass it.
+type HTTPFetcher struct {
+	client   *http.Client
+	maxBytes int64
+	// AllowPrivate turns off the address check, it is meant for tests only
+	AllowPrivate bool
+}
+
+func NewHTTPFetcher(timeout time.Duration, maxBytes int64) *HTTPFetcher {
+	f := &HTTPFetcher{maxBytes: maxBytes}
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if f.AllowPrivate {
+				return nil
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublic(ip) {
+				return fmt.Errorf("preview: address %s is not allowed", host)
+			}
+			return nil
+		},
+	}
+	f.client = &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       time.Minute,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("preview: too many redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("preview: bad redirect scheme %q", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+	return f
+}
+
+var privateNets = func() []*net.IPNet {
+	res := []*net.IPNet{}
+	for _, cidr := range []string{
+		"0.0.0.0/8",
+		"100.64.0.0/10",
+		"192.0.0.0/24",
+		"198.18.0.0/15",
+		"240.0.0.0/4",
+		"64:ff9b::/96",
+	} {
+		_, ipNet, _ := net.ParseCIDR(cidr)
+		res = append(res, ipNet)
+	}
+	return res
+}()
+
+func isPublic(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, ipNet := range privateNets {
+		if ipNet.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *HTTPFetcher) Fetch(ctx context.Context, link string) (Preview, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return Preview{}, err
+	}
+	req.Header.Set("Accept", "text/html")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return Preview{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Preview{}, fmt.Errorf("preview: bad status %d", resp.StatusCode)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return Preview{}, fmt.Errorf("preview: bad content type %q", mediaType)
+	}
+	return parse(io.LimitReader(resp.Body, f.maxBytes), resp.Request.URL), nil
+}
+
+// parse reads the head of the page, OpenGraph tags win over plain ones.
+func parse(r io.Reader, base *url.URL) Preview {
+	var (
+		res, fallback Preview
+		inTitle       bool
+	)
+	tokenizer := html.NewTokenizer(r)
+loop:
+	for {
+		tt := tokenizer.Next()
+		switch tt {
+		case html.ErrorToken:
+			break loop
+		case html.TextToken:
+			if inTitle && fallback.Title == "" {
+				fallback.Title = string(tokenizer.Text())
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				break loop
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = tt == html.StartTagToken
+			case "body":
+				break loop
+			case "meta":
+				attrs := map[string]string{}
+				for hasAttr {
+					var key, value []byte
+					key, value, hasAttr = tokenizer.TagAttr()
+					attrs[string(key)] = string(value)
+				}
+				content := attrs["content"]
+				switch strings.ToLower(attrs["property"] + attrs["name"]) {
+				case "og:title":
+					res.Title = content
+				case "og:description":
+					res.Description = content
+				case "og:image", "og:image:url", "og:image:secure_url":
+					if res.Image == "" {
+						res.Image = content
+					}
+				case "twitter:title":
+					fallback.Title = content
+				case "description", "twitter:description":
+					fallback.Description = content
+				case "twitter:image":
+					fallback.Image = content
+				}
+			}
+		}
+	}
+	if res.Title == "" {
+		res.Title = fallback.Title
+	}
+	if res.Description == "" {
+		res.Description = fallback.Description
+	}
+	if res.Image == "" {
+		res.Image = fallback.Image
+	}
+	res.Title = truncate(res.Title, maxTitleLen)
+	res.Description = truncate(res.Description, maxDescriptionLen)
+	res.Image = resolve(base, res.Image)
+	return res
+}
+
+func truncate(text string, limit int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	return string([]rune(text)[:limit])
+}
+
+// resolve makes image links absolute and drops everything but http(s) ones.
+func resolve(base *url.URL, link string) string {
+	link = strings.TrimSpace(link)
+	if link == "" {
+		return ""
+	}
+	ref, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	abs := base.ResolveReference(ref)
+	if abs.Scheme != "http" && abs.Scheme != "https" {
+		return ""
+	}
+	return abs.String()
+}
@@ -0,0 +1,140 @@
+package preview
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetch(t *testing.T) {
+	type testCase struct {
+		path     string
+		preview  Preview
+		hasError bool
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/og", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<!DOCTYPE html><html><head>
+			<title>Plain title</title>
+			<meta name="description" content="plain description">
+			<meta property="og:title" content="OG title">
+			<meta property="og:description" content="OG   description">
+			<meta property="og:image" content="/img/a.png">
+			</head><body><meta property="og:title" content="body title"></body></html>`))
+	})
+	mux.HandleFunc("/plain", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title> Plain
+			title </title><meta name="description" content="plain description">
+			<meta name="twitter:image" content="javascript:alert(1)"></head></html>`))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/og", http.StatusFound)
+	})
+	mux.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	mux.HandleFunc("/big", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><head><title>" + strings.Repeat("a", 2000) + `</title><meta property="og:title" content="late">`))
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.Header().Set("Content-Type", "text/html")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	testCases := []testCase{
+		{
+			path: "/og",
+			preview: Preview{
+				Title:       "OG title",
+				Description: "OG description",
+				Image:       server.URL + "/img/a.png",
+			},
+		},
+		{
+			path: "/redirect",
+			preview: Preview{
+				Title:       "OG title",
+				Description: "OG description",
+				Image:       server.URL + "/img/a.png",
+			},
+		},
+		{
+			path:    "/plain",
+			preview: Preview{Title: "Plain title", Description: "plain description"},
+		},
+		{
+			path:    "/big",
+			preview: Preview{Title: strings.Repeat("a", maxTitleLen)},
+		},
+		{
+			path:     "/json",
+			hasError: true,
+		},
+		{
+			path:     "/missing",
+			hasError: true,
+		},
+		{
+			path:     "/slow",
+			hasError: true,
+		},
+	}
+
+	fetcher := NewHTTPFetcher(100*time.Millisecond, 1024)
+	fetcher.AllowPrivate = true
+	for _, testCase := range testCases {
+		res, err := fetcher.Fetch(context.Background(), server.URL+testCase.path)
+		if testCase.hasError {
+			require.Error(t, err, testCase.path)
+			continue
+		}
+		require.NoError(t, err, testCase.path)
+		require.Equal(t, res, testCase.preview, testCase.path)
+	}
+}
+
+func TestFetchPrivate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+	}))
+	defer server.Close()
+
+	fetcher := NewHTTPFetcher(time.Second, 1024)
+	_, err := fetcher.Fetch(context.Background(), server.URL)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is not allowed")
+}
+
+func TestIsPublic(t *testing.T) {
+	for ip, public := range map[string]bool{
+		"8.8.8.8":         true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"::1":             false,
+		"fc00::1":         false,
+		"fe80::1":         false,
+		"::ffff:10.0.0.1": false,
+	} {
+		require.Equal(t, isPublic(net.ParseIP(ip)), public, ip)
+	}
+}
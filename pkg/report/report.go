@@ -0,0 +1,33 @@
+package report
+
+import (
+	"redditclone/pkg/user"
+)
+
+const (
+	KindPost    = "post"
+	KindComment = "comment"
+)
+
+type Target struct {
+	Kind      string `json:"kind"`
+	PostID    uint64 `json:"postId,string"`
+	CommentID uint64 `json:"commentId,string,omitempty"`
+}
+
+type Report struct {
+	Target
+	ReporterID int64
+	Reason     string
+}
+
+type Item struct {
+	Target
+	Reports      int64      `json:"reports"`
+	Reasons      []string   `json:"reasons"`
+	LastReported string     `json:"lastReported"`
+	Author       *user.User `json:"author,omitempty"`
+	Text         string     `json:"text,omitempty"`
+	Hidden       bool       `json:"hidden"`
+	Missing      bool       `json:"missing,omitempty"`
+}
@@ -11,13 +11,13 @@ type DatabaseSession struct {
 	mock.Mock
 }
 
-// AddToken provides a mock function with given fields: token, _a1
-func (_m *DatabaseSession) AddToken(token string, _a1 int64) error {
-	ret := _m.Called(token, _a1)
+// AddToken provides a mock function with given fields: token, userID, _a2
+func (_m *DatabaseSession) AddToken(token string, userID int64, _a2 int64) error {
+	ret := _m.Called(token, userID, _a2)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, int64) error); ok {
-		r0 = rf(token, _a1)
+	if rf, ok := ret.Get(0).(func(string, int64, int64) error); ok {
+		r0 = rf(token, userID, _a2)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -62,8 +62,8 @@ func (_m *DatabaseSession) GetAll() ([]*session.DatabaseRow, error) {
 	return r0, r1
 }
 
-// GetTime provides a mock function with given fields: token
-func (_m *DatabaseSession) GetTime(token string) (int64, error) {
+// GetSession provides a mock function with given fields: token
+func (_m *DatabaseSession) GetSession(token string) (int64, int64, error) {
 	ret := _m.Called(token)
 
 	var r0 int64
@@ -73,14 +73,21 @@ func (_m *DatabaseSession) GetTime(token string) (int64, error) {
 		r0 = ret.Get(0).(int64)
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(string) int64); ok {
 		r1 = rf(token)
 	} else {
-		r1 = ret.Error(1)
+		r1 = ret.Get(1).(int64)
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string) error); ok {
+		r2 = rf(token)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // RemoveToken provides a mock function with given fields: token
@@ -97,6 +104,20 @@ func (_m *DatabaseSession) RemoveToken(token string) error {
 	return r0
 }
 
+// RemoveUserTokens provides a mock function with given fields: userID, except
+func (_m *DatabaseSession) RemoveUserTokens(userID int64, except string) error {
+	ret := _m.Called(userID, except)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64, string) error); ok {
+		r0 = rf(userID, except)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateAuth provides a mock function with given fields: token, timeTo
 func (_m *DatabaseSession) UpdateAuth(token string, timeTo time.Time) error {
 	ret := _m.Called(token, timeTo)
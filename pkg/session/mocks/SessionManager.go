@@ -2,9 +2,8 @@
 
 package mocks
 
-import http "net/http"
 import mock "github.com/stretchr/testify/mock"
-
+import http "net/http"
 import zap "go.uber.org/zap"
 
 // SessionManager is an autogenerated mock type for the SessionManager type
@@ -12,13 +11,13 @@ type SessionManager struct {
 	mock.Mock
 }
 
-// AddAuth provides a mock function with given fields: w
-func (_m *SessionManager) AddAuth(w http.ResponseWriter) error {
-	ret := _m.Called(w)
+// AddAuth provides a mock function with given fields: w, userID
+func (_m *SessionManager) AddAuth(w http.ResponseWriter, userID int64) error {
+	ret := _m.Called(w, userID)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(http.ResponseWriter) error); ok {
-		r0 = rf(w)
+	if rf, ok := ret.Get(0).(func(http.ResponseWriter, int64) error); ok {
+		r0 = rf(w, userID)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -41,17 +40,24 @@ func (_m *SessionManager) CheckAllTimes(logger *zap.SugaredLogger) error {
 }
 
 // CheckAuth provides a mock function with given fields: r
-func (_m *SessionManager) CheckAuth(r *http.Request) error {
+func (_m *SessionManager) CheckAuth(r *http.Request) (int64, error) {
 	ret := _m.Called(r)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(*http.Request) error); ok {
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(*http.Request) int64); ok {
 		r0 = rf(r)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(int64)
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // Close provides a mock function with given fields:
@@ -68,6 +74,34 @@ func (_m *SessionManager) Close() error {
 	return r0
 }
 
+// RevokeAll provides a mock function with given fields: userID
+func (_m *SessionManager) RevokeAll(userID int64) error {
+	ret := _m.Called(userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64) error); ok {
+		r0 = rf(userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeOthers provides a mock function with given fields: r, userID
+func (_m *SessionManager) RevokeOthers(r *http.Request, userID int64) error {
+	ret := _m.Called(r, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*http.Request, int64) error); ok {
+		r0 = rf(r, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateAuth provides a mock function with given fields: w, r
 func (_m *SessionManager) UpdateAuth(w http.ResponseWriter, r *http.Request) error {
 	ret := _m.Called(w, r)
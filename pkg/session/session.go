@@ -18,8 +18,10 @@ const (
 
 type SessionManager interface {
 	CheckAllTimes(logger *zap.SugaredLogger) error
-	AddAuth(w http.ResponseWriter) error
-	CheckAuth(r *http.Request) error
+	AddAuth(w http.ResponseWriter, userID int64) error
+	CheckAuth(r *http.Request) (userID int64, err error)
+	RevokeOthers(r *http.Request, userID int64) error
+	RevokeAll(userID int64) error
 	UpdateAuth(w http.ResponseWriter, r *http.Request) error
 	Close() error
 }
@@ -59,10 +61,10 @@ func (s *SessionManagerStruct) CheckAllTimes(logger *zap.SugaredLogger) error {
 	return nil
 }
 
-func (s *SessionManagerStruct) AddAuth(w http.ResponseWriter) error {
+func (s *SessionManagerStruct) AddAuth(w http.ResponseWriter, userID int64) error {
 	authToken := token.RandStringRunes(tokenLen)
 
-	err := s.database.AddToken(authToken, time.Now().Add(AuthTime).Unix())
+	err := s.database.AddToken(authToken, userID, time.Now().Add(AuthTime).Unix())
 	if err != nil {
 		return err
 	}
@@ -76,27 +78,43 @@ func (s *SessionManagerStruct) AddAuth(w http.ResponseWriter) error {
 	return err
 }
 
-func (s *SessionManagerStruct) CheckAuth(r *http.Request) error {
+// CheckAuth checks the session of the request and returns the user it was
+// opened for.
+func (s *SessionManagerStruct) CheckAuth(r *http.Request) (userID int64, err error) {
 	sessionCookie, err := r.Cookie("session_id")
 	if err != nil {
-		return ErrorTokenNotFound{}
+		return 0, ErrorTokenNotFound{}
 	}
 	token := sessionCookie.Value
 	log.Printf("token: %s", token)
-	timeToken, err := s.database.GetTime(token)
+	userID, timeToken, err := s.database.GetSession(token)
 	log.Printf("err: %s", err)
 	log.Printf("timeTo: %d", timeToken)
 	if err != nil {
-		return ErrorTokenNotFound{}
+		return 0, ErrorTokenNotFound{}
 	}
 	if timeToken < time.Now().Unix() {
 
 		if err = s.database.RemoveToken(sessionCookie.Value); err != nil {
-			return err
+			return 0, err
 		}
-		return ErrorTokenIsExpired{}
+		return 0, ErrorTokenIsExpired{}
 	}
-	return nil
+	return userID, nil
+}
+
+// RevokeOthers removes every session of the user except the one the request
+// was made with.
+func (s *SessionManagerStruct) RevokeOthers(r *http.Request, userID int64) error {
+	sessionCookie, err := r.Cookie("session_id")
+	if err != nil {
+		return ErrorTokenNotFound{}
+	}
+	return s.database.RemoveUserTokens(userID, sessionCookie.Value)
+}
+
+func (s *SessionManagerStruct) RevokeAll(userID int64) error {
+	return s.database.RemoveUserTokens(userID, "")
 }
 
 func (s *SessionManagerStruct) UpdateAuth(w http.ResponseWriter, r *http.Request) error {
@@ -13,10 +13,11 @@ type DatabaseRow struct {
 }
 
 type DatabaseSession interface {
-	AddToken(token string, time int64) error
-	GetTime(token string) (time int64, err error)
+	AddToken(token string, userID int64, time int64) error
+	GetSession(token string) (userID int64, timeTo int64, err error)
 	GetAll() (res []*DatabaseRow, err error)
 	RemoveToken(token string) error
+	RemoveUserTokens(userID int64, except string) error
 	UpdateAuth(token string, timeTo time.Time) error
 	Close() error
 }
@@ -25,14 +26,14 @@ type DatabaseSessionStruct struct {
 	database *sql.DB
 }
 
-func (d *DatabaseSessionStruct) GetTime(token string) (time int64, err error) {
-	row := d.database.QueryRow("SELECT time_to FROM authorization WHERE token = ? LIMIT 1", token)
-	err = row.Scan(&time)
+func (d *DatabaseSessionStruct) GetSession(token string) (userID int64, timeTo int64, err error) {
+	row := d.database.QueryRow("SELECT user_id, time_to FROM authorization WHERE token = ? LIMIT 1", token)
+	err = row.Scan(&userID, &timeTo)
 	return
 }
 
 func (d *DatabaseSessionStruct) GetAll() (res []*DatabaseRow, err error) {
-	rows, err := d.database.Query("select * from authorization")
+	rows, err := d.database.Query("select token, time_to from authorization")
 	if err != nil {
 		return nil, fmt.Errorf("databaseSessionStruct: GetAll: %w", err)
 	}
@@ -50,9 +51,9 @@ func (d *DatabaseSessionStruct) GetAll() (res []*DatabaseRow, err error) {
 	return
 }
 
-func (d *DatabaseSessionStruct) AddToken(token string, time int64) error {
-	_, err := d.database.Exec("INSERT INTO authorization (`token`, `time_to`) VALUES (?, ?)",
-		token, time)
+func (d *DatabaseSessionStruct) AddToken(token string, userID int64, time int64) error {
+	_, err := d.database.Exec("INSERT INTO authorization (`token`, `user_id`, `time_to`) VALUES (?, ?, ?)",
+		token, userID, time)
 	return err
 }
 
@@ -62,6 +63,12 @@ func (d *DatabaseSessionStruct) RemoveToken(token string) error {
 	return err
 }
 
+func (d *DatabaseSessionStruct) RemoveUserTokens(userID int64, except string) error {
+	_, err := d.database.Exec("DELETE FROM authorization WHERE user_id = ? AND token <> ?",
+		userID, except)
+	return err
+}
+
 func (d *DatabaseSessionStruct) UpdateAuth(token string, timeTo time.Time) error {
 	_, err := d.database.Exec("UPDATE authorization SET time_to = ? WHERE token = ?",
 		timeTo.Unix(), token)
@@ -7,10 +7,48 @@ import (
 	"strconv"
 )
 
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// DeletedUsername replaces the author of posts and comments of deleted accounts.
+const DeletedUsername = "[deleted]"
+
+// Content modes tell how NSFW and spoiler posts are shown.
+const (
+	ContentShow = "show"
+	ContentBlur = "blur"
+	ContentHide = "hide"
+)
+
 type User struct {
 	Username     string `json:"username"`
 	PasswordHash string `json:"-" bson:"-"`
 	UserID       int64  `json:"id,string"`
+	// Email is optional and never shown to other users
+	Email         string `json:"-" bson:"-"`
+	EmailVerified bool   `json:"-" bson:"-"`
+}
+
+func CanModerate(role string) bool {
+	return role == RoleModerator || role == RoleAdmin
+}
+
+// Preferences hold content modes of a user for NSFW and spoiler posts.
+type Preferences struct {
+	NSFW    string `json:"nsfw"`
+	Spoiler string `json:"spoiler"`
+}
+
+// DefaultPreferences apply to anonymous visitors and new users.
+func DefaultPreferences() Preferences {
+	return Preferences{NSFW: ContentHide, Spoiler: ContentBlur}
+}
+
+func ValidContentMode(mode string) bool {
+	return mode == ContentShow || mode == ContentBlur || mode == ContentHide
 }
 
 func Md5Hash(data string) string {
@@ -26,3 +64,15 @@ func Crc32Hash(data string) string {
 func GetPasswordHash(password string) string {
 	return Crc32Hash(Md5Hash(password+"heh")) + "b" + Md5Hash(password)[:len(password)]
 }
+
+type Profile struct {
+	Username     string `json:"username"`
+	UserID       int64  `json:"id,string"`
+	Created      string `json:"created"`
+	Bio          string `json:"bio"`
+	AvatarURL    string `json:"avatar"`
+	PostKarma    int64  `json:"postKarma"`
+	CommentKarma int64  `json:"commentKarma"`
+	PostCount    uint64 `json:"postCount"`
+	CommentCount uint64 `json:"commentCount"`
+}